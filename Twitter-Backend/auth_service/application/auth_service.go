@@ -31,6 +31,8 @@ var (
 	smtpServerPort  = 5879
 	smtpEmail       = os.Getenv("SMTP_AUTH_MAIL")
 	smtpPassword    = os.Getenv("SMTP_AUTH_PASSWORD")
+	// moderators are promoted on their first login, there is no other way to create the first one
+	moderators = strings.Split(os.Getenv("MODERATOR_USERNAMES"), ",")
 )
 
 type AuthService struct {
@@ -143,6 +145,26 @@ func (service *AuthService) DeleteUserByID(ctx context.Context, id primitive.Obj
 	return service.store.DeleteUserByID(ctx, id)
 }
 
+// PromoteModerator gives an existing account the Moderator role, it is picked up on the next login
+func (service *AuthService) PromoteModerator(ctx context.Context, username string) error {
+	ctx, span := service.tracer.Start(ctx, "AuthService.PromoteModerator")
+	defer span.End()
+	service.logging.Infoln("AuthService.PromoteModerator : promoteModerator service reached")
+
+	user, err := service.store.GetOneUser(ctx, username)
+	if err != nil {
+		service.logging.Errorf("AuthService.PromoteModerator.GetOneUser() : %s", err)
+		return fmt.Errorf(errors.UserNotFound)
+	}
+
+	if user.UserType == domain.Moderator {
+		return nil
+	}
+
+	user.UserType = domain.Moderator
+	return service.store.UpdateUser(ctx, user)
+}
+
 func (service *AuthService) SendMail(ctx context.Context, user *domain.User) error {
 	ctx, span := service.tracer.Start(ctx, "AuthService.SendMail")
 	defer span.End()
@@ -189,7 +211,7 @@ func (service *AuthService) sendValidationMail(ctx context.Context, validationTo
 	}
 
 	if err := client.DialAndSend(message); err != nil {
-		service.logging.Errorln("AuthService.sendValidationMail : failed to send verification mail because of: %s", err)
+		service.logging.Errorf("AuthService.sendValidationMail : failed to send verification mail because of: %s", err)
 		log.Fatalf("failed to send verification mail because of: %s", err)
 		return err
 	}
@@ -428,6 +450,31 @@ func (service *AuthService) Login(ctx context.Context, credentials *domain.Crede
 		return "not_same", err
 	}
 
+	userServiceEndpoint := fmt.Sprintf("http://%s:%s/getOne/%s", userServiceHost, userServicePort, user.Username)
+	userServiceRequest, _ := http.NewRequest("GET", userServiceEndpoint, nil)
+	response, err := http.DefaultClient.Do(userServiceRequest)
+	if err != nil {
+		service.logging.Errorf("AuthService.Login : %s (user_service unavailable)", err)
+		return "", fmt.Errorf(errors.ServiceUnavailable)
+	}
+	defer response.Body.Close()
+
+	var userProfile domain.User
+	err = responseToType(response.Body, &userProfile)
+	if err == nil && userProfile.Suspended {
+		service.logging.Errorln("AuthService.Login : account is suspended")
+		return "", fmt.Errorf(errors.SuspendedUser)
+	}
+
+	if user.UserType != domain.Moderator && isSeededModerator(user.Username) {
+		user.UserType = domain.Moderator
+		err = service.store.UpdateUser(ctx, user)
+		if err != nil {
+			service.logging.Errorf("AuthService.Login.UpdateUser() : %s", err)
+			return "", err
+		}
+	}
+
 	tokenString, err := GenerateJWT(user)
 	if err != nil {
 		service.logging.Errorf("AuthService.Login.GenerateJWT() : %s", err)
@@ -599,6 +646,16 @@ func validateUserType(user *domain.User) (*domain.User, error) {
 	return nil, fmt.Errorf("invalid user data")
 }
 
+func isSeededModerator(username string) bool {
+	for _, moderator := range moderators {
+		if strings.TrimSpace(moderator) == username {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isBusiness(user *domain.User) bool {
 	if len(user.CompanyName) >= 3 &&
 		len(user.Website) >= 3 &&
@@ -71,12 +71,14 @@ func (o *CreateUserOrchestrator) Start(ctx context.Context, user *domain.User) e
 	return o.commandPublisher.Publish(event)
 }
 
-func (o *CreateUserOrchestrator) handle(reply *events.CreateUserReply) {
+// handle returns a failed publish, so the reply is redelivered and the command sent again
+func (o *CreateUserOrchestrator) handle(reply *events.CreateUserReply) error {
 	command := events.CreateUserCommand{User: reply.User}
 	command.Type = o.nextCommandType(*reply)
 	if command.Type != events.UnknownCommand {
-		_ = o.commandPublisher.Publish(command)
+		return o.commandPublisher.Publish(command)
 	}
+	return nil
 }
 
 func (o *CreateUserOrchestrator) nextCommandType(reply events.CreateUserReply) events.CreateUserCommandType {
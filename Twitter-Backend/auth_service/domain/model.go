@@ -21,6 +21,7 @@ type User struct {
 	Password   string             `bson:"password" json:"password" validate:"onlyCharAndNum,required"`
 	UserType   UserType           `bson:"userType" json:"userType" validate:"onlyChar"`
 	Visibility bool               `bson:"visibility" json:"visibility"`
+	Suspended  bool               `bson:"suspended,omitempty" json:"suspended,omitempty"`
 
 	CompanyName string `bson:"companyName,omitempty" json:"companyName,omitempty" validation:"onlyCharAndNum"`
 	Website     string `bson:"website,omitempty" json:"website,omitempty" validate:"onlyCharAndNum"`
@@ -36,8 +37,9 @@ const (
 type UserType string
 
 const (
-	Regular  = "Regular"
-	Business = "Business"
+	Regular   = "Regular"
+	Business  = "Business"
+	Moderator = "Moderator"
 )
 
 type Credentials struct {
@@ -12,4 +12,6 @@ const (
 	UsernameAlreadyExist      = "username already exists in database"
 	EmailAlreadyExist         = "email already exists in database"
 	ServiceUnavailable        = "service is unavailable at the moment"
+	SuspendedUser             = "account is suspended"
+	UserNotFound              = "user doesn't exist"
 )
@@ -64,6 +64,7 @@ func (handler *AuthHandler) Init(router *mux.Router) {
 	router.HandleFunc("/checkRecoverToken", handler.CheckRecoveryPasswordToken).Methods("POST")
 	router.HandleFunc("/recoverPassword", handler.RecoverPassword).Methods("POST")
 	router.HandleFunc("/changePassword", handler.ChangePassword).Methods("POST")
+	router.HandleFunc("/moderators/{username}", handler.PromoteModerator).Methods("PUT")
 	http.Handle("/", router)
 	log.Fatal(http.ListenAndServe(":8003", authorization.Authorizer(authEnforcer)(router)))
 
@@ -268,6 +269,11 @@ func (handler *AuthHandler) Login(writer http.ResponseWriter, req *http.Request)
 			http.Error(writer, token, http.StatusLocked)
 			return
 		}
+		if err.Error() == errors.SuspendedUser {
+			handler.logging.Errorf("AuthHandler.Login : %s", err)
+			http.Error(writer, err.Error(), http.StatusForbidden)
+			return
+		}
 		handler.logging.Errorf("AuthHandler.Login : %s (username not exist)", err)
 		http.Error(writer, "Username not exist!", http.StatusBadRequest)
 		return
@@ -343,6 +349,28 @@ func (handler *AuthHandler) ChangePassword(writer http.ResponseWriter, req *http
 	writer.WriteHeader(http.StatusOK)
 }
 
+func (handler *AuthHandler) PromoteModerator(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "AuthHandler.PromoteModerator")
+	defer span.End()
+
+	handler.logging.Infoln("AuthHandler.PromoteModerator : Endpoint promoteModerator reached")
+
+	username := mux.Vars(req)["username"]
+
+	err := handler.service.PromoteModerator(ctx, username)
+	if err != nil {
+		if err.Error() == errors.UserNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		handler.logging.Errorf("AuthHandler.PromoteModerator : %s", err)
+		http.Error(writer, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
 func ExtractTraceInfoMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	events "github.com/zjalicf/twitter-clone-common/common/saga/create_user"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"go.opentelemetry.io/otel/trace"
 	"log"
 )
@@ -32,8 +33,9 @@ func NewCreateUserCommandHandler(authService *application.AuthService, publisher
 	return o, nil
 }
 
-// hendlovanje komandama
-func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand) {
+// hendlovanje komandama. A failure is returned to have the command redelivered, the saga is
+// only rolled back when the last delivery fails
+func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand, delivery jetstream.Delivery) error {
 
 	user := handler.authService.UserToDomain(command.User)
 	reply := events.CreateUserReply{User: command.User}
@@ -48,6 +50,9 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 		err := handler.authService.SendMail(context.Background(), &user)
 		if err != nil {
 			log.Printf("Failed to send mail: %s", err.Error())
+			if !delivery.Last {
+				return err
+			}
 			reply.Type = events.MailFailed
 		} else {
 			reply.Type = events.MailSent
@@ -56,7 +61,10 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 
 	case events.RollbackAuth:
 		//TODO
-		_ = handler.authService.DeleteUserByID(context.Background(), user.ID)
+		err := handler.authService.DeleteUserByID(context.Background(), user.ID)
+		if err != nil && !delivery.Last {
+			return err
+		}
 		reply.Type = events.UnknownReply
 		fmt.Println("Rollback auth")
 
@@ -65,6 +73,7 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 	}
 
 	if reply.Type != events.UnknownReply {
-		_ = handler.publisher.Publish(reply)
+		return handler.publisher.Publish(reply)
 	}
+	return nil
 }
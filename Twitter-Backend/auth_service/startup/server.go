@@ -12,6 +12,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/nats"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.opentelemetry.io/otel"
@@ -112,12 +113,12 @@ func (server *Server) Start() {
 	//saga init
 
 	//orchestrator
-	commandPublisher := server.initPublisher(server.config.CreateUserCommandSubject)
-	replySubscriber := server.initSubscriber(server.config.CreateUserReplySubject, QueueGroup)
+	commandPublisher := server.initSagaPublisher(server.config.CreateUserCommandSubject)
+	replySubscriber := server.initSagaSubscriber(server.config.CreateUserReplySubject, QueueGroup)
 
 	//service
-	replyPublisher := server.initPublisher(server.config.CreateUserReplySubject)
-	commandSubscriber := server.initSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
+	replyPublisher := server.initSagaPublisher(server.config.CreateUserReplySubject)
+	commandSubscriber := server.initSagaSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
 
 	createUserOrchestrator := server.initCreateUserOrchestrator(commandPublisher, replySubscriber, tracer)
 
@@ -185,6 +186,28 @@ func (server *Server) initSubscriber(subject string, queueGroup string) saga.Sub
 	return subscriber
 }
 
+// initSagaPublisher publishes to a JetStream stream, saga messages are kept until the
+// services handling them are up
+func (server *Server) initSagaPublisher(subject string) saga.Publisher {
+	publisher, err := jetstream.NewJetStreamPublisher(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return publisher
+}
+
+func (server *Server) initSagaSubscriber(subject string, queueGroup string) saga.Subscriber {
+	subscriber, err := jetstream.NewJetStreamSubscriber(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject, queueGroup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return subscriber
+}
+
 func (server *Server) initCreateUserOrchestrator(publisher saga.Publisher, subscriber saga.Subscriber, tracer trace.Tracer) *application.CreateUserOrchestrator {
 	orchestrator, err := application.NewCreateUserOrchestrator(publisher, subscriber, tracer)
 	if err != nil {
@@ -0,0 +1,55 @@
+package jetstream
+
+import (
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"strings"
+	"time"
+)
+
+const (
+	//messages are kept for a week, long enough for a service that was down to catch up
+	streamMaxAge = 7 * 24 * time.Hour
+
+	//a message is delivered at most maxDeliver times, waiting longer before every redelivery
+	maxDeliver = 5
+)
+
+var backOff = []time.Duration{10 * time.Second, 30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+// getJetStream connects to NATS and makes sure the stream of the subject exists. Every
+// subject is kept in a stream of its own, so its publishers and subscribers create the
+// same stream whichever of them starts first
+func getJetStream(host, port, user, password, subject string) (*nats.Conn, nats.JetStreamContext, error) {
+	url := fmt.Sprintf("nats://%s:%s@%s:%s", user, password, host, port)
+	connection, err := nats.Connect(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	js, err := connection.JetStream()
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+
+	_, err = js.StreamInfo(streamName(subject))
+	if err == nats.ErrStreamNotFound {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:      streamName(subject),
+			Subjects:  []string{subject},
+			Storage:   nats.FileStorage,
+			Retention: nats.LimitsPolicy,
+			MaxAge:    streamMaxAge,
+		})
+	}
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+	return connection, js, nil
+}
+
+// streamName names the stream of the subject, stream names can't hold dots
+func streamName(subject string) string {
+	return strings.ReplaceAll(subject, ".", "_")
+}
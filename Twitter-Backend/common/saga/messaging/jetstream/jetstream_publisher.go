@@ -0,0 +1,44 @@
+package jetstream
+
+import (
+	"encoding/json"
+	"github.com/nats-io/nats.go"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+)
+
+// Publisher publishes JSON messages to a JetStream stream, Publish returns once the stream
+// has stored the message
+type Publisher struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+func NewJetStreamPublisher(host, port, user, password, subject string) (saga.Publisher, error) {
+	conn, js, err := getJetStream(host, port, user, password, subject)
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		conn:    conn,
+		js:      js,
+		subject: subject,
+	}, nil
+}
+
+func (p *Publisher) Publish(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = p.js.Publish(p.subject, data)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection of the publisher
+func (p *Publisher) Close() {
+	p.conn.Close()
+}
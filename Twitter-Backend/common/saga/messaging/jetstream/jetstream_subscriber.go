@@ -0,0 +1,146 @@
+package jetstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"log"
+	"reflect"
+)
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	deliveryType = reflect.TypeOf(Delivery{})
+)
+
+// Delivery tells a handler taking it as its second argument how often the message was
+// delivered. A handler failing on the Last delivery won't get the message again, so that is
+// where it gives up on it
+type Delivery struct {
+	Attempt uint64
+	Last    bool
+}
+
+// Subscriber delivers the messages of a JetStream stream to a durable consumer shared by the
+// queue group, so messages published while the service was down are handled once it is back.
+// A message is acked once the handler returns. A handler that panics or returns an error
+// gets the message again after a backoff, until it was delivered maxDeliver times
+type Subscriber struct {
+	conn       *nats.Conn
+	js         nats.JetStreamContext
+	subject    string
+	queueGroup string
+}
+
+func NewJetStreamSubscriber(host, port, user, password, subject, queueGroup string) (saga.Subscriber, error) {
+	conn, js, err := getJetStream(host, port, user, password, subject)
+	if err != nil {
+		return nil, err
+	}
+	return &Subscriber{
+		conn:       conn,
+		js:         js,
+		subject:    subject,
+		queueGroup: queueGroup,
+	}, nil
+}
+
+// Subscribe takes a func with one argument the messages are decoded into, like the handlers
+// of an encoded connection, and optionally the Delivery. It may return an error to have the
+// message redelivered
+func (s *Subscriber) Subscribe(handler interface{}) error {
+	handle, err := decodingHandler(handler)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.js.QueueSubscribe(s.subject, s.queueGroup, func(msg *nats.Msg) {
+		s.deliver(msg, handle)
+	},
+		nats.Durable(s.queueGroup),
+		nats.DeliverAll(),
+		nats.ManualAck(),
+		nats.AckExplicit(),
+		nats.MaxDeliver(maxDeliver),
+		nats.BackOff(backOff))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Subscriber) deliver(msg *nats.Msg, handle func(data []byte, delivery Delivery) error) {
+	var delivered uint64 = 1
+	metadata, err := msg.Metadata()
+	if err == nil {
+		delivered = metadata.NumDelivered
+	}
+
+	err = handle(msg.Data, Delivery{Attempt: delivered, Last: delivered >= maxDeliver})
+	if err == nil {
+		_ = msg.Ack()
+		return
+	}
+
+	if delivered >= maxDeliver {
+		log.Printf("Error in jetstream subscriber %s, message dropped after %d deliveries: %s", s.subject, delivered, err.Error())
+		_ = msg.Term()
+		return
+	}
+
+	log.Printf("Error in jetstream subscriber %s, delivery %d: %s", s.subject, delivered, err.Error())
+	_ = msg.NakWithDelay(backOff[delivered-1])
+}
+
+// Close closes the connection of the subscriber, the durable consumer stays and keeps the
+// messages published until a subscriber of the queue group is back
+func (s *Subscriber) Close() {
+	s.conn.Close()
+}
+
+// decodingHandler wraps the handler in a func decoding the JSON message into its argument
+func decodingHandler(handler interface{}) (func(data []byte, delivery Delivery) error, error) {
+	value := reflect.ValueOf(handler)
+	handlerType := value.Type()
+	if handlerType.Kind() != reflect.Func || handlerType.NumIn() < 1 || handlerType.NumIn() > 2 ||
+		(handlerType.NumIn() == 2 && handlerType.In(1) != deliveryType) || handlerType.NumOut() > 1 ||
+		(handlerType.NumOut() == 1 && handlerType.Out(0) != errorType) {
+		return nil, fmt.Errorf("jetstream handler must be a func with one argument and optionally the Delivery, returning nothing or an error")
+	}
+	argType := handlerType.In(0)
+
+	return func(data []byte, delivery Delivery) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("handler panicked: %v", recovered)
+			}
+		}()
+
+		var arg reflect.Value
+		if argType.Kind() == reflect.Ptr {
+			arg = reflect.New(argType.Elem())
+		} else {
+			arg = reflect.New(argType)
+		}
+		err = json.Unmarshal(data, arg.Interface())
+		if err != nil {
+			//a message that can't be decoded won't decode when it is redelivered either
+			log.Printf("Error in jetstream subscriber, message dropped: %s", err.Error())
+			return nil
+		}
+		if argType.Kind() != reflect.Ptr {
+			arg = arg.Elem()
+		}
+
+		args := []reflect.Value{arg}
+		if handlerType.NumIn() == 2 {
+			args = append(args, reflect.ValueOf(delivery))
+		}
+		out := value.Call(args)
+		if len(out) == 1 && !out[0].IsNil() {
+			return out[0].Interface().(error)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,224 @@
+package jetstream
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats-server/v2/test"
+)
+
+const (
+	testUser     = "saga"
+	testPassword = "saga"
+	testGroup    = "test_service"
+)
+
+type testMessage struct {
+	Text string
+}
+
+type delivered struct {
+	message  testMessage
+	delivery Delivery
+	at       time.Time
+}
+
+// runServer starts an embedded NATS server with JetStream, its store is removed with the test
+func runServer(t *testing.T) (string, string) {
+	t.Helper()
+
+	opts := test.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	opts.Username = testUser
+	opts.Password = testPassword
+	natsServer := test.RunServer(&opts)
+	t.Cleanup(natsServer.Shutdown)
+
+	address := natsServer.Addr().(*net.TCPAddr)
+	return "127.0.0.1", strconv.Itoa(address.Port)
+}
+
+// shortBackOff keeps redeliveries within milliseconds for the test
+func shortBackOff(t *testing.T) {
+	t.Helper()
+
+	previous := backOff
+	backOff = []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond, 200 * time.Millisecond}
+	t.Cleanup(func() { backOff = previous })
+}
+
+func newSubscriber(t *testing.T, host, port, subject string) *Subscriber {
+	t.Helper()
+
+	subscriber, err := NewJetStreamSubscriber(host, port, testUser, testPassword, subject, testGroup)
+	if err != nil {
+		t.Fatalf("NewJetStreamSubscriber() error = %v", err)
+	}
+	return subscriber.(*Subscriber)
+}
+
+func publish(t *testing.T, host, port, subject string, texts ...string) {
+	t.Helper()
+
+	publisher, err := NewJetStreamPublisher(host, port, testUser, testPassword, subject)
+	if err != nil {
+		t.Fatalf("NewJetStreamPublisher() error = %v", err)
+	}
+	defer publisher.(*Publisher).Close()
+
+	for _, text := range texts {
+		err = publisher.Publish(testMessage{Text: text})
+		if err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+}
+
+func receive(t *testing.T, deliveries <-chan delivered) delivered {
+	t.Helper()
+
+	select {
+	case received := <-deliveries:
+		return received
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message delivered")
+		return delivered{}
+	}
+}
+
+func TestDurableDeliveryAcrossSubscriberRestart(t *testing.T) {
+	host, port := runServer(t)
+	subject := "test.durable"
+	deliveries := make(chan delivered, 10)
+	handler := func(message testMessage, delivery Delivery) {
+		deliveries <- delivered{message: message, delivery: delivery}
+	}
+
+	first := newSubscriber(t, host, port, subject)
+	if err := first.Subscribe(handler); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	publish(t, host, port, subject, "before restart")
+	if got := receive(t, deliveries).message.Text; got != "before restart" {
+		t.Fatalf("delivered %q, want %q", got, "before restart")
+	}
+	first.Close()
+
+	publish(t, host, port, subject, "while down")
+
+	restarted := newSubscriber(t, host, port, subject)
+	defer restarted.Close()
+	if err := restarted.Subscribe(handler); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	received := receive(t, deliveries)
+	if received.message.Text != "while down" {
+		t.Fatalf("delivered %q after restart, want %q", received.message.Text, "while down")
+	}
+	if received.delivery.Attempt != 1 {
+		t.Errorf("Attempt = %d, want 1", received.delivery.Attempt)
+	}
+}
+
+func TestFailedMessageIsRedeliveredWithBackOff(t *testing.T) {
+	shortBackOff(t)
+	host, port := runServer(t)
+	subject := "test.backoff"
+	deliveries := make(chan delivered, 10)
+	handler := func(message *testMessage, delivery Delivery) error {
+		deliveries <- delivered{message: *message, delivery: delivery, at: time.Now()}
+		if delivery.Attempt < 3 {
+			return fmt.Errorf("attempt %d failed", delivery.Attempt)
+		}
+		return nil
+	}
+
+	subscriber := newSubscriber(t, host, port, subject)
+	defer subscriber.Close()
+	if err := subscriber.Subscribe(handler); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	publish(t, host, port, subject, "retried")
+
+	var received []delivered
+	for i := 0; i < 3; i++ {
+		received = append(received, receive(t, deliveries))
+	}
+
+	for i, r := range received {
+		if r.delivery.Attempt != uint64(i+1) {
+			t.Errorf("delivery %d has Attempt = %d", i+1, r.delivery.Attempt)
+		}
+		if r.delivery.Last {
+			t.Errorf("delivery %d is marked as the last one", i+1)
+		}
+		if i > 0 && r.at.Sub(received[i-1].at) < backOff[i-1] {
+			t.Errorf("delivery %d came after %s, want at least %s", i+1, r.at.Sub(received[i-1].at), backOff[i-1])
+		}
+	}
+
+	select {
+	case extra := <-deliveries:
+		t.Errorf("acked message delivered again, attempt %d", extra.delivery.Attempt)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestMessageIsTerminatedAfterMaxDeliver(t *testing.T) {
+	shortBackOff(t)
+	host, port := runServer(t)
+	subject := "test.max_deliver"
+	deliveries := make(chan delivered, 10)
+	var mutex sync.Mutex
+	panicked := false
+	handler := func(message testMessage, delivery Delivery) error {
+		deliveries <- delivered{message: message, delivery: delivery}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !panicked {
+			//a panic counts as a failure like a returned error
+			panicked = true
+			panic("handler panicked")
+		}
+		return fmt.Errorf("attempt %d failed", delivery.Attempt)
+	}
+
+	subscriber := newSubscriber(t, host, port, subject)
+	defer subscriber.Close()
+	if err := subscriber.Subscribe(handler); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	publish(t, host, port, subject, "never handled")
+
+	for i := 1; i <= maxDeliver; i++ {
+		received := receive(t, deliveries)
+		if received.delivery.Attempt != uint64(i) {
+			t.Errorf("delivery %d has Attempt = %d", i, received.delivery.Attempt)
+		}
+		if received.delivery.Last != (i == maxDeliver) {
+			t.Errorf("delivery %d has Last = %t", i, received.delivery.Last)
+		}
+	}
+
+	select {
+	case extra := <-deliveries:
+		t.Fatalf("message delivered again after maxDeliver, attempt %d", extra.delivery.Attempt)
+	case <-time.After(time.Second):
+	}
+
+	info, err := subscriber.js.ConsumerInfo(streamName(subject), testGroup)
+	if err != nil {
+		t.Fatalf("ConsumerInfo() error = %v", err)
+	}
+	if info.NumAckPending != 0 {
+		t.Errorf("NumAckPending = %d, want the terminated message to be done", info.NumAckPending)
+	}
+}
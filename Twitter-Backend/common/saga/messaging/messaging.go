@@ -0,0 +1,9 @@
+package saga
+
+type Publisher interface {
+	Publish(message interface{}) error
+}
+
+type Subscriber interface {
+	Subscribe(function interface{}) error
+}
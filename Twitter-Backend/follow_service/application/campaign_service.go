@@ -0,0 +1,225 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+const (
+	maxCampaignNameLength = 50
+	// maxAdsPerFeed caps the campaigns charged for one feed
+	maxAdsPerFeed = 3
+	businessUser  = "Business"
+	// interestWindow is how long engaging with a hashtag counts as an interest in it
+	interestWindow = 30 * 24 * time.Hour
+	// maxAdTargets caps the accounts and the hashtags one ad targets
+	maxAdTargets = 10
+)
+
+type CampaignService struct {
+	store   domain.FollowRequestStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewCampaignService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *CampaignService {
+	return &CampaignService{
+		store:   store,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (service *CampaignService) CreateCampaign(ctx context.Context, advertiser string, userType string, campaign *domain.Campaign) error {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.CreateCampaign")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.CreateCampaign : CreateCampaign reached")
+
+	if userType != businessUser {
+		return fmt.Errorf(errors.ErrorNotBusiness)
+	}
+
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if !validCampaign(campaign) {
+		return fmt.Errorf(errors.InvalidCampaign)
+	}
+
+	campaign.ID = uuid.New().String()
+	campaign.Advertiser = advertiser
+	campaign.Status = domain.CampaignActive
+	campaign.Spent = 0
+	campaign.SpentToday = 0
+	campaign.CreatedAt = time.Now().Unix()
+	campaign.Ads = 0
+
+	err := service.store.SaveCampaign(ctx, campaign)
+	if err != nil {
+		service.logging.Errorf("CampaignService.CreateCampaign.SaveCampaign() : %s", err)
+		return err
+	}
+
+	service.logging.Infoln("CampaignService.CreateCampaign : CreateCampaign successful")
+
+	return nil
+}
+
+// GetCampaign returns the campaign to its advertiser, anyone else gets a missing campaign
+func (service *CampaignService) GetCampaign(ctx context.Context, viewer string, id string) (*domain.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.GetCampaign")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.GetCampaign : GetCampaign reached")
+
+	campaign, err := service.store.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.Advertiser != viewer {
+		return nil, fmt.Errorf(errors.ErrorCampaignNotExists)
+	}
+
+	return campaign, nil
+}
+
+func (service *CampaignService) GetCampaignsOfUser(ctx context.Context, advertiser string) ([]*domain.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.GetCampaignsOfUser")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.GetCampaignsOfUser : GetCampaignsOfUser reached")
+
+	return service.store.GetCampaignsOfUser(ctx, advertiser)
+}
+
+// UpdateCampaign changes the name, schedule, budgets and bid, the status is changed by pausing and resuming
+func (service *CampaignService) UpdateCampaign(ctx context.Context, viewer string, id string, update *domain.Campaign) (*domain.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.UpdateCampaign")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.UpdateCampaign : UpdateCampaign reached")
+
+	campaign, err := service.GetCampaign(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Name = strings.TrimSpace(update.Name)
+	campaign.StartAt = update.StartAt
+	campaign.EndAt = update.EndAt
+	campaign.DailyBudget = update.DailyBudget
+	campaign.TotalBudget = update.TotalBudget
+	campaign.Bid = update.Bid
+	if !validCampaign(campaign) {
+		return nil, fmt.Errorf(errors.InvalidCampaign)
+	}
+
+	err = service.store.UpdateCampaign(ctx, campaign)
+	if err != nil {
+		service.logging.Errorf("CampaignService.UpdateCampaign.UpdateCampaign() : %s", err)
+		return nil, err
+	}
+
+	service.logging.Infoln("CampaignService.UpdateCampaign : UpdateCampaign successful")
+
+	return campaign, nil
+}
+
+func (service *CampaignService) PauseCampaign(ctx context.Context, viewer string, id string) (*domain.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.PauseCampaign")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.PauseCampaign : PauseCampaign reached")
+
+	return service.setStatus(ctx, viewer, id, domain.CampaignPaused)
+}
+
+func (service *CampaignService) ResumeCampaign(ctx context.Context, viewer string, id string) (*domain.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx, "CampaignService.ResumeCampaign")
+	defer span.End()
+
+	service.logging.Infoln("CampaignService.ResumeCampaign : ResumeCampaign reached")
+
+	return service.setStatus(ctx, viewer, id, domain.CampaignActive)
+}
+
+func (service *CampaignService) setStatus(ctx context.Context, viewer string, id string, status domain.CampaignStatus) (*domain.Campaign, error) {
+	campaign, err := service.GetCampaign(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.Status == status {
+		return campaign, nil
+	}
+
+	campaign.Status = status
+	err = service.store.UpdateCampaign(ctx, campaign)
+	if err != nil {
+		service.logging.Errorf("CampaignService.setStatus.UpdateCampaign() : %s", err)
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+func validCampaign(campaign *domain.Campaign) bool {
+	length := len([]rune(campaign.Name))
+	return length > 0 && length <= maxCampaignNameLength &&
+		campaign.StartAt > 0 && campaign.EndAt > campaign.StartAt &&
+		campaign.Bid > 0 && campaign.DailyBudget >= campaign.Bid &&
+		campaign.TotalBudget >= campaign.DailyBudget
+}
+
+// newAdServing describes serving ads to username at now. Daily budgets are paced over the UTC day
+func newAdServing(username string, now time.Time) *domain.AdServing {
+	utc := now.UTC()
+	midnight := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+
+	return &domain.AdServing{
+		Username:     username,
+		Now:          now.Unix(),
+		Day:          utc.Format("2006-01-02"),
+		DayFraction:  utc.Sub(midnight).Seconds() / (24 * time.Hour).Seconds(),
+		EngagedSince: now.Add(-interestWindow).Unix(),
+		Limit:        maxAdsPerFeed,
+	}
+}
+
+// normalizeTargeting trims the targeted accounts and hashtags and drops duplicates, hashtags are
+// kept in lower case with their # like tweet_service reports them
+func normalizeTargeting(ad *domain.Ad) bool {
+	ad.FollowersOf = distinct(ad.FollowersOf, func(username string) string {
+		return strings.TrimPrefix(strings.TrimSpace(username), "@")
+	})
+	ad.Hashtags = distinct(ad.Hashtags, func(hashtag string) string {
+		hashtag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(hashtag), "#"))
+		if hashtag == "" {
+			return ""
+		}
+		return "#" + hashtag
+	})
+
+	return len(ad.FollowersOf) <= maxAdTargets && len(ad.Hashtags) <= maxAdTargets && ad.FrequencyCap >= 0
+}
+
+func distinct(values []string, normalize func(string) string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, value := range values {
+		value = normalize(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
@@ -8,18 +8,29 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/zjalicf/twitter-clone-common/common/saga/create_user"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
 	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+// secondDegreeLimit caps the second degree followings handed to the ranked feed
+const secondDegreeLimit = 20
+
 type FollowService struct {
 	store   domain.FollowRequestStore
+	users   *UserClient
+	tweets  *TweetClient
+	events  saga.Publisher
 	tracer  trace.Tracer
 	logging *logrus.Logger
 }
 
-func NewFollowService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *FollowService {
+func NewFollowService(store domain.FollowRequestStore, users *UserClient, tweets *TweetClient, events saga.Publisher, tracer trace.Tracer, logging *logrus.Logger) *FollowService {
 	return &FollowService{
 		store:   store,
+		users:   users,
+		tweets:  tweets,
+		events:  events,
 		tracer:  tracer,
 		logging: logging,
 	}
@@ -34,7 +45,9 @@ func (service *FollowService) FollowExist(ctx context.Context, followRequest *do
 	return service.store.FollowExist(ctx, followRequest)
 }
 
-func (service *FollowService) GetFeedInfoOfUser(ctx context.Context, username string) (*domain.FeedInfo, error) {
+// GetFeedInfoOfUser returns the users whose tweets make up the feed, the ranked feed
+// also gets second degree followings as candidates
+func (service *FollowService) GetFeedInfoOfUser(ctx context.Context, username string, ranked bool) (*domain.FeedInfo, error) {
 	ctx, span := service.tracer.Start(ctx, "FollowService.GetFeedInfoOfUser")
 	defer span.End()
 
@@ -48,18 +61,52 @@ func (service *FollowService) GetFeedInfoOfUser(ctx context.Context, username st
 
 	followings = append(followings, username)
 
-	recommendAds, err := service.store.GetRecommendAdsId(ctx, username)
+	recommendAds, err := service.store.GetRecommendAdsId(ctx, newAdServing(username, time.Now()))
 	if err != nil {
 		service.logging.Errorf("FollowService.GetFollowingsOfUser.GetRecommendAdsId() : %s", err)
 		return nil, err
 	}
 
-	service.logging.Infoln("FollowService.GetFeedInfoOfUser : GetFeedInfoOfUser successful")
-
-	return &domain.FeedInfo{
+	feedInfo := domain.FeedInfo{
 		Usernames: followings,
 		AdIds:     recommendAds,
-	}, nil
+	}
+
+	if ranked {
+		feedInfo.SecondDegree, err = service.publicSecondDegree(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	service.logging.Infoln("FollowService.GetFeedInfoOfUser : GetFeedInfoOfUser successful")
+
+	return &feedInfo, nil
+}
+
+// publicSecondDegree returns the second degree followings of the user that are public now,
+// the graph only knows the privacy users had when they registered
+func (service *FollowService) publicSecondDegree(ctx context.Context, username string) ([]domain.Connection, error) {
+	connections, err := service.store.GetSecondDegreeFollowings(ctx, username, secondDegreeLimit)
+	if err != nil {
+		service.logging.Errorf("FollowService.GetFeedInfoOfUser.GetSecondDegreeFollowings() : %s", err)
+		return nil, err
+	}
+
+	public := make([]domain.Connection, 0, len(connections))
+	for _, connection := range connections {
+		private, err := service.users.IsPrivate(ctx, connection.Username)
+		if err != nil {
+			//an account that can't be checked is left out rather than risking a private one
+			service.logging.Errorf("FollowService.GetFeedInfoOfUser.IsPrivate() : %s", err)
+			continue
+		}
+		if !private {
+			public = append(public, connection)
+		}
+	}
+
+	return public, nil
 }
 
 func (service *FollowService) GetFollowingsOfUser(ctx context.Context, username string) ([]string, error) {
@@ -153,6 +200,7 @@ func (service *FollowService) CreateRequest(ctx context.Context, request *domain
 			service.logging.Errorf("FollowService.CreateRequest.SaveFollow() : %s", err)
 			return err
 		}
+		service.publishFollowEvent(request.Requester, request.Receiver, domain.Followed)
 	}
 
 	service.logging.Infoln("FollowService.CreateRequest : create_request successful")
@@ -194,6 +242,7 @@ func (service *FollowService) AcceptRequest(ctx context.Context, id *string) err
 		service.logging.Errorf("FollowService.AcceptRequest.SaveFollow() : %s", err)
 		return fmt.Errorf(errors.ErrorInSaveFollow)
 	}
+	service.publishFollowEvent(request.Requester, request.Receiver, domain.Followed)
 
 	service.logging.Infoln("FollowService.AcceptRequest : accept_request successful")
 
@@ -201,6 +250,49 @@ func (service *FollowService) AcceptRequest(ctx context.Context, id *string) err
 
 }
 
+func (service *FollowService) Unfollow(ctx context.Context, username string, followee string) error {
+	ctx, span := service.tracer.Start(ctx, "FollowService.Unfollow")
+	defer span.End()
+
+	service.logging.Infoln("FollowService.Unfollow : unfollow reached")
+
+	request := domain.FollowRequest{
+		Requester: username,
+		Receiver:  followee,
+	}
+
+	deleted, err := service.store.DeleteFollow(ctx, &request)
+	if err != nil {
+		service.logging.Errorf("FollowService.Unfollow.DeleteFollow() : %s", err)
+		return err
+	}
+
+	if !deleted {
+		return fmt.Errorf(errors.ErrorFollowNotExists)
+	}
+	service.publishFollowEvent(username, followee, domain.Unfollowed)
+
+	service.logging.Infoln("FollowService.Unfollow : unfollow successful")
+
+	return nil
+}
+
+// publishFollowEvent only logs failures, the follow itself is already saved and
+// timelines catch up with the followee's tweets on the next fan-out
+func (service *FollowService) publishFollowEvent(follower string, followee string, eventType domain.FollowEventType) {
+	event := domain.FollowEvent{
+		Follower:  follower,
+		Followee:  followee,
+		Type:      eventType,
+		Timestamp: time.Now().Unix(),
+	}
+
+	err := service.events.Publish(event)
+	if err != nil {
+		service.logging.Errorf("FollowService.publishFollowEvent.Publish() : %s", err)
+	}
+}
+
 func (service *FollowService) DeleteUser(ctx context.Context, id *string) error {
 	ctx, span := service.tracer.Start(ctx, "FollowService.AcceptRequest")
 	defer span.End()
@@ -228,12 +320,45 @@ func (service *FollowService) HandleRequest(ctx context.Context, followRequest *
 	return service.store.SaveRequest(ctx, followRequest)
 }
 
-func (service *FollowService) SaveAd(ctx context.Context, ad *domain.Ad) error {
+// SaveAd adds the ad to a campaign of the advertiser, only ads of campaigns are served. The
+// tweet has to be an advertisement the advertiser posted, it is read with the advertiser's token
+func (service *FollowService) SaveAd(ctx context.Context, token string, advertiser string, userType string, ad *domain.Ad) error {
 	ctx, span := service.tracer.Start(ctx, "FollowService.SaveAd")
 	defer span.End()
 
 	service.logging.Infoln("FollowService.SaveAd : SaveAd reached")
 
+	if userType != businessUser {
+		return fmt.Errorf(errors.ErrorNotBusiness)
+	}
+
+	if !normalizeTargeting(ad) {
+		return fmt.Errorf(errors.InvalidAdTargeting)
+	}
+
+	if ad.CampaignID == "" {
+		return fmt.Errorf(errors.ErrorCampaignRequired)
+	}
+
+	campaign, err := service.store.GetCampaign(ctx, ad.CampaignID)
+	if err != nil {
+		return err
+	}
+
+	if campaign.Advertiser != advertiser {
+		return fmt.Errorf(errors.ErrorNotCampaignOwner)
+	}
+
+	tweet, err := service.tweets.GetTweet(ctx, token, ad.TweetID)
+	if err != nil {
+		service.logging.Errorf("FollowService.SaveAd.GetTweet() : %s", err)
+		return err
+	}
+
+	if !tweet.Advertisement || tweet.Username != advertiser {
+		return fmt.Errorf(errors.ErrorNotAdTweet)
+	}
+
 	return service.store.SaveAd(ctx, ad)
 }
 
@@ -289,6 +414,7 @@ func (service *FollowService) UserToDomain(userIn create_user.User) domain.User
 	user.Residence = userIn.Residence
 	user.Username = userIn.Username
 	user.Gender = string(userIn.Gender)
+	user.Privacy = userIn.Visibility
 	if user.Age == 0 {
 		user.Gender = ""
 	}
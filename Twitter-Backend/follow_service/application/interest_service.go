@@ -0,0 +1,36 @@
+package application
+
+import (
+	"context"
+	"follow_service/domain"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InterestService keeps the hashtags users engage with, ads can target users by them
+type InterestService struct {
+	store   domain.FollowRequestStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewInterestService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *InterestService {
+	return &InterestService{
+		store:   store,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (service *InterestService) HandleEvent(ctx context.Context, event *domain.TweetEvent) error {
+	ctx, span := service.tracer.Start(ctx, "InterestService.HandleEvent")
+	defer span.End()
+
+	if len(event.Hashtags) == 0 || event.Username == "" {
+		return nil
+	}
+
+	service.logging.Infof("InterestService.HandleEvent : %s %s", event.Type, event.TweetID)
+
+	return service.store.SaveInterests(ctx, event.Username, event.Hashtags, event.Timestamp)
+}
@@ -0,0 +1,293 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+const maxListNameLength = 25
+
+type ListService struct {
+	store   domain.FollowRequestStore
+	users   *UserClient
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewListService(store domain.FollowRequestStore, users *UserClient, tracer trace.Tracer, logging *logrus.Logger) *ListService {
+	return &ListService{
+		store:   store,
+		users:   users,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (service *ListService) CreateList(ctx context.Context, owner string, list *domain.List) error {
+	ctx, span := service.tracer.Start(ctx, "ListService.CreateList")
+	defer span.End()
+
+	service.logging.Infoln("ListService.CreateList : CreateList reached")
+
+	list.Name = strings.TrimSpace(list.Name)
+	if !validListName(list.Name) {
+		return fmt.Errorf(errors.InvalidListName)
+	}
+
+	list.ID = uuid.New().String()
+	list.Owner = owner
+	list.CreatedAt = time.Now().Unix()
+	list.Members = 0
+
+	err := service.store.SaveList(ctx, list)
+	if err != nil {
+		service.logging.Errorf("ListService.CreateList.SaveList() : %s", err)
+		return err
+	}
+
+	service.logging.Infoln("ListService.CreateList : CreateList successful")
+
+	return nil
+}
+
+// GetList returns the list, a private list of someone else looks the same as a missing one
+func (service *ListService) GetList(ctx context.Context, viewer string, id string) (*domain.List, error) {
+	ctx, span := service.tracer.Start(ctx, "ListService.GetList")
+	defer span.End()
+
+	service.logging.Infoln("ListService.GetList : GetList reached")
+
+	list, err := service.store.GetList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.Private && list.Owner != viewer {
+		return nil, fmt.Errorf(errors.ErrorListNotExists)
+	}
+
+	return list, nil
+}
+
+func (service *ListService) GetListsOfUser(ctx context.Context, viewer string, owner string) ([]*domain.List, error) {
+	ctx, span := service.tracer.Start(ctx, "ListService.GetListsOfUser")
+	defer span.End()
+
+	service.logging.Infoln("ListService.GetListsOfUser : GetListsOfUser reached")
+
+	lists, err := service.store.GetListsOfUser(ctx, owner)
+	if err != nil {
+		service.logging.Errorf("ListService.GetListsOfUser.GetListsOfUser() : %s", err)
+		return nil, err
+	}
+
+	if viewer == owner {
+		return lists, nil
+	}
+
+	visible := []*domain.List{}
+	for _, list := range lists {
+		if !list.Private {
+			visible = append(visible, list)
+		}
+	}
+
+	return visible, nil
+}
+
+func (service *ListService) UpdateList(ctx context.Context, viewer string, id string, update *domain.List) (*domain.List, error) {
+	ctx, span := service.tracer.Start(ctx, "ListService.UpdateList")
+	defer span.End()
+
+	service.logging.Infoln("ListService.UpdateList : UpdateList reached")
+
+	list, err := service.ownedList(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Name = strings.TrimSpace(update.Name)
+	if !validListName(list.Name) {
+		return nil, fmt.Errorf(errors.InvalidListName)
+	}
+	list.Private = update.Private
+
+	err = service.store.UpdateList(ctx, list)
+	if err != nil {
+		service.logging.Errorf("ListService.UpdateList.UpdateList() : %s", err)
+		return nil, err
+	}
+
+	service.logging.Infoln("ListService.UpdateList : UpdateList successful")
+
+	return list, nil
+}
+
+func (service *ListService) DeleteList(ctx context.Context, viewer string, id string) error {
+	ctx, span := service.tracer.Start(ctx, "ListService.DeleteList")
+	defer span.End()
+
+	service.logging.Infoln("ListService.DeleteList : DeleteList reached")
+
+	_, err := service.ownedList(ctx, viewer, id)
+	if err != nil {
+		return err
+	}
+
+	return service.store.DeleteList(ctx, id)
+}
+
+// AddMember adds the account to the list, private accounts have to be followed by the owner first
+func (service *ListService) AddMember(ctx context.Context, viewer string, id string, username string) error {
+	ctx, span := service.tracer.Start(ctx, "ListService.AddMember")
+	defer span.End()
+
+	service.logging.Infoln("ListService.AddMember : AddMember reached")
+
+	list, err := service.ownedList(ctx, viewer, id)
+	if err != nil {
+		return err
+	}
+
+	if username != list.Owner {
+		private, err := service.users.IsPrivate(ctx, username)
+		if err != nil {
+			service.logging.Errorf("ListService.AddMember.IsPrivate() : %s", err)
+			return err
+		}
+
+		if private {
+			follows, err := service.store.FollowExist(ctx, &domain.FollowRequest{Requester: list.Owner, Receiver: username})
+			if err != nil {
+				service.logging.Errorf("ListService.AddMember.FollowExist() : %s", err)
+				return err
+			}
+			if !follows {
+				return fmt.Errorf(errors.ErrorMemberNotFollows)
+			}
+		}
+	}
+
+	added, err := service.store.AddListMember(ctx, id, username)
+	if err != nil {
+		service.logging.Errorf("ListService.AddMember.AddListMember() : %s", err)
+		return err
+	}
+	if !added {
+		return fmt.Errorf(errors.ErrorUserNotExists)
+	}
+
+	service.logging.Infoln("ListService.AddMember : AddMember successful")
+
+	return nil
+}
+
+func (service *ListService) RemoveMember(ctx context.Context, viewer string, id string, username string) error {
+	ctx, span := service.tracer.Start(ctx, "ListService.RemoveMember")
+	defer span.End()
+
+	service.logging.Infoln("ListService.RemoveMember : RemoveMember reached")
+
+	_, err := service.ownedList(ctx, viewer, id)
+	if err != nil {
+		return err
+	}
+
+	removed, err := service.store.RemoveListMember(ctx, id, username)
+	if err != nil {
+		service.logging.Errorf("ListService.RemoveMember.RemoveListMember() : %s", err)
+		return err
+	}
+	if !removed {
+		return fmt.Errorf(errors.ErrorUserNotExists)
+	}
+
+	return nil
+}
+
+func (service *ListService) GetMembers(ctx context.Context, viewer string, id string) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx, "ListService.GetMembers")
+	defer span.End()
+
+	service.logging.Infoln("ListService.GetMembers : GetMembers reached")
+
+	_, err := service.GetList(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.store.GetListMembers(ctx, id)
+}
+
+// GetListFeedInfo returns the members whose tweets the viewer may read in the list timeline.
+// Private members are left out unless the viewer follows them
+func (service *ListService) GetListFeedInfo(ctx context.Context, viewer string, id string) (*domain.FeedInfo, error) {
+	ctx, span := service.tracer.Start(ctx, "ListService.GetListFeedInfo")
+	defer span.End()
+
+	service.logging.Infoln("ListService.GetListFeedInfo : GetListFeedInfo reached")
+
+	members, err := service.GetMembers(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	followings, err := service.store.GetFollowingsOfUser(ctx, viewer)
+	if err != nil {
+		service.logging.Errorf("ListService.GetListFeedInfo.GetFollowingsOfUser() : %s", err)
+		return nil, err
+	}
+
+	followed := make(map[string]bool, len(followings)+1)
+	for _, following := range followings {
+		followed[following] = true
+	}
+	followed[viewer] = true
+
+	usernames := []string{}
+	for _, member := range members {
+		if !followed[member] {
+			private, err := service.users.IsPrivate(ctx, member)
+			if err != nil {
+				service.logging.Errorf("ListService.GetListFeedInfo.IsPrivate() : %s", err)
+				continue
+			}
+			if private {
+				continue
+			}
+		}
+		usernames = append(usernames, member)
+	}
+
+	service.logging.Infoln("ListService.GetListFeedInfo : GetListFeedInfo successful")
+
+	return &domain.FeedInfo{
+		Usernames: usernames,
+		AdIds:     []string{},
+	}, nil
+}
+
+func (service *ListService) ownedList(ctx context.Context, viewer string, id string) (*domain.List, error) {
+	list, err := service.GetList(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.Owner != viewer {
+		return nil, fmt.Errorf(errors.ErrorNotListOwner)
+	}
+
+	return list, nil
+}
+
+func validListName(name string) bool {
+	length := len([]rune(name))
+	return length > 0 && length <= maxListNameLength
+}
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"follow_service/errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AdTweet is the part of a tweet an ad is checked against
+type AdTweet struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Advertisement bool   `json:"advertisement"`
+}
+
+// TweetClient reads tweets from tweet_service on behalf of the user whose token is passed,
+// so tweets that user may not read are not found
+type TweetClient struct {
+	host   string
+	port   string
+	client *http.Client
+}
+
+func NewTweetClient(host string, port string) *TweetClient {
+	return &TweetClient{
+		host:   host,
+		port:   port,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (client *TweetClient) GetTweet(ctx context.Context, token string, id string) (*AdTweet, error) {
+	endpoint := fmt.Sprintf("http://%s:%s/getOneTweet/%s", client.host, client.port, url.PathEscape(id))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Authorization", token)
+
+	response, err := client.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf(errors.ServiceUnavailable)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf(errors.ErrorTweetNotExists)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(errors.ServiceUnavailable)
+	}
+
+	var tweet AdTweet
+	err = json.NewDecoder(response.Body).Decode(&tweet)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tweet, nil
+}
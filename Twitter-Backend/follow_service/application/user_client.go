@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"follow_service/errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// privacyTTL is how long a looked up privacy setting is trusted before asking user_service again
+const privacyTTL = time.Minute
+
+type cachedPrivacy struct {
+	private bool
+	expires time.Time
+}
+
+// UserClient asks user_service whether accounts are private. Answers are cached for a
+// short while because list timelines need one per member
+type UserClient struct {
+	host   string
+	port   string
+	client *http.Client
+	mutex  sync.Mutex
+	cache  map[string]cachedPrivacy
+}
+
+func NewUserClient(host string, port string) *UserClient {
+	return &UserClient{
+		host:   host,
+		port:   port,
+		client: &http.Client{Timeout: 5 * time.Second},
+		cache:  make(map[string]cachedPrivacy),
+	}
+}
+
+func (client *UserClient) IsPrivate(ctx context.Context, username string) (bool, error) {
+	client.mutex.Lock()
+	cached, ok := client.cache[username]
+	client.mutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.private, nil
+	}
+
+	endpoint := fmt.Sprintf("http://%s:%s/getOne/%s", client.host, client.port, url.PathEscape(username))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	response, err := client.client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf(errors.ServiceUnavailable)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return false, fmt.Errorf(errors.ErrorUserNotExists)
+	}
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf(errors.ServiceUnavailable)
+	}
+
+	var user struct {
+		Privacy bool `json:"privacy"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&user)
+	if err != nil {
+		return false, err
+	}
+
+	client.mutex.Lock()
+	client.cache[username] = cachedPrivacy{private: user.Privacy, expires: time.Now().Add(privacyTTL)}
+	client.mutex.Unlock()
+
+	return user.Privacy, nil
+}
@@ -0,0 +1,45 @@
+package domain
+
+type CampaignStatus string
+
+const (
+	CampaignActive CampaignStatus = "active"
+	CampaignPaused CampaignStatus = "paused"
+)
+
+// ads saved before campaigns existed go to the legacy campaign, it bids nothing and never ends.
+// It is the only campaign served without a bid
+const (
+	LegacyCampaignID = "legacy"
+	OpenCampaignEnd  = int64(253402300799)
+)
+
+// Campaign runs the ads of an advertiser between its start and end. Amounts are in cents,
+// the bid is paid for every ad served and spending is held to the daily and total budget
+type Campaign struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Advertiser  string         `json:"advertiser"`
+	StartAt     int64          `json:"start_at"`
+	EndAt       int64          `json:"end_at"`
+	DailyBudget int64          `json:"daily_budget"`
+	TotalBudget int64          `json:"total_budget"`
+	Bid         int64          `json:"bid"`
+	Status      CampaignStatus `json:"status"`
+	Spent       int64          `json:"spent"`
+	SpentToday  int64          `json:"spent_today"`
+	CreatedAt   int64          `json:"created_at"`
+	Ads         int            `json:"ads"`
+}
+
+// AdServing describes one request for ads. Day is the UTC day spending and frequency caps are
+// counted in and DayFraction the part of it that has passed, campaigns may not spend ahead of
+// that pace. Hashtags the user engaged with before EngagedSince no longer count as interests
+type AdServing struct {
+	Username     string
+	Now          int64
+	Day          string
+	DayFraction  float64
+	EngagedSince int64
+	Limit        int
+}
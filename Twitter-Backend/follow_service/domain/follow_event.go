@@ -0,0 +1,17 @@
+package domain
+
+type FollowEventType string
+
+const (
+	Followed   FollowEventType = "Followed"
+	Unfollowed FollowEventType = "Unfollowed"
+)
+
+// FollowEvent is published whenever a follow relationship is created or removed,
+// tweet_service uses it to keep the timelines of followers up to date
+type FollowEvent struct {
+	Follower  string          `json:"follower"`
+	Followee  string          `json:"followee"`
+	Type      FollowEventType `json:"type"`
+	Timestamp int64           `json:"timestamp"`
+}
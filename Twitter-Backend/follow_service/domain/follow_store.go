@@ -9,15 +9,31 @@ type FollowRequestStore interface {
 	GetRequestByRequesterReceiver(ctx context.Context, requester, receiver *string) (*FollowRequest, error)
 	GetFollowingsOfUser(ctx context.Context, username string) ([]string, error)
 	GetFollowersOfUser(ctx context.Context, username string) ([]string, error)
+	GetSecondDegreeFollowings(ctx context.Context, username string, limit int) ([]Connection, error)
 	SaveRequest(ctx context.Context, followRequest *FollowRequest) error
 	SaveFollow(ctx context.Context, request *FollowRequest) error
+	DeleteFollow(ctx context.Context, request *FollowRequest) (bool, error)
 	AcceptRequest(ctx context.Context, id *string) (*FollowRequest, error)
 	DeclineRequest(ctx context.Context, id *string) error
 	FollowExist(ctx context.Context, followRequest *FollowRequest) (bool, error)
 	UpdateRequest(ctx context.Context, request *FollowRequest) error
 	SaveAd(ctx context.Context, ad *Ad) error
-	GetRecommendAdsId(ctx context.Context, username string) ([]string, error)
+	GetRecommendAdsId(ctx context.Context, serving *AdServing) ([]string, error)
 	CountFollowings(ctx context.Context, username string) (int, error)
 	RecommendWithFollowings(ctx context.Context, username string) ([]string, error)
 	RecommendationWithoutFollowings(ctx context.Context, username string, recommends []string) ([]string, error)
+	SaveList(ctx context.Context, list *List) error
+	GetList(ctx context.Context, id string) (*List, error)
+	GetListsOfUser(ctx context.Context, owner string) ([]*List, error)
+	UpdateList(ctx context.Context, list *List) error
+	DeleteList(ctx context.Context, id string) error
+	AddListMember(ctx context.Context, id string, username string) (bool, error)
+	RemoveListMember(ctx context.Context, id string, username string) (bool, error)
+	GetListMembers(ctx context.Context, id string) ([]string, error)
+	SaveCampaign(ctx context.Context, campaign *Campaign) error
+	GetCampaign(ctx context.Context, id string) (*Campaign, error)
+	GetCampaignsOfUser(ctx context.Context, advertiser string) ([]*Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign *Campaign) error
+	MigrateLegacyAds(ctx context.Context) (int64, error)
+	SaveInterests(ctx context.Context, username string, hashtags []string, at int64) error
 }
@@ -0,0 +1,12 @@
+package domain
+
+// List is a named set of accounts whose tweets make up a timeline of their own.
+// Private lists are only visible to their owner
+type List struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Owner     string `json:"owner"`
+	Private   bool   `json:"private"`
+	CreatedAt int64  `json:"created_at"`
+	Members   int    `json:"members"`
+}
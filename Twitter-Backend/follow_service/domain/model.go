@@ -13,19 +13,36 @@ type User struct {
 	Age       int    `json:"age"`
 	Residence string `json:"residence"`
 	Gender    string `json:"gender"`
+	Privacy   bool   `json:"privacy"`
 }
 
+// Ad targets users by age, gender and residence. FollowersOf and Hashtags narrow it down to
+// followers of any of the accounts and users who engaged with any of the hashtags lately,
+// ExcludeFollowers leaves out followers of the advertiser and FrequencyCap limits how many
+// times a day one user is served the ad, zero means no limit
 type Ad struct {
-	TweetID   string `json:"tweet_id"`
-	AgeFrom   int    `json:"age_from"`
-	AgeTo     int    `json:"age_to"`
-	Gender    string `json:"gender"`
-	Residence string `json:"residence"`
+	TweetID          string   `json:"tweet_id"`
+	CampaignID       string   `json:"campaign_id"`
+	AgeFrom          int      `json:"age_from"`
+	AgeTo            int      `json:"age_to"`
+	Gender           string   `json:"gender"`
+	Residence        string   `json:"residence"`
+	FollowersOf      []string `json:"followers_of"`
+	Hashtags         []string `json:"hashtags"`
+	ExcludeFollowers bool     `json:"exclude_followers"`
+	FrequencyCap     int      `json:"frequency_cap"`
 }
 
 type FeedInfo struct {
-	Usernames []string `json:"usernames"`
-	AdIds     []string `json:"ad_ids"`
+	Usernames    []string     `json:"usernames"`
+	AdIds        []string     `json:"ad_ids"`
+	SecondDegree []Connection `json:"second_degree,omitempty"`
+}
+
+// Connection is a user followed by the user's followings, Mutual counts the followings in between
+type Connection struct {
+	Username string `json:"username"`
+	Mutual   int    `json:"mutual"`
 }
 
 type Status int
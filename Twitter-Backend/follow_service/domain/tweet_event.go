@@ -0,0 +1,11 @@
+package domain
+
+// TweetEvent is published by tweet_service for every tweet, favorite and retweet. Username
+// is the user who acted and Hashtags the hashtags of the tweet
+type TweetEvent struct {
+	Type      string   `json:"type"`
+	TweetID   string   `json:"tweet_id"`
+	Username  string   `json:"username"`
+	Hashtags  []string `json:"hashtags,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+}
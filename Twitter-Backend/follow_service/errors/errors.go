@@ -1,10 +1,24 @@
 package errors
 
 var (
-	ServiceUnavailable    = "service is unavailable at this moment, try again later"
-	BadRequestError       = "bad request"
-	ErrorInSaveFollow     = "save follow relationship error"
-	ErrorInAcceptRequest  = "update accept request error"
-	ErrorRequestNotExists = "request not exists"
-	InternalServerError   = "internal server error"
+	ServiceUnavailable     = "service is unavailable at this moment, try again later"
+	BadRequestError        = "bad request"
+	ErrorInSaveFollow      = "save follow relationship error"
+	ErrorInAcceptRequest   = "update accept request error"
+	ErrorRequestNotExists  = "request not exists"
+	ErrorFollowNotExists   = "follow not exists"
+	InternalServerError    = "internal server error"
+	ErrorListNotExists     = "list not exists"
+	ErrorUserNotExists     = "user not exists"
+	ErrorNotListOwner      = "only the owner can change the list"
+	ErrorMemberNotFollows  = "private accounts can only be added to a list after following them"
+	InvalidListName        = "list name must have between 1 and 25 characters"
+	ErrorCampaignNotExists = "campaign not exists"
+	ErrorNotCampaignOwner  = "only the advertiser can change the campaign"
+	ErrorNotBusiness       = "only business accounts can run campaigns"
+	InvalidCampaign        = "campaign needs a name, an end after its start, a positive bid and budgets of at least one bid with the daily budget within the total"
+	InvalidAdTargeting     = "ads can target at most 10 accounts and 10 hashtags and need a frequency cap of zero or more"
+	ErrorCampaignRequired  = "ads have to be added to a campaign"
+	ErrorTweetNotExists    = "tweet not exists"
+	ErrorNotAdTweet        = "only advertisement tweets of the advertiser can be ads"
 )
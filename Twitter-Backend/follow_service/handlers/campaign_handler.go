@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"follow_service/application"
+	"follow_service/authorization"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+type CampaignHandler struct {
+	service *application.CampaignService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewCampaignHandler(service *application.CampaignService, tracer trace.Tracer, logging *logrus.Logger) *CampaignHandler {
+	return &CampaignHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *CampaignHandler) Init(router *mux.Router) {
+	router.HandleFunc("/campaigns", handler.CreateCampaign).Methods("POST")
+	router.HandleFunc("/campaigns", handler.GetCampaignsOfUser).Methods("GET")
+	router.HandleFunc("/campaigns/{id}", handler.GetCampaign).Methods("GET")
+	router.HandleFunc("/campaigns/{id}", handler.UpdateCampaign).Methods("PUT")
+	router.HandleFunc("/campaigns/{id}/pause", handler.PauseCampaign).Methods("PUT")
+	router.HandleFunc("/campaigns/{id}/resume", handler.ResumeCampaign).Methods("PUT")
+}
+
+func (handler *CampaignHandler) CreateCampaign(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.CreateCampaign")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.CreateCampaign : create_campaign reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var campaign domain.Campaign
+	err := json.NewDecoder(req.Body).Decode(&campaign)
+	if err != nil {
+		handler.logging.Errorf("CampaignHandler.CreateCampaign.Decode() : %s", err)
+		http.Error(writer, errors.BadRequestError, http.StatusBadRequest)
+		return
+	}
+
+	err = handler.service.CreateCampaign(ctx, claims["username"], claims["userType"], &campaign)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	handler.logging.Infoln("CampaignHandler.CreateCampaign : create_campaign successful")
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(campaign, writer)
+}
+
+func (handler *CampaignHandler) GetCampaignsOfUser(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.GetCampaignsOfUser")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.GetCampaignsOfUser : get_campaigns_of_user reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	campaigns, err := handler.service.GetCampaignsOfUser(ctx, claims["username"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(campaigns, writer)
+}
+
+func (handler *CampaignHandler) GetCampaign(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.GetCampaign")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.GetCampaign : get_campaign reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	campaign, err := handler.service.GetCampaign(ctx, claims["username"], mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(campaign, writer)
+}
+
+func (handler *CampaignHandler) UpdateCampaign(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.UpdateCampaign")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.UpdateCampaign : update_campaign reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update domain.Campaign
+	err := json.NewDecoder(req.Body).Decode(&update)
+	if err != nil {
+		handler.logging.Errorf("CampaignHandler.UpdateCampaign.Decode() : %s", err)
+		http.Error(writer, errors.BadRequestError, http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := handler.service.UpdateCampaign(ctx, claims["username"], mux.Vars(req)["id"], &update)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(campaign, writer)
+}
+
+func (handler *CampaignHandler) PauseCampaign(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.PauseCampaign")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.PauseCampaign : pause_campaign reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	campaign, err := handler.service.PauseCampaign(ctx, claims["username"], mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(campaign, writer)
+}
+
+func (handler *CampaignHandler) ResumeCampaign(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "CampaignHandler.ResumeCampaign")
+	defer span.End()
+
+	handler.logging.Infoln("CampaignHandler.ResumeCampaign : resume_campaign reached")
+
+	claims, ok := handler.claims(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	campaign, err := handler.service.ResumeCampaign(ctx, claims["username"], mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(campaign, writer)
+}
+
+func (handler *CampaignHandler) claims(req *http.Request) (map[string]string, bool) {
+	token, err := authorization.GetToken(req)
+	if err != nil {
+		handler.logging.Errorf("CampaignHandler.GetToken() : %s", err)
+		return nil, false
+	}
+
+	claims := authorization.GetMapClaims(token.Bytes())
+	return claims, claims["username"] != ""
+}
+
+func (handler *CampaignHandler) writeError(writer http.ResponseWriter, err error) {
+	handler.logging.Errorf("CampaignHandler : %s", err)
+
+	switch err.Error() {
+	case errors.ErrorCampaignNotExists, errors.ErrorUserNotExists:
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.ErrorNotCampaignOwner, errors.ErrorNotBusiness:
+		http.Error(writer, err.Error(), http.StatusForbidden)
+	case errors.InvalidCampaign:
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(writer, errors.InternalServerError, http.StatusInternalServerError)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"follow_service/application"
 	events "github.com/zjalicf/twitter-clone-common/common/saga/create_user"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"go.opentelemetry.io/otel/trace"
 	"log"
 )
@@ -31,8 +32,9 @@ func NewCreateUserCommandHandler(followService *application.FollowService, publi
 	return o, nil
 }
 
-// hendlovanje komandama
-func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand) {
+// hendlovanje komandama. A failure is returned to have the command redelivered, the saga is
+// only rolled back when the last delivery fails
+func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand, delivery jetstream.Delivery) error {
 
 	user := handler.followService.UserToDomain(command.User)
 	reply := events.CreateUserReply{User: command.User}
@@ -42,6 +44,9 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 	case events.UpdateGraph:
 		err := handler.followService.CreateUser(context.Background(), &user)
 		if err != nil {
+			if !delivery.Last {
+				return err
+			}
 			reply.Type = events.FollowFailed
 		} else {
 			reply.Type = events.GraphUpdated
@@ -49,7 +54,10 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 
 	case events.RollbackFollow:
 		//TODO
-		_ = handler.followService.DeleteUser(context.Background(), &user.ID)
+		err := handler.followService.DeleteUser(context.Background(), &user.ID)
+		if err != nil && !delivery.Last {
+			return err
+		}
 		log.Println("Rollback follow")
 		reply.Type = events.FollowFailed
 	default:
@@ -57,6 +65,7 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 	}
 
 	if reply.Type != events.UnknownReply {
-		_ = handler.replyPublisher.Publish(reply)
+		return handler.replyPublisher.Publish(reply)
 	}
+	return nil
 }
@@ -42,6 +42,7 @@ func (handler *FollowHandler) Init(router *mux.Router) {
 	router.HandleFunc("/requests/{visibility}", handler.CreateRequest).Methods("POST")
 	router.HandleFunc("/acceptRequest/{id}", handler.AcceptRequest).Methods("PUT")
 	router.HandleFunc("/declineRequest/{id}", handler.DeclineRequest).Methods("PUT")
+	router.HandleFunc("/follow/{username}", handler.Unfollow).Methods("DELETE")
 	router.HandleFunc("/feedInfo", handler.GetFeedInfoOfUser).Methods("GET")
 	router.HandleFunc("/followings/{username}", handler.GetFollowingsOfUser).Methods("GET")
 	router.HandleFunc("/followers/{username}", handler.GetFollowersOfUser).Methods("GET")
@@ -62,7 +63,7 @@ func (handler *FollowHandler) GetRequestsForUser(writer http.ResponseWriter, req
 
 	token, err := authorization.GetToken(req)
 	if err != nil {
-		handler.logging.Errorf("FollowHandler.GetRequestsForUser.GetToken() : %s")
+		handler.logging.Errorf("FollowHandler.GetRequestsForUser.GetToken() : %s", err)
 		writer.WriteHeader(http.StatusUnauthorized)
 		return
 	}
@@ -71,7 +72,7 @@ func (handler *FollowHandler) GetRequestsForUser(writer http.ResponseWriter, req
 
 	returnRequests, err := handler.service.GetRequestsForUser(ctx, claims["username"])
 	if err != nil {
-		handler.logging.Errorf("FollowHandler.GetRequestsForUser.GetRequestsForUser() : %s")
+		handler.logging.Errorf("FollowHandler.GetRequestsForUser.GetRequestsForUser() : %s", err)
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -91,7 +92,9 @@ func (handler *FollowHandler) GetFeedInfoOfUser(writer http.ResponseWriter, req
 	claims := authorization.GetMapClaims(token.Bytes())
 	username := claims["username"]
 
-	feedInfo, err := handler.service.GetFeedInfoOfUser(ctx, username)
+	ranked := req.URL.Query().Get("ranked") == "true"
+
+	feedInfo, err := handler.service.GetFeedInfoOfUser(ctx, username, ranked)
 	if err != nil {
 		handler.logging.Errorf("FollowHandler.GetFeedInfoOfUser : %s", err)
 		http.Error(writer, err.Error(), http.StatusInternalServerError)
@@ -281,24 +284,81 @@ func (handler *FollowHandler) DeclineRequest(writer http.ResponseWriter, req *ht
 
 }
 
+func (handler *FollowHandler) Unfollow(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "FollowHandler.Unfollow")
+	defer span.End()
+
+	handler.logging.Infoln("FollowHandler.Unfollow : unfollow reached")
+
+	vars := mux.Vars(req)
+	followee, ok := vars["username"]
+	if !ok {
+		handler.logging.Errorf("FollowHandler.Unfollow.Vars() : bad request")
+		http.Error(writer, errors.BadRequestError, http.StatusBadRequest)
+		return
+	}
+
+	token, err := authorization.GetToken(req)
+	if err != nil {
+		handler.logging.Errorf("FollowHandler.Unfollow.GetToken() : %s", err)
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	claims := authorization.GetMapClaims(token.Bytes())
+
+	err = handler.service.Unfollow(ctx, claims["username"], followee)
+	if err != nil {
+		handler.logging.Errorf("FollowHandler.Unfollow.Unfollow() : %s", err)
+		if err.Error() == errors.ErrorFollowNotExists {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(writer, errors.InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	handler.logging.Infoln("FollowHandler.Unfollow : unfollow successful")
+
+	writer.WriteHeader(http.StatusOK)
+}
+
 func (handler *FollowHandler) SaveAd(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "FollowHandler.SaveAd")
 	defer span.End()
 
 	handler.logging.Infoln("FollowHandler.SaveAd : save_ad reached")
 
+	token, err := authorization.GetToken(req)
+	if err != nil {
+		handler.logging.Errorf("FollowHandler.SaveAd.GetToken() : %s", err)
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	claims := authorization.GetMapClaims(token.Bytes())
+
 	var request domain.Ad
-	err := json.NewDecoder(req.Body).Decode(&request)
+	err = json.NewDecoder(req.Body).Decode(&request)
 	if err != nil {
 		handler.logging.Errorf("FollowHandler.SaveAd.Decode() : %s", err)
 		http.Error(writer, "bad request", http.StatusBadRequest)
 		return
 	}
 
-	err = handler.service.SaveAd(ctx, &request)
+	err = handler.service.SaveAd(ctx, req.Header.Get("Authorization"), claims["username"], claims["userType"], &request)
 	if err != nil {
 		handler.logging.Errorf("FollowHandler.SaveAd.SaveAd() : %s", err)
-		http.Error(writer, "internal server error", http.StatusInternalServerError)
+		switch err.Error() {
+		case errors.ErrorCampaignNotExists, errors.ErrorUserNotExists, errors.ErrorTweetNotExists:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.ErrorNotCampaignOwner, errors.ErrorNotBusiness, errors.ErrorNotAdTweet:
+			http.Error(writer, err.Error(), http.StatusForbidden)
+		case errors.InvalidAdTargeting, errors.ErrorCampaignRequired:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.ServiceUnavailable:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(writer, "internal server error", http.StatusInternalServerError)
+		}
 		return
 	}
 
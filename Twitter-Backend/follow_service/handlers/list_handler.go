@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"follow_service/application"
+	"follow_service/authorization"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+type ListHandler struct {
+	service *application.ListService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewListHandler(service *application.ListService, tracer trace.Tracer, logging *logrus.Logger) *ListHandler {
+	return &ListHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *ListHandler) Init(router *mux.Router) {
+	router.HandleFunc("/lists", handler.CreateList).Methods("POST")
+	router.HandleFunc("/lists", handler.GetListsOfUser).Methods("GET")
+	router.HandleFunc("/lists/{id}", handler.GetList).Methods("GET")
+	router.HandleFunc("/lists/{id}", handler.UpdateList).Methods("PUT")
+	router.HandleFunc("/lists/{id}", handler.DeleteList).Methods("DELETE")
+	router.HandleFunc("/lists/{id}/members", handler.GetMembers).Methods("GET")
+	router.HandleFunc("/lists/{id}/members/{username}", handler.AddMember).Methods("PUT")
+	router.HandleFunc("/lists/{id}/members/{username}", handler.RemoveMember).Methods("DELETE")
+	router.HandleFunc("/lists/{id}/feedInfo", handler.GetListFeedInfo).Methods("GET")
+}
+
+func (handler *ListHandler) CreateList(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.CreateList")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.CreateList : create_list reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var list domain.List
+	err := json.NewDecoder(req.Body).Decode(&list)
+	if err != nil {
+		handler.logging.Errorf("ListHandler.CreateList.Decode() : %s", err)
+		http.Error(writer, errors.BadRequestError, http.StatusBadRequest)
+		return
+	}
+
+	err = handler.service.CreateList(ctx, username, &list)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	handler.logging.Infoln("ListHandler.CreateList : create_list successful")
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(list, writer)
+}
+
+// GetListsOfUser returns the lists of ?owner=, or of the user in the token when it is not set
+func (handler *ListHandler) GetListsOfUser(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.GetListsOfUser")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.GetListsOfUser : get_lists_of_user reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	owner := req.URL.Query().Get("owner")
+	if owner == "" {
+		owner = username
+	}
+
+	lists, err := handler.service.GetListsOfUser(ctx, username, owner)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(lists, writer)
+}
+
+func (handler *ListHandler) GetList(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.GetList")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.GetList : get_list reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	list, err := handler.service.GetList(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(list, writer)
+}
+
+func (handler *ListHandler) UpdateList(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.UpdateList")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.UpdateList : update_list reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update domain.List
+	err := json.NewDecoder(req.Body).Decode(&update)
+	if err != nil {
+		handler.logging.Errorf("ListHandler.UpdateList.Decode() : %s", err)
+		http.Error(writer, errors.BadRequestError, http.StatusBadRequest)
+		return
+	}
+
+	list, err := handler.service.UpdateList(ctx, username, mux.Vars(req)["id"], &update)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(list, writer)
+}
+
+func (handler *ListHandler) DeleteList(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.DeleteList")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.DeleteList : delete_list reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := handler.service.DeleteList(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *ListHandler) GetMembers(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.GetMembers")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.GetMembers : get_members reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	members, err := handler.service.GetMembers(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(members, writer)
+}
+
+func (handler *ListHandler) AddMember(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.AddMember")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.AddMember : add_member reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(req)
+	err := handler.service.AddMember(ctx, username, vars["id"], vars["username"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (handler *ListHandler) RemoveMember(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.RemoveMember")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.RemoveMember : remove_member reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(req)
+	err := handler.service.RemoveMember(ctx, username, vars["id"], vars["username"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// GetListFeedInfo is called by tweet_service to build the list timeline of the user in the token
+func (handler *ListHandler) GetListFeedInfo(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ListHandler.GetListFeedInfo")
+	defer span.End()
+
+	handler.logging.Infoln("ListHandler.GetListFeedInfo : get_list_feed_info reached")
+
+	username, ok := handler.username(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	feedInfo, err := handler.service.GetListFeedInfo(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(feedInfo, writer)
+}
+
+func (handler *ListHandler) username(req *http.Request) (string, bool) {
+	token, err := authorization.GetToken(req)
+	if err != nil {
+		handler.logging.Errorf("ListHandler.GetToken() : %s", err)
+		return "", false
+	}
+
+	username := authorization.GetMapClaims(token.Bytes())["username"]
+	return username, username != ""
+}
+
+func (handler *ListHandler) writeError(writer http.ResponseWriter, err error) {
+	handler.logging.Errorf("ListHandler : %s", err)
+
+	switch err.Error() {
+	case errors.ErrorListNotExists, errors.ErrorUserNotExists:
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.ErrorNotListOwner, errors.ErrorMemberNotFollows:
+		http.Error(writer, err.Error(), http.StatusForbidden)
+	case errors.InvalidListName:
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	case errors.ServiceUnavailable:
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(writer, errors.InternalServerError, http.StatusInternalServerError)
+	}
+}
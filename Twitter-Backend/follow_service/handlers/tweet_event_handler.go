@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"follow_service/application"
+	"follow_service/domain"
+	"github.com/sirupsen/logrus"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TweetEventHandler records the hashtags of tweets users post, favorite and retweet as their interests
+type TweetEventHandler struct {
+	interests  *application.InterestService
+	subscriber saga.Subscriber
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewTweetEventHandler(interests *application.InterestService, subscriber saga.Subscriber, tracer trace.Tracer, logging *logrus.Logger) (*TweetEventHandler, error) {
+	handler := &TweetEventHandler{
+		interests:  interests,
+		subscriber: subscriber,
+		tracer:     tracer,
+		logging:    logging,
+	}
+
+	err := handler.subscriber.Subscribe(handler.handle)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func (handler *TweetEventHandler) handle(event *domain.TweetEvent) {
+	ctx, span := handler.tracer.Start(context.Background(), "TweetEventHandler.handle")
+	defer span.End()
+
+	err := handler.interests.HandleEvent(ctx, event)
+	if err != nil {
+		handler.logging.Errorf("TweetEventHandler.handle : %s", err)
+	}
+}
@@ -14,6 +14,12 @@ type Config struct {
 	NatsPass                 string
 	CreateUserCommandSubject string
 	CreateUserReplySubject   string
+	FollowEventsSubject      string
+	TweetEventsSubject       string
+	UserServiceHost          string
+	UserServicePort          string
+	TweetServiceHost         string
+	TweetServicePort         string
 	JaegerAddress            string
 }
 
@@ -30,6 +36,12 @@ func NewConfig() *Config {
 		NatsPass:                 os.Getenv("NATS_PASS"),
 		CreateUserCommandSubject: os.Getenv("CREATE_USER_COMMAND_SUBJECT"),
 		CreateUserReplySubject:   os.Getenv("CREATE_USER_REPLY_SUBJECT"),
+		FollowEventsSubject:      os.Getenv("FOLLOW_EVENTS_SUBJECT"),
+		TweetEventsSubject:       os.Getenv("TWEET_EVENTS_SUBJECT"),
+		UserServiceHost:          os.Getenv("USER_SERVICE_HOST"),
+		UserServicePort:          os.Getenv("USER_SERVICE_PORT"),
+		TweetServiceHost:         os.Getenv("TWEET_SERVICE_HOST"),
+		TweetServicePort:         os.Getenv("TWEET_SERVICE_PORT"),
 		JaegerAddress:            os.Getenv("JAEGER_ADDRESS"),
 	}
 }
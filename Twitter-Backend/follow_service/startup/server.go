@@ -12,6 +12,7 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/sirupsen/logrus"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/nats"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
@@ -107,16 +108,27 @@ func (server *Server) Start() {
 
 	neo4jDriver := server.initNeo4JDriver()
 	followStore := server.initFollowStore(neo4jDriver, tracer, Logger)
-	followService := server.initFollowService(followStore, tracer, Logger)
+	server.migrateLegacyAds(followStore)
+	followEventsPublisher := server.initPublisher(server.config.FollowEventsSubject)
+	userClient := application.NewUserClient(server.config.UserServiceHost, server.config.UserServicePort)
+	tweetClient := application.NewTweetClient(server.config.TweetServiceHost, server.config.TweetServicePort)
+	followService := server.initFollowService(followStore, userClient, tweetClient, followEventsPublisher, tracer, Logger)
 	followHandler := server.initFollowHandler(followService, tracer, Logger)
+	listService := server.initListService(followStore, userClient, tracer, Logger)
+	listHandler := server.initListHandler(listService, tracer, Logger)
+	campaignService := server.initCampaignService(followStore, tracer, Logger)
+	campaignHandler := server.initCampaignHandler(campaignService, tracer, Logger)
+	interestService := server.initInterestService(followStore, tracer, Logger)
+	tweetEventsSubscriber := server.initSubscriber(server.config.TweetEventsSubject, QueueGroup)
+	server.initTweetEventHandler(interestService, tweetEventsSubscriber, tracer)
 
 	//saga init
-	replyPublisher := server.initPublisher(server.config.CreateUserReplySubject)
-	commandSubscriber := server.initSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
+	replyPublisher := server.initSagaPublisher(server.config.CreateUserReplySubject)
+	commandSubscriber := server.initSagaSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
 
 	server.initCreateUserHandler(followService, replyPublisher, commandSubscriber, tracer)
 
-	server.start(followHandler)
+	server.start(followHandler, listHandler, campaignHandler)
 }
 
 func (server *Server) initFollowStore(driver *neo4j.DriverWithContext, tracer trace.Tracer, logging *logrus.Logger) domain.FollowRequestStore {
@@ -125,14 +137,50 @@ func (server *Server) initFollowStore(driver *neo4j.DriverWithContext, tracer tr
 	return store
 }
 
-func (server *Server) initFollowService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *application.FollowService {
-	return application.NewFollowService(store, tracer, logging)
+// migrateLegacyAds keeps ads saved before campaigns existed in serving, a failure is retried
+// on the next start
+func (server *Server) migrateLegacyAds(store domain.FollowRequestStore) {
+	_, err := store.MigrateLegacyAds(context.Background())
+	if err != nil {
+		Logger.Errorf("follow_service : migrating legacy ads failed : %s", err)
+	}
+}
+
+func (server *Server) initFollowService(store domain.FollowRequestStore, users *application.UserClient, tweets *application.TweetClient, events saga.Publisher, tracer trace.Tracer, logging *logrus.Logger) *application.FollowService {
+	return application.NewFollowService(store, users, tweets, events, tracer, logging)
 }
 
 func (server *Server) initFollowHandler(service *application.FollowService, tracer trace.Tracer, logging *logrus.Logger) *handlers.FollowHandler {
 	return handlers.NewFollowHandler(service, tracer, logging)
 }
 
+func (server *Server) initListService(store domain.FollowRequestStore, users *application.UserClient, tracer trace.Tracer, logging *logrus.Logger) *application.ListService {
+	return application.NewListService(store, users, tracer, logging)
+}
+
+func (server *Server) initListHandler(service *application.ListService, tracer trace.Tracer, logging *logrus.Logger) *handlers.ListHandler {
+	return handlers.NewListHandler(service, tracer, logging)
+}
+
+func (server *Server) initCampaignService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *application.CampaignService {
+	return application.NewCampaignService(store, tracer, logging)
+}
+
+func (server *Server) initCampaignHandler(service *application.CampaignService, tracer trace.Tracer, logging *logrus.Logger) *handlers.CampaignHandler {
+	return handlers.NewCampaignHandler(service, tracer, logging)
+}
+
+func (server *Server) initInterestService(store domain.FollowRequestStore, tracer trace.Tracer, logging *logrus.Logger) *application.InterestService {
+	return application.NewInterestService(store, tracer, logging)
+}
+
+func (server *Server) initTweetEventHandler(interests *application.InterestService, subscriber saga.Subscriber, tracer trace.Tracer) {
+	_, err := handlers.NewTweetEventHandler(interests, subscriber, tracer, Logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func (server *Server) initCreateUserHandler(service *application.FollowService, publisher saga.Publisher, subscriber saga.Subscriber, tracer trace.Tracer) {
 	_, err := handlers.NewCreateUserCommandHandler(service, publisher, subscriber, tracer)
 	if err != nil {
@@ -160,8 +208,32 @@ func (server *Server) initSubscriber(subject string, queueGroup string) saga.Sub
 	return subscriber
 }
 
-func (server *Server) start(followHandler *handlers.FollowHandler) {
+// initSagaPublisher publishes to a JetStream stream, saga messages are kept until the
+// services handling them are up
+func (server *Server) initSagaPublisher(subject string) saga.Publisher {
+	publisher, err := jetstream.NewJetStreamPublisher(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return publisher
+}
+
+func (server *Server) initSagaSubscriber(subject string, queueGroup string) saga.Subscriber {
+	subscriber, err := jetstream.NewJetStreamSubscriber(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject, queueGroup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return subscriber
+}
+
+func (server *Server) start(followHandler *handlers.FollowHandler, listHandler *handlers.ListHandler, campaignHandler *handlers.CampaignHandler) {
 	router := mux.NewRouter()
+	listHandler.Init(router)
+	campaignHandler.Init(router)
 	followHandler.Init(router)
 
 	srv := &http.Server{
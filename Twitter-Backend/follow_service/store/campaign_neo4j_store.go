@@ -0,0 +1,257 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"time"
+)
+
+// campaigns are (advertiser:User)-[:ADVERTISES]->(c:Campaign)-[:SERVES]->(ad:Ad). Spending of
+// the current day is kept with the day it belongs to, an older day counts as nothing spent
+const campaignColumns = "c.id as id, c.name as name, o.username as advertiser, c.start_at as start_at, " +
+	"c.end_at as end_at, c.daily_budget as daily_budget, c.total_budget as total_budget, c.bid as bid, " +
+	"c.status as status, c.spent as spent, " +
+	"CASE WHEN c.spent_day = $day THEN c.spent_today ELSE 0 END as spent_today, " +
+	"c.created_at as created_at, count(ad) as ads"
+
+func (store *FollowNeo4JStore) SaveCampaign(ctx context.Context, campaign *domain.Campaign) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.SaveCampaign")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.SaveCampaign : SaveCampaign reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	saved, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (o:User) WHERE o.username = $advertiser "+
+					"CREATE (o)-[:ADVERTISES]->(c:Campaign {id: $id, name: $name, start_at: $start_at, end_at: $end_at, "+
+					"daily_budget: $daily_budget, total_budget: $total_budget, bid: $bid, status: $status, "+
+					"spent: 0, spent_today: 0, spent_day: '', created_at: $created_at}) "+
+					"RETURN c.id as id",
+				map[string]any{"advertiser": campaign.Advertiser, "id": campaign.ID, "name": campaign.Name,
+					"start_at": campaign.StartAt, "end_at": campaign.EndAt, "daily_budget": campaign.DailyBudget,
+					"total_budget": campaign.TotalBudget, "bid": campaign.Bid, "status": string(campaign.Status),
+					"created_at": campaign.CreatedAt})
+			if err != nil {
+				store.logging.Errorf("FollowStore.SaveCampaign.Run() : %s", err)
+				return nil, err
+			}
+
+			return result.Next(ctx), nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.SaveCampaign.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	if !saved.(bool) {
+		return fmt.Errorf(errors.ErrorUserNotExists)
+	}
+
+	store.logging.Infoln("FollowStore.SaveCampaign : SaveCampaign successful")
+
+	return nil
+}
+
+func (store *FollowNeo4JStore) GetCampaign(ctx context.Context, id string) (*domain.Campaign, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetCampaign")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetCampaign : GetCampaign reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	campaigns, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (o:User)-[:ADVERTISES]->(c:Campaign) WHERE c.id = $id "+
+				"OPTIONAL MATCH (c)-[:SERVES]->(ad:Ad) "+
+				"RETURN "+campaignColumns,
+			map[string]any{"id": id, "day": spendingDay(time.Now())})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetCampaign.Run() : %s", err)
+			return nil, err
+		}
+
+		return collectCampaigns(ctx, result), nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetCampaign.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	found := campaigns.([]*domain.Campaign)
+	if len(found) == 0 {
+		return nil, fmt.Errorf(errors.ErrorCampaignNotExists)
+	}
+
+	store.logging.Infoln("FollowStore.GetCampaign : GetCampaign successful")
+
+	return found[0], nil
+}
+
+func (store *FollowNeo4JStore) GetCampaignsOfUser(ctx context.Context, advertiser string) ([]*domain.Campaign, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetCampaignsOfUser")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetCampaignsOfUser : GetCampaignsOfUser reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	campaigns, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (o:User)-[:ADVERTISES]->(c:Campaign) WHERE o.username = $advertiser "+
+				"OPTIONAL MATCH (c)-[:SERVES]->(ad:Ad) "+
+				"RETURN "+campaignColumns+" "+
+				"ORDER BY created_at DESC",
+			map[string]any{"advertiser": advertiser, "day": spendingDay(time.Now())})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetCampaignsOfUser.Run() : %s", err)
+			return nil, err
+		}
+
+		return collectCampaigns(ctx, result), nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetCampaignsOfUser.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	store.logging.Infoln("FollowStore.GetCampaignsOfUser : GetCampaignsOfUser successful")
+
+	return campaigns.([]*domain.Campaign), nil
+}
+
+// UpdateCampaign writes the settings and status of the campaign, spending is only changed by serving
+func (store *FollowNeo4JStore) UpdateCampaign(ctx context.Context, campaign *domain.Campaign) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.UpdateCampaign")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.UpdateCampaign : UpdateCampaign reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			_, err := transaction.Run(ctx,
+				"MATCH (c:Campaign) WHERE c.id = $id "+
+					"SET c.name = $name, c.start_at = $start_at, c.end_at = $end_at, c.daily_budget = $daily_budget, "+
+					"c.total_budget = $total_budget, c.bid = $bid, c.status = $status",
+				map[string]any{"id": campaign.ID, "name": campaign.Name, "start_at": campaign.StartAt,
+					"end_at": campaign.EndAt, "daily_budget": campaign.DailyBudget, "total_budget": campaign.TotalBudget,
+					"bid": campaign.Bid, "status": string(campaign.Status)})
+			if err != nil {
+				store.logging.Errorf("FollowStore.UpdateCampaign.Run() : %s", err)
+				return nil, err
+			}
+			return nil, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.UpdateCampaign.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	store.logging.Infoln("FollowStore.UpdateCampaign : UpdateCampaign successful")
+
+	return nil
+}
+
+// MigrateLegacyAds moves ads that belong to no campaign into the legacy campaign, so ads saved
+// before campaigns existed are still served. The ads do not know their advertiser, the legacy
+// campaign has none. Running it again only moves ads that are still left over
+func (store *FollowNeo4JStore) MigrateLegacyAds(ctx context.Context) (int64, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.MigrateLegacyAds")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.MigrateLegacyAds : MigrateLegacyAds reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	migrated, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (ad:Ad) WHERE NOT ()-[:SERVES]->(ad) "+
+					"WITH collect(ad) as ads WHERE size(ads) > 0 "+
+					"MERGE (c:Campaign {id: $id}) "+
+					"ON CREATE SET c.name = $id, c.start_at = 0, c.end_at = $end_at, "+
+					"c.daily_budget = 0, c.total_budget = 0, c.bid = 0, c.status = $status, "+
+					"c.spent = 0, c.spent_today = 0, c.spent_day = '', c.created_at = $created_at "+
+					"WITH c, ads UNWIND ads as ad "+
+					"MERGE (c)-[:SERVES]->(ad) "+
+					"RETURN count(ad) as migrated",
+				map[string]any{"id": domain.LegacyCampaignID, "end_at": domain.OpenCampaignEnd,
+					"status": string(domain.CampaignActive), "created_at": time.Now().Unix()})
+			if err != nil {
+				store.logging.Errorf("FollowStore.MigrateLegacyAds.Run() : %s", err)
+				return nil, err
+			}
+
+			if result.Next(ctx) {
+				count, _ := result.Record().Get("migrated")
+				return count.(int64), nil
+			}
+
+			return int64(0), result.Err()
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.MigrateLegacyAds.ExecuteWrite() : %s", err)
+		return 0, err
+	}
+
+	store.logging.Infof("FollowStore.MigrateLegacyAds : moved %d ads to the legacy campaign", migrated.(int64))
+
+	return migrated.(int64), nil
+}
+
+func collectCampaigns(ctx context.Context, result neo4j.ResultWithContext) []*domain.Campaign {
+	campaigns := []*domain.Campaign{}
+	for result.Next(ctx) {
+		record := result.Record()
+		id, _ := record.Get("id")
+		if id == nil {
+			continue
+		}
+		name, _ := record.Get("name")
+		advertiser, _ := record.Get("advertiser")
+		startAt, _ := record.Get("start_at")
+		endAt, _ := record.Get("end_at")
+		dailyBudget, _ := record.Get("daily_budget")
+		totalBudget, _ := record.Get("total_budget")
+		bid, _ := record.Get("bid")
+		status, _ := record.Get("status")
+		spent, _ := record.Get("spent")
+		spentToday, _ := record.Get("spent_today")
+		createdAt, _ := record.Get("created_at")
+		ads, _ := record.Get("ads")
+		campaigns = append(campaigns, &domain.Campaign{
+			ID:          id.(string),
+			Name:        name.(string),
+			Advertiser:  advertiser.(string),
+			StartAt:     startAt.(int64),
+			EndAt:       endAt.(int64),
+			DailyBudget: dailyBudget.(int64),
+			TotalBudget: totalBudget.(int64),
+			Bid:         bid.(int64),
+			Status:      domain.CampaignStatus(status.(string)),
+			Spent:       spent.(int64),
+			SpentToday:  spentToday.(int64),
+			CreatedAt:   createdAt.(int64),
+			Ads:         int(ads.(int64)),
+		})
+	}
+	return campaigns
+}
+
+// spendingDay is the UTC day daily budgets are counted in
+func spendingDay(now time.Time) string {
+	return now.UTC().Format("2006-01-02")
+}
@@ -259,6 +259,57 @@ func (store *FollowNeo4JStore) GetFollowersOfUser(ctx context.Context, username
 	return followings.([]string), nil
 }
 
+// GetSecondDegreeFollowings returns users followed by the user's followings that the user
+// doesn't follow, the ones with the most followings in between come first. Users that were
+// private when they registered are left out
+func (store *FollowNeo4JStore) GetSecondDegreeFollowings(ctx context.Context, username string, limit int) ([]domain.Connection, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetSecondDegreeFollowings")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetSecondDegreeFollowings : GetSecondDegreeFollowings reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	connections, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (u:User)-[:FOLLOWS]->(f:User)-[:FOLLOWS]->(s:User) "+
+				"WHERE u.username = $username AND NOT u = s AND NOT exists((u)-[:FOLLOWS]->(s)) "+
+				"AND coalesce(s.privacy, false) = false "+
+				"RETURN s.username as username, count(DISTINCT f) as mutual "+
+				"ORDER BY mutual DESC LIMIT $limit",
+			map[string]any{"username": username, "limit": limit})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetSecondDegreeFollowings.Run() : %s", err)
+			return nil, err
+		}
+
+		var connections []domain.Connection
+		for result.Next(ctx) {
+			record := result.Record()
+			username, _ := record.Get("username")
+			mutual, _ := record.Get("mutual")
+			if username == nil || mutual == nil {
+				continue
+			}
+			connections = append(connections, domain.Connection{
+				Username: username.(string),
+				Mutual:   int(mutual.(int64)),
+			})
+		}
+
+		return connections, nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetSecondDegreeFollowings.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	store.logging.Infoln("FollowStore.GetSecondDegreeFollowings : GetSecondDegreeFollowings successful")
+
+	return connections.([]domain.Connection), nil
+}
+
 func (store *FollowNeo4JStore) SaveRequest(ctx context.Context, request *domain.FollowRequest) error {
 	ctx, span := store.tracer.Start(ctx, "FollowStore.SaveRequest")
 	defer span.End()
@@ -350,10 +401,10 @@ func (store *FollowNeo4JStore) SaveUser(ctx context.Context, user *domain.User)
 	_, err := session.ExecuteWrite(ctx,
 		func(transaction neo4j.ManagedTransaction) (any, error) {
 			result, err := transaction.Run(ctx,
-				"CREATE (u:User) SET u.id = $id, u.username = $username, "+
-					"u.age = $age, u.residence = $residence, u.gender = $gender RETURN u.id + ', from node ' + id(u)",
+				"MERGE (u:User {id: $id}) SET u.username = $username, "+
+					"u.age = $age, u.residence = $residence, u.gender = $gender, u.privacy = $privacy RETURN u.id + ', from node ' + id(u)",
 				map[string]any{"id": user.ID, "username": user.Username, "age": user.Age,
-					"residence": user.Residence, "gender": user.Gender})
+					"residence": user.Residence, "gender": user.Gender, "privacy": user.Privacy})
 			if err != nil {
 				store.logging.Errorf("FollowStore.SaveUser.Run() : %s", err)
 				return nil, err
@@ -442,6 +493,48 @@ func (store *FollowNeo4JStore) SaveFollow(ctx context.Context, request *domain.F
 	return nil
 }
 
+// DeleteFollow removes the follow relationship, false means there was nothing to remove
+func (store *FollowNeo4JStore) DeleteFollow(ctx context.Context, request *domain.FollowRequest) (bool, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.DeleteFollow")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.DeleteFollow : DeleteFollow reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	deleted, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (requester:User)-[f:FOLLOWS]->(receiver:User) "+
+					"WHERE requester.username = $requester AND receiver.username = $receiver "+
+					"DELETE f "+
+					"RETURN count(f) as deleted",
+				map[string]any{"requester": request.Requester, "receiver": request.Receiver})
+			if err != nil {
+				store.logging.Errorf("FollowStore.DeleteFollow.Run() : %s", err)
+				return nil, err
+			}
+
+			if result.Next(ctx) {
+				count, _ := result.Record().Get("deleted")
+				if count != nil {
+					return count.(int64) > 0, nil
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.DeleteFollow.ExecuteWrite() : %s", err)
+		return false, err
+	}
+
+	store.logging.Infoln("FollowStore.DeleteFollow : DeleteFollow successful")
+
+	return deleted.(bool), nil
+}
+
 func (store *FollowNeo4JStore) AcceptRequest(ctx context.Context, id *string) (*domain.FollowRequest, error) {
 	ctx, span := store.tracer.Start(ctx, "FollowStore.AcceptRequest")
 	defer span.End()
@@ -522,6 +615,7 @@ func (store *FollowNeo4JStore) DeclineRequest(ctx context.Context, id *string) e
 	return nil
 }
 
+// SaveAd stores the targeting of the ad and attaches it to its campaign
 func (store *FollowNeo4JStore) SaveAd(ctx context.Context, ad *domain.Ad) error {
 	ctx, span := store.tracer.Start(ctx, "FollowStore.SaveAd")
 	defer span.End()
@@ -531,36 +625,47 @@ func (store *FollowNeo4JStore) SaveAd(ctx context.Context, ad *domain.Ad) error
 	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
 	defer session.Close(ctx)
 
-	_, err := session.ExecuteWrite(ctx,
+	saved, err := session.ExecuteWrite(ctx,
 		func(transaction neo4j.ManagedTransaction) (any, error) {
 			result, err := transaction.Run(ctx,
-				"CREATE (ad:Ad) SET ad.tweetID = $tweetID, ad.ageFrom = $ageFrom, "+
-					"ad.ageTo = $ageTo, ad.gender = $gender, ad.residence = $residence "+
-					"RETURN ad.id + ', from node ' + id(ad)",
-				map[string]any{"tweetID": ad.TweetID, "ageFrom": ad.AgeFrom, "ageTo": ad.AgeTo,
-					"gender": ad.Gender, "residence": ad.Residence})
+				"MATCH (c:Campaign) WHERE c.id = $campaignID "+
+					"CREATE (c)-[:SERVES]->(ad:Ad) SET ad.tweetID = $tweetID, ad.ageFrom = $ageFrom, "+
+					"ad.ageTo = $ageTo, ad.gender = $gender, ad.residence = $residence, "+
+					"ad.followersOf = $followersOf, ad.hashtags = $hashtags, "+
+					"ad.excludeFollowers = $excludeFollowers, ad.frequencyCap = $frequencyCap "+
+					"RETURN ad.tweetID as tweetID",
+				map[string]any{"campaignID": ad.CampaignID, "tweetID": ad.TweetID, "ageFrom": ad.AgeFrom,
+					"ageTo": ad.AgeTo, "gender": ad.Gender, "residence": ad.Residence,
+					"followersOf": ad.FollowersOf, "hashtags": ad.Hashtags,
+					"excludeFollowers": ad.ExcludeFollowers, "frequencyCap": ad.FrequencyCap})
 			if err != nil {
 				store.logging.Errorf("FollowStore.SaveAd.Run() : %s", err)
 				return nil, err
 			}
 
-			if result.Next(ctx) {
-				return result.Record().Values[0], nil
-			}
-
-			return nil, result.Err()
+			return result.Next(ctx), nil
 		})
 	if err != nil {
 		store.logging.Errorf("FollowStore.SaveAd.ExecuteWrite() : %s", err)
 		return err
 	}
 
+	if !saved.(bool) {
+		return fmt.Errorf(errors.ErrorCampaignNotExists)
+	}
+
 	store.logging.Infoln("FollowStore.SaveAd : SaveAd successful")
 
 	return nil
 }
 
-func (store *FollowNeo4JStore) GetRecommendAdsId(ctx context.Context, username string) ([]string, error) {
+// GetRecommendAdsId picks up to serving.Limit campaigns with ads that target the user, are
+// running, stay within their budgets and are not ahead of their pace for the day, and charges
+// their bid. The campaign is locked before the charge and its budgets are checked again, so
+// concurrent feeds can not spend past them. The ad served from each campaign is counted
+// against its frequency cap for the user. Campaigns without a bid are not served, except for
+// the legacy campaign
+func (store *FollowNeo4JStore) GetRecommendAdsId(ctx context.Context, serving *domain.AdServing) ([]string, error) {
 	ctx, span := store.tracer.Start(ctx, "FollowStore.GetRecommendAdsId")
 	defer span.End()
 
@@ -569,21 +674,45 @@ func (store *FollowNeo4JStore) GetRecommendAdsId(ctx context.Context, username s
 	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
 	defer session.Close(ctx)
 
-	recommendsIds, err := session.ExecuteRead(ctx,
+	recommendsIds, err := session.ExecuteWrite(ctx,
 		func(transaction neo4j.ManagedTransaction) (any, error) {
 			result, err := transaction.Run(ctx,
-				"MATCH (u:User), (ad:Ad) "+
+				"MATCH (u:User), (c:Campaign)-[:SERVES]->(ad:Ad) "+
 					"WHERE u.username = $username AND ad.ageFrom <= u.age <= ad.ageTo "+
 					"AND u.residence = ad.residence AND (ad.gender = u.gender "+
 					"OR (NOT ad.gender = u.gender AND ad.gender = 'Both')) "+
+					"AND c.status = 'active' AND c.start_at <= $now AND $now < c.end_at "+
+					"AND (c.bid > 0 OR c.id = $legacy) "+
+					"AND (size(coalesce(ad.followersOf, [])) = 0 "+
+					"OR size([(u)-[:FOLLOWS]->(a:User) WHERE a.username IN ad.followersOf | a]) > 0) "+
+					"AND (size(coalesce(ad.hashtags, [])) = 0 "+
+					"OR size([(u)-[e:ENGAGED]->(h:Hashtag) WHERE h.name IN ad.hashtags AND e.last >= $engagedSince | h]) > 0) "+
+					"AND NOT (coalesce(ad.excludeFollowers, false) AND exists((u)-[:FOLLOWS]->(:User)-[:ADVERTISES]->(c))) "+
+					"AND (coalesce(ad.frequencyCap, 0) = 0 "+
+					"OR size([(u)-[s:SAW]->(ad) WHERE s.day = $day AND s.count >= ad.frequencyCap | s]) = 0) "+
+					"WITH u, c, collect(ad) as ads, "+
+					"CASE WHEN c.spent_day = $day THEN c.spent_today ELSE 0 END as spentToday "+
+					"WHERE c.spent + c.bid <= c.total_budget AND spentToday + c.bid <= c.daily_budget "+
+					"AND spentToday <= c.daily_budget * $dayFraction "+
+					"WITH u, c, ads ORDER BY c.bid DESC, rand() LIMIT $limit "+
+					"SET c._lock = true "+
+					"WITH u, c, ads, CASE WHEN c.spent_day = $day THEN c.spent_today ELSE 0 END as spentToday "+
+					"WHERE c.spent + c.bid <= c.total_budget AND spentToday + c.bid <= c.daily_budget "+
+					"SET c.spent = c.spent + c.bid, c.spent_today = spentToday + c.bid, c.spent_day = $day "+
+					"REMOVE c._lock "+
+					"WITH u, ads[toInteger(rand() * size(ads))] as ad "+
+					"MERGE (u)-[s:SAW]->(ad) "+
+					"SET s.count = CASE WHEN s.day = $day THEN s.count + 1 ELSE 1 END, s.day = $day "+
 					"RETURN ad.tweetID as tweetID",
-				map[string]any{"username": username})
+				map[string]any{"username": serving.Username, "now": serving.Now, "day": serving.Day,
+					"dayFraction": serving.DayFraction, "engagedSince": serving.EngagedSince, "limit": serving.Limit,
+					"legacy": domain.LegacyCampaignID})
 			if err != nil {
 				store.logging.Errorf("FollowStore.GetRecommendAdsId.Run() : %s", err)
 				return nil, err
 			}
 
-			var recommends []string
+			recommends := []string{}
 			for result.Next(ctx) {
 				record := result.Record()
 				tweetID, _ := record.Get("tweetID")
@@ -593,7 +722,7 @@ func (store *FollowNeo4JStore) GetRecommendAdsId(ctx context.Context, username s
 			return recommends, nil
 		})
 	if err != nil {
-		store.logging.Errorf("FollowStore.GetRecommendAdsId.ExecuteRead() : %s", err)
+		store.logging.Errorf("FollowStore.GetRecommendAdsId.ExecuteWrite() : %s", err)
 		return nil, err
 	}
 
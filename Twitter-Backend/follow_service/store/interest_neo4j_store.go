@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SaveInterests records that the user engaged with the hashtags at the given time,
+// as (u:User)-[:ENGAGED {count, last}]->(h:Hashtag)
+func (store *FollowNeo4JStore) SaveInterests(ctx context.Context, username string, hashtags []string, at int64) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.SaveInterests")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.SaveInterests : SaveInterests reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			_, err := transaction.Run(ctx,
+				"MATCH (u:User) WHERE u.username = $username "+
+					"UNWIND $hashtags as hashtag "+
+					"MERGE (h:Hashtag {name: hashtag}) "+
+					"MERGE (u)-[e:ENGAGED]->(h) "+
+					"SET e.count = coalesce(e.count, 0) + 1, "+
+					"e.last = CASE WHEN coalesce(e.last, 0) > $at THEN e.last ELSE $at END",
+				map[string]any{"username": username, "hashtags": hashtags, "at": at})
+			if err != nil {
+				store.logging.Errorf("FollowStore.SaveInterests.Run() : %s", err)
+				return nil, err
+			}
+			return nil, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.SaveInterests.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	store.logging.Infoln("FollowStore.SaveInterests : SaveInterests successful")
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"follow_service/domain"
+	"follow_service/errors"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// lists are (owner:User)-[:OWNS]->(l:List)-[:MEMBER]->(member:User)
+const listColumns = "l.id as id, l.name as name, o.username as owner, l.private as private, l.created_at as created_at, count(m) as members"
+
+func (store *FollowNeo4JStore) SaveList(ctx context.Context, list *domain.List) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.SaveList")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.SaveList : SaveList reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	saved, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (o:User) WHERE o.username = $owner "+
+					"CREATE (o)-[:OWNS]->(l:List {id: $id, name: $name, private: $private, created_at: $created_at}) "+
+					"RETURN l.id as id",
+				map[string]any{"owner": list.Owner, "id": list.ID, "name": list.Name,
+					"private": list.Private, "created_at": list.CreatedAt})
+			if err != nil {
+				store.logging.Errorf("FollowStore.SaveList.Run() : %s", err)
+				return nil, err
+			}
+
+			return result.Next(ctx), nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.SaveList.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	if !saved.(bool) {
+		return fmt.Errorf(errors.ErrorUserNotExists)
+	}
+
+	store.logging.Infoln("FollowStore.SaveList : SaveList successful")
+
+	return nil
+}
+
+func (store *FollowNeo4JStore) GetList(ctx context.Context, id string) (*domain.List, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetList")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetList : GetList reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	lists, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (o:User)-[:OWNS]->(l:List) WHERE l.id = $id "+
+				"OPTIONAL MATCH (l)-[m:MEMBER]->(:User) "+
+				"RETURN "+listColumns,
+			map[string]any{"id": id})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetList.Run() : %s", err)
+			return nil, err
+		}
+
+		return collectLists(ctx, result), nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetList.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	found := lists.([]*domain.List)
+	if len(found) == 0 {
+		return nil, fmt.Errorf(errors.ErrorListNotExists)
+	}
+
+	store.logging.Infoln("FollowStore.GetList : GetList successful")
+
+	return found[0], nil
+}
+
+func (store *FollowNeo4JStore) GetListsOfUser(ctx context.Context, owner string) ([]*domain.List, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetListsOfUser")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetListsOfUser : GetListsOfUser reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	lists, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (o:User)-[:OWNS]->(l:List) WHERE o.username = $owner "+
+				"OPTIONAL MATCH (l)-[m:MEMBER]->(:User) "+
+				"RETURN "+listColumns+" "+
+				"ORDER BY created_at DESC",
+			map[string]any{"owner": owner})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetListsOfUser.Run() : %s", err)
+			return nil, err
+		}
+
+		return collectLists(ctx, result), nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetListsOfUser.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	store.logging.Infoln("FollowStore.GetListsOfUser : GetListsOfUser successful")
+
+	return lists.([]*domain.List), nil
+}
+
+func (store *FollowNeo4JStore) UpdateList(ctx context.Context, list *domain.List) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.UpdateList")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.UpdateList : UpdateList reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			_, err := transaction.Run(ctx,
+				"MATCH (l:List) WHERE l.id = $id "+
+					"SET l.name = $name, l.private = $private",
+				map[string]any{"id": list.ID, "name": list.Name, "private": list.Private})
+			if err != nil {
+				store.logging.Errorf("FollowStore.UpdateList.Run() : %s", err)
+				return nil, err
+			}
+			return nil, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.UpdateList.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	store.logging.Infoln("FollowStore.UpdateList : UpdateList successful")
+
+	return nil
+}
+
+func (store *FollowNeo4JStore) DeleteList(ctx context.Context, id string) error {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.DeleteList")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.DeleteList : DeleteList reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			_, err := transaction.Run(ctx,
+				"MATCH (l:List) WHERE l.id = $id DETACH DELETE l",
+				map[string]any{"id": id})
+			if err != nil {
+				store.logging.Errorf("FollowStore.DeleteList.Run() : %s", err)
+				return nil, err
+			}
+			return nil, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.DeleteList.ExecuteWrite() : %s", err)
+		return err
+	}
+
+	store.logging.Infoln("FollowStore.DeleteList : DeleteList successful")
+
+	return nil
+}
+
+// AddListMember returns false when there is no user with that username
+func (store *FollowNeo4JStore) AddListMember(ctx context.Context, id string, username string) (bool, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.AddListMember")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.AddListMember : AddListMember reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	added, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (l:List), (u:User) WHERE l.id = $id AND u.username = $username "+
+					"MERGE (l)-[:MEMBER]->(u) "+
+					"RETURN u.username as username",
+				map[string]any{"id": id, "username": username})
+			if err != nil {
+				store.logging.Errorf("FollowStore.AddListMember.Run() : %s", err)
+				return nil, err
+			}
+
+			return result.Next(ctx), nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.AddListMember.ExecuteWrite() : %s", err)
+		return false, err
+	}
+
+	store.logging.Infoln("FollowStore.AddListMember : AddListMember successful")
+
+	return added.(bool), nil
+}
+
+// RemoveListMember returns false when the user was not a member of the list
+func (store *FollowNeo4JStore) RemoveListMember(ctx context.Context, id string, username string) (bool, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.RemoveListMember")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.RemoveListMember : RemoveListMember reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	removed, err := session.ExecuteWrite(ctx,
+		func(transaction neo4j.ManagedTransaction) (any, error) {
+			result, err := transaction.Run(ctx,
+				"MATCH (l:List)-[m:MEMBER]->(u:User) WHERE l.id = $id AND u.username = $username "+
+					"DELETE m "+
+					"RETURN count(m) as removed",
+				map[string]any{"id": id, "username": username})
+			if err != nil {
+				store.logging.Errorf("FollowStore.RemoveListMember.Run() : %s", err)
+				return nil, err
+			}
+
+			if result.Next(ctx) {
+				count, _ := result.Record().Get("removed")
+				if count != nil {
+					return count.(int64) > 0, nil
+				}
+			}
+
+			return false, nil
+		})
+	if err != nil {
+		store.logging.Errorf("FollowStore.RemoveListMember.ExecuteWrite() : %s", err)
+		return false, err
+	}
+
+	store.logging.Infoln("FollowStore.RemoveListMember : RemoveListMember successful")
+
+	return removed.(bool), nil
+}
+
+func (store *FollowNeo4JStore) GetListMembers(ctx context.Context, id string) ([]string, error) {
+	ctx, span := store.tracer.Start(ctx, "FollowStore.GetListMembers")
+	defer span.End()
+
+	store.logging.Infoln("FollowStore.GetListMembers : GetListMembers reached")
+
+	session := store.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: DATABASE})
+	defer session.Close(ctx)
+
+	members, err := session.ExecuteRead(ctx, func(transaction neo4j.ManagedTransaction) (any, error) {
+		result, err := transaction.Run(ctx,
+			"MATCH (l:List)-[:MEMBER]->(u:User) WHERE l.id = $id "+
+				"RETURN u.username as username ORDER BY username",
+			map[string]any{"id": id})
+		if err != nil {
+			store.logging.Errorf("FollowStore.GetListMembers.Run() : %s", err)
+			return nil, err
+		}
+
+		members := []string{}
+		for result.Next(ctx) {
+			username, _ := result.Record().Get("username")
+			if username != nil {
+				members = append(members, username.(string))
+			}
+		}
+
+		return members, nil
+	})
+	if err != nil {
+		store.logging.Errorf("FollowStore.GetListMembers.ExecuteRead() : %s", err)
+		return nil, err
+	}
+
+	store.logging.Infoln("FollowStore.GetListMembers : GetListMembers successful")
+
+	return members.([]string), nil
+}
+
+func collectLists(ctx context.Context, result neo4j.ResultWithContext) []*domain.List {
+	lists := []*domain.List{}
+	for result.Next(ctx) {
+		record := result.Record()
+		id, _ := record.Get("id")
+		name, _ := record.Get("name")
+		owner, _ := record.Get("owner")
+		private, _ := record.Get("private")
+		createdAt, _ := record.Get("created_at")
+		members, _ := record.Get("members")
+		if id == nil {
+			continue
+		}
+		lists = append(lists, &domain.List{
+			ID:        id.(string),
+			Name:      name.(string),
+			Owner:     owner.(string),
+			Private:   private.(bool),
+			CreatedAt: createdAt.(int64),
+			Members:   int(members.(int64)),
+		})
+	}
+	return lists
+}
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"report_service/errors"
+	"time"
+)
+
+// adTweet holds the fields of a tweet_service tweet needed to tell an ad and its owner
+type adTweet struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	OwnerUsername string `json:"owner_username"`
+	Advertisement bool   `json:"advertisement"`
+}
+
+// AdClient asks tweet_service which ad tweets belong to an advertiser
+type AdClient struct {
+	client *http.Client
+}
+
+func NewAdClient() *AdClient {
+	return &AdClient{
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// AdsOf returns the ids of the ads posted by username, read with the advertiser's own token.
+// Retweets of ads of other users are left out
+func (client *AdClient) AdsOf(ctx context.Context, token string, username string) ([]string, error) {
+	endpoint := fmt.Sprintf("http://%s:%s/user/%s", tweetServiceHost, tweetServicePort, url.PathEscape(username))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Authorization", token)
+
+	response, err := client.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf(errors.ServiceUnavailableError)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(errors.ServiceUnavailableError)
+	}
+
+	var tweets []adTweet
+	err = json.NewDecoder(response.Body).Decode(&tweets)
+	if err != nil {
+		return nil, err
+	}
+
+	ads := []string{}
+	for _, tweet := range tweets {
+		if !tweet.Advertisement || tweet.Username != username {
+			continue
+		}
+		if tweet.OwnerUsername != "" && tweet.OwnerUsername != username {
+			continue
+		}
+		ads = append(ads, tweet.ID)
+	}
+	return ads, nil
+}
@@ -0,0 +1,152 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"os"
+	"report_service/domain"
+	"report_service/errors"
+	"strings"
+	"time"
+)
+
+var (
+	tweetServiceHost = os.Getenv("TWEET_SERVICE_HOST")
+	tweetServicePort = os.Getenv("TWEET_SERVICE_PORT")
+	userServiceHost  = os.Getenv("USER_SERVICE_HOST")
+	userServicePort  = os.Getenv("USER_SERVICE_PORT")
+)
+
+type ModerationService struct {
+	store   domain.ModerationStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewModerationService(store domain.ModerationStore, tracer trace.Tracer, logging *logrus.Logger) *ModerationService {
+	return &ModerationService{
+		store:   store,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (service *ModerationService) FileReport(ctx context.Context, report *domain.ContentReport, reporter string) (*domain.ModerationReport, error) {
+	ctx, span := service.tracer.Start(ctx, "ModerationService.FileReport")
+	defer span.End()
+
+	service.logging.Infoln("ModerationService.FileReport : FileReport service reached")
+
+	report.TargetID = strings.TrimSpace(report.TargetID)
+	if report.TargetID == "" || !report.Reason.IsValid() {
+		return nil, fmt.Errorf(errors.InvalidReportError)
+	}
+
+	switch report.TargetType {
+	case domain.TargetTweet:
+		if _, err := gocql.ParseUUID(report.TargetID); err != nil {
+			return nil, fmt.Errorf(errors.InvalidReportError)
+		}
+	case domain.TargetUser:
+		if report.TargetID == reporter {
+			return nil, fmt.Errorf(errors.InvalidReportError)
+		}
+	default:
+		return nil, fmt.Errorf(errors.InvalidReportError)
+	}
+
+	return service.store.FileReport(ctx, report, reporter)
+}
+
+func (service *ModerationService) GetQueue(ctx context.Context, status domain.ModerationStatus) ([]*domain.ModerationReport, error) {
+	ctx, span := service.tracer.Start(ctx, "ModerationService.GetQueue")
+	defer span.End()
+
+	service.logging.Infoln("ModerationService.GetQueue : GetQueue service reached")
+
+	if status == "" {
+		status = domain.StatusOpen
+	}
+
+	return service.store.GetQueue(ctx, status)
+}
+
+// TakeAction closes an open report. Hiding a tweet and suspending an account are
+// delegated to tweet_service and user_service with the moderator's own token.
+func (service *ModerationService) TakeAction(ctx context.Context, reportID string, action *domain.ModerationAction, moderator string, token string) (*domain.ModerationReport, error) {
+	ctx, span := service.tracer.Start(ctx, "ModerationService.TakeAction")
+	defer span.End()
+
+	service.logging.Infoln("ModerationService.TakeAction : TakeAction service reached")
+
+	id, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, fmt.Errorf(errors.ReportNotFoundError)
+	}
+
+	report, err := service.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Status != domain.StatusOpen {
+		return nil, fmt.Errorf(errors.ReportAlreadyClosed)
+	}
+
+	switch {
+	case action.Action == domain.ActionDismiss:
+		report.Status = domain.StatusDismissed
+	case action.Action == domain.ActionHide && report.TargetType == domain.TargetTweet:
+		endpoint := fmt.Sprintf("http://%s:%s/moderation/hide/%s", tweetServiceHost, tweetServicePort, report.TargetID)
+		err = service.callService(endpoint, token)
+		report.Status = domain.StatusActioned
+	case action.Action == domain.ActionSuspend && report.TargetType == domain.TargetUser:
+		endpoint := fmt.Sprintf("http://%s:%s/suspend/%s", userServiceHost, userServicePort, report.TargetID)
+		err = service.callService(endpoint, token)
+		report.Status = domain.StatusActioned
+	default:
+		return nil, fmt.Errorf(errors.InvalidActionError)
+	}
+
+	if err != nil {
+		service.logging.Errorf("ModerationService.TakeAction.callService() : %s", err)
+		return nil, err
+	}
+
+	report.Action = action.Action
+	report.Note = action.Note
+	report.ModeratedBy = moderator
+	report.UpdatedAt = time.Now().Unix()
+
+	err = service.store.Resolve(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (service *ModerationService) callService(endpoint string, token string) error {
+	request, err := http.NewRequest("PUT", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Authorization", token)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf(errors.ServiceUnavailableError)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("moderation action failed with status %d", response.StatusCode)
+	}
+
+	return nil
+}
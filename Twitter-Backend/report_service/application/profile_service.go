@@ -0,0 +1,126 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"report_service/domain"
+	"report_service/errors"
+	"time"
+)
+
+// ProfileService keeps the report timezone of advertisers and tells which one the reports
+// of an ad are kept in. Ads of unknown advertisers use the default location
+type ProfileService struct {
+	store           domain.ProfileStore
+	ads             *AdClient
+	defaultLocation *time.Location
+	tracer          trace.Tracer
+	logger          *logrus.Logger
+}
+
+func NewProfileService(store domain.ProfileStore, ads *AdClient, defaultLocation *time.Location, tracer trace.Tracer, logger *logrus.Logger) *ProfileService {
+	return &ProfileService{
+		store:           store,
+		ads:             ads,
+		defaultLocation: defaultLocation,
+		tracer:          tracer,
+		logger:          logger,
+	}
+}
+
+// GetProfile returns the profile of the advertiser, with the default timezone if it never saved one
+func (service *ProfileService) GetProfile(ctx context.Context, advertiser string) (*domain.ReportProfile, error) {
+	ctx, span := service.tracer.Start(ctx, "ProfileService.GetProfile")
+	defer span.End()
+
+	service.logger.Infoln("ProfileService.GetProfile : GetProfile service reached")
+
+	profile, err := service.store.GetProfile(ctx, advertiser)
+	if err != nil {
+		service.logger.Errorf("Error in ProfileService.GetProfile: %s", err.Error())
+		return nil, err
+	}
+	if profile == nil {
+		profile = &domain.ReportProfile{Advertiser: advertiser, Timezone: service.defaultLocation.String()}
+	}
+	return profile, nil
+}
+
+// SaveProfile sets the timezone of the advertiser and records its current ads, so their
+// events are reported in the timezone from now on. Reports kept so far are not moved
+func (service *ProfileService) SaveProfile(ctx context.Context, token string, advertiser string, profile *domain.ReportProfile) (*domain.ReportProfile, error) {
+	ctx, span := service.tracer.Start(ctx, "ProfileService.SaveProfile")
+	defer span.End()
+
+	service.logger.Infoln("ProfileService.SaveProfile : SaveProfile service reached")
+
+	location, err := time.LoadLocation(profile.Timezone)
+	if err != nil || profile.Timezone == "" || profile.Timezone == "Local" {
+		return nil, fmt.Errorf(errors.InvalidTimezoneError)
+	}
+
+	ads, err := service.ads.AdsOf(ctx, token, advertiser)
+	if err != nil {
+		service.logger.Errorf("Error in ProfileService.SaveProfile.AdsOf: %s", err.Error())
+		return nil, err
+	}
+
+	err = service.store.SaveAdOwners(ctx, advertiser, ads)
+	if err != nil {
+		service.logger.Errorf("Error in ProfileService.SaveProfile.SaveAdOwners: %s", err.Error())
+		return nil, err
+	}
+
+	saved := domain.ReportProfile{
+		Advertiser: advertiser,
+		Timezone:   location.String(),
+		UpdatedAt:  time.Now().Unix(),
+	}
+	err = service.store.SaveProfile(ctx, &saved)
+	if err != nil {
+		service.logger.Errorf("Error in ProfileService.SaveProfile: %s", err.Error())
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// RecordAdOwners remembers who posted the ads
+func (service *ProfileService) RecordAdOwners(ctx context.Context, advertiser string, tweetIDs []string) error {
+	ctx, span := service.tracer.Start(ctx, "ProfileService.RecordAdOwners")
+	defer span.End()
+
+	return service.store.SaveAdOwners(ctx, advertiser, tweetIDs)
+}
+
+// AdLocation returns the location the reports of the ad are kept in
+func (service *ProfileService) AdLocation(ctx context.Context, tweetID string) *time.Location {
+	ctx, span := service.tracer.Start(ctx, "ProfileService.AdLocation")
+	defer span.End()
+
+	advertiser, err := service.store.GetAdOwner(ctx, tweetID)
+	if err != nil || advertiser == "" {
+		return service.defaultLocation
+	}
+	return service.AdvertiserLocation(ctx, advertiser)
+}
+
+// AdvertiserLocation returns the location the reports of the advertiser are kept in
+func (service *ProfileService) AdvertiserLocation(ctx context.Context, advertiser string) *time.Location {
+	ctx, span := service.tracer.Start(ctx, "ProfileService.AdvertiserLocation")
+	defer span.End()
+
+	profile, err := service.store.GetProfile(ctx, advertiser)
+	if err != nil || profile == nil {
+		return service.defaultLocation
+	}
+
+	location, err := time.LoadLocation(profile.Timezone)
+	if err != nil {
+		service.logger.Errorf("Error in ProfileService.AdvertiserLocation: %s", err.Error())
+		return service.defaultLocation
+	}
+	return location
+}
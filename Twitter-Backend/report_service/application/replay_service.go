@@ -0,0 +1,238 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"report_service/domain"
+	"report_service/errors"
+	"time"
+)
+
+// ReplayService rebuilds reports from the raw events and impressions in Cassandra. Only
+// reports that lie wholly in the replayed range are rebuilt, so a replay gives the same
+// reports every time it runs. Events that arrive while a replay runs may need another one
+type ReplayService struct {
+	eventStore  domain.EventStore
+	reportStore domain.ReportStore
+	profiles    *ProfileService
+	tracer      trace.Tracer
+	logger      *logrus.Logger
+}
+
+func NewReplayService(eventStore domain.EventStore, reportStore domain.ReportStore, profiles *ProfileService, tracer trace.Tracer, logger *logrus.Logger) *ReplayService {
+	return &ReplayService{
+		eventStore:  eventStore,
+		reportStore: reportStore,
+		profiles:    profiles,
+		tracer:      tracer,
+		logger:      logger,
+	}
+}
+
+func (service *ReplayService) Replay(ctx context.Context, request *domain.ReplayRequest) (*domain.ReplayResult, error) {
+	ctx, span := service.tracer.Start(ctx, "ReplayService.Replay")
+	defer span.End()
+
+	service.logger.Infof("ReplayService.Replay : tweet %q from %d to %d, dry run %t", request.TweetID, request.From, request.To, request.DryRun)
+
+	if request.TweetID == "" && request.From == 0 && request.To == 0 {
+		return nil, fmt.Errorf(errors.InvalidReplayError)
+	}
+	to := request.To
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if request.From < 0 || request.From > to {
+		return nil, fmt.Errorf(errors.InvalidRangeError)
+	}
+
+	tweetIDs := []string{request.TweetID}
+	if request.TweetID == "" {
+		var err error
+		tweetIDs, err = service.eventStore.GetTweetIDs(ctx)
+		if err != nil {
+			service.logger.Errorf("Error in ReplayService.Replay.GetTweetIDs: %s", err.Error())
+			return nil, err
+		}
+	}
+
+	result := domain.ReplayResult{DryRun: request.DryRun, Checks: []*domain.ReplayCheck{}}
+	for _, tweetID := range tweetIDs {
+		checks, err := service.replayTweet(ctx, tweetID, request.From, to, request.DryRun)
+		if err != nil {
+			service.logger.Errorf("Error in ReplayService.Replay.replayTweet %s: %s", tweetID, err.Error())
+			return nil, err
+		}
+
+		result.Tweets++
+		for _, check := range checks {
+			result.Changed += check.Changed
+		}
+		result.Checks = append(result.Checks, checks...)
+	}
+
+	service.logger.Infof("ReplayService.Replay : %d tweets replayed, %d reports changed", result.Tweets, result.Changed)
+	return &result, nil
+}
+
+// replayTweet reads the events of the tweet in every report touching from and to, and
+// rebuilds the reports of each granularity that lie wholly in what was read
+func (service *ReplayService) replayTweet(ctx context.Context, tweetID string, from int64, to int64, dryRun bool) ([]*domain.ReplayCheck, error) {
+	location := service.profiles.AdLocation(ctx, tweetID)
+	start, end := replayWindow(location, from, to)
+
+	events, err := service.eventStore.GetEvents(ctx, tweetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	impressions, err := service.eventStore.GetImpressions(ctx, tweetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*domain.ReplayCheck, 0, len(domain.Granularities))
+	for _, granularity := range domain.Granularities {
+		first := granularity.Start(time.Unix(start, 0).In(location))
+		if first.Unix() < start {
+			first = granularity.Next(first)
+		}
+		last := granularity.Start(time.Unix(end, 0).In(location))
+		if !first.Before(last) {
+			continue
+		}
+
+		rebuilt, reach := rebuildReports(tweetID, granularity, location, first.Unix(), last.Unix(), events, impressions)
+
+		current, err := service.reportStore.GetReports(ctx, []string{tweetID}, string(granularity), first.Unix(), last.Unix()-1)
+		if err != nil {
+			return nil, err
+		}
+
+		check := compareReports(current, rebuilt)
+		check.TweetID = tweetID
+		check.Granularity = granularity
+		check.From = first.Unix()
+		check.To = last.Unix()
+		checks = append(checks, check)
+
+		if dryRun || check.Changed == 0 {
+			continue
+		}
+		err = service.reportStore.ReplaceReports(ctx, tweetID, granularity, first.Unix(), last.Unix(), rebuilt, reach)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
+
+// replayWindow widens from and to to the start and end of the widest reports they fall in
+func replayWindow(location *time.Location, from int64, to int64) (int64, int64) {
+	start, end := from, to
+	for _, granularity := range domain.Granularities {
+		first := granularity.Start(time.Unix(from, 0).In(location))
+		last := granularity.Next(granularity.Start(time.Unix(to, 0).In(location)))
+		if from != 0 && first.Unix() < start {
+			start = first.Unix()
+		}
+		if last.Unix() > end {
+			end = last.Unix()
+		}
+	}
+	return start, end
+}
+
+// rebuildReports sums up the events and impressions into the reports from from up to to
+func rebuildReports(tweetID string, granularity domain.Granularity, location *time.Location, from int64, to int64, events []*domain.Event, impressions []*domain.Impression) ([]*domain.Report, map[int64][]string) {
+	reports := make(map[int64]*domain.Report)
+	report := func(timestamp int64) *domain.Report {
+		date := granularity.Start(time.Unix(timestamp, 0).In(location)).Unix()
+		if date < from || date >= to {
+			return nil
+		}
+		if reports[date] == nil {
+			reports[date] = &domain.Report{TweetID: tweetID, Timestamp: date}
+		}
+		return reports[date]
+	}
+
+	for _, event := range events {
+		found := report(event.Timestamp)
+		if found == nil {
+			continue
+		}
+		switch event.Type {
+		case "Liked":
+			found.LikeCount++
+		case "Unliked":
+			found.UnlikeCount++
+		case "ViewCount":
+			found.ViewCount++
+		case "Timespent":
+			found.Timespent += int(event.Timespent)
+			found.TimespentCount++
+		}
+	}
+
+	reach := make(map[int64][]string)
+	reached := make(map[int64]map[string]bool)
+	for _, impression := range impressions {
+		found := report(impression.Timestamp)
+		if found == nil {
+			continue
+		}
+		found.Impressions++
+		if reached[found.Timestamp] == nil {
+			reached[found.Timestamp] = make(map[string]bool)
+		}
+		if !reached[found.Timestamp][impression.Username] {
+			reached[found.Timestamp][impression.Username] = true
+			reach[found.Timestamp] = append(reach[found.Timestamp], impression.Username)
+			found.Reach++
+		}
+	}
+
+	result := make([]*domain.Report, 0, len(reports))
+	for _, report := range reports {
+		result = append(result, report)
+	}
+	return result, reach
+}
+
+// compareReports sums up the current and rebuilt reports and counts the reports that differ,
+// including current reports the events don't back
+func compareReports(current []*domain.Report, rebuilt []*domain.Report) *domain.ReplayCheck {
+	check := domain.ReplayCheck{Reports: len(rebuilt)}
+
+	byDate := make(map[int64]*domain.Report, len(current))
+	for _, report := range current {
+		addReport(&check.Current, report)
+		if byDate[report.Timestamp] != nil {
+			//duplicates of a report are always replaced
+			check.Changed++
+			continue
+		}
+		byDate[report.Timestamp] = report
+	}
+
+	for _, report := range rebuilt {
+		addReport(&check.Rebuilt, report)
+		existing, ok := byDate[report.Timestamp]
+		if !ok || !sameCounts(existing, report) {
+			check.Changed++
+		}
+		delete(byDate, report.Timestamp)
+	}
+	check.Changed += len(byDate)
+
+	check.Current.ComputeRates()
+	check.Rebuilt.ComputeRates()
+	return &check
+}
+
+func sameCounts(a *domain.Report, b *domain.Report) bool {
+	return a.LikeCount == b.LikeCount && a.UnlikeCount == b.UnlikeCount && a.ViewCount == b.ViewCount &&
+		a.Timespent == b.Timespent && a.TimespentCount == b.TimespentCount && a.Impressions == b.Impressions && a.Reach == b.Reach
+}
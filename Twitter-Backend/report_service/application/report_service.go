@@ -2,95 +2,406 @@ package application
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/gocql/gocql"
 	"github.com/sirupsen/logrus"
-	events "github.com/zjalicf/twitter-clone-common/common/saga/create_event"
 	"go.opentelemetry.io/otel/trace"
 	"log"
 	"report_service/domain"
+	"report_service/errors"
+	"sort"
+	"strings"
 	"time"
 )
 
+const maxReportDates = 400
+
+// ReportService keeps the reports of ads, reports start at midnight in the timezone of the
+// advertiser
 type ReportService struct {
 	eventStore  domain.EventStore
 	reportStore domain.ReportStore
+	ads         *AdClient
+	profiles    *ProfileService
 	tracer      trace.Tracer
 	logger      *logrus.Logger
 }
 
-func NewReportService(eventStore domain.EventStore, reportStore domain.ReportStore, tracer trace.Tracer, logger *logrus.Logger) *ReportService {
+func NewReportService(eventStore domain.EventStore, reportStore domain.ReportStore, ads *AdClient, profiles *ProfileService, tracer trace.Tracer, logger *logrus.Logger) *ReportService {
 	return &ReportService{
 		eventStore:  eventStore,
 		reportStore: reportStore,
+		ads:         ads,
+		profiles:    profiles,
 		tracer:      tracer,
 		logger:      logger,
 	}
 }
 
-func (service *ReportService) CreateEvent(event events.Event) {
-	eventOut := EventToDomain(event)
+// CreateEvent keeps the raw event under its ID, so a redelivered event overwrites itself. Events
+// of older producers come without an ID and get one here, the reply carries it on to CreateReport
+func (service *ReportService) CreateEvent(event *domain.AdEvent) error {
+	if event.ID == "" {
+		id, _ := gocql.RandomUUID()
+		event.ID = id.String()
+	}
+
+	eventOut := EventToDomain(*event)
 	_, err := service.eventStore.CreateEvent(context.TODO(), &eventOut)
 	if err != nil {
-		log.Printf("Error in report_service CreateEvent()", err.Error())
-		return
+		log.Printf("Error in report_service CreateEvent(): %s", err.Error())
+		return err
 	}
+	return nil
 }
 
-func (service *ReportService) CreateReport(event *events.Event) {
+// CreateReport counts the event in its reports once. Every report skips an event ID it
+// counted already, so an event redelivered after counting it in some of them is only added
+// to the rest. It is marked processed once all of them counted it
+func (service *ReportService) CreateReport(event *domain.AdEvent) error {
 	ctx, span := service.tracer.Start(context.TODO(), "ReportService.CreateReport")
 	defer span.End()
 
 	service.logger.Infoln("ReportService.CreateReport : Create Report service reached")
 
-	if event.Type == "Timespent" {
-		domainEvent := EventToDomain(*event)
-		timeSpendDaily, err := service.eventStore.GetTimespentDailyEvents(ctx, &domainEvent)
+	if event.ID != "" {
+		processed, err := service.reportStore.ProcessedEvents(ctx, []string{event.ID})
 		if err != nil {
-			service.logger.Errorf("Error in getting daily spent: %s", err.Error())
-			return
+			service.logger.Errorf("Error in ReportService.CreateReport.ProcessedEvents: %s", err.Error())
+			return err
+		}
+		if processed[event.ID] {
+			service.logger.Infof("ReportService.CreateReport : event %s is already counted", event.ID)
+			return nil
 		}
-		timeSpendMonthly, err := service.eventStore.GetTimespentMonthlyEvents(ctx, &domainEvent)
+	}
+
+	dates := domain.ReportDates(event.Timestamp, service.profiles.AdLocation(ctx, event.TweetID))
+
+	_, err := service.reportStore.CreateReport(ctx, event.ID, &event.Event, dates)
+	if err != nil {
+		service.logger.Errorf("Error in report_service CreateReport(): %s", err.Error())
+		return err
+	}
+
+	if event.ID != "" {
+		err = service.reportStore.MarkEventsProcessed(ctx, []string{event.ID})
 		if err != nil {
-			service.logger.Errorf("Error in getting monthly spent: %s", err.Error())
-			return
+			service.logger.Errorf("Error in ReportService.CreateReport.MarkEventsProcessed: %s", err.Error())
+			return err
 		}
+	}
+
+	log.Println("Succesfull updated report")
+	return nil
+}
+
+// RecordImpressions keeps the raw impressions of the batch and adds them to the reports,
+// impressions of one ad in the same hour are added to its reports at once. Impressions are
+// counted once by their ID like events, a report skips a group of impressions it counted
+// already and the impressions are marked processed once all groups are counted
+func (service *ReportService) RecordImpressions(ctx context.Context, batch *domain.ImpressionBatch) error {
+	ctx, span := service.tracer.Start(ctx, "ReportService.RecordImpressions")
+	defer span.End()
 
-		event.DailySpent = timeSpendDaily
-		event.MonthlySpent = timeSpendMonthly
+	service.logger.Infof("ReportService.RecordImpressions : %d impressions", len(batch.Impressions))
+
+	err := service.eventStore.CreateImpressions(ctx, batch.Impressions)
+	if err != nil {
+		service.logger.Errorf("Error in ReportService.RecordImpressions.CreateImpressions: %s", err.Error())
+		return err
 	}
 
-	log.Printf("Timedaily :%s , TimeMonthly: %s", event.DailySpent, event.MonthlySpent)
-	thisT := time.Unix(event.Timestamp, 0)
-	localTime := time.Date(thisT.Year(), thisT.Month(), thisT.Day(), thisT.Hour()+1, 0, 0, 0, time.Local)
+	owners := make(map[string][]string)
+	for _, impression := range batch.Impressions {
+		if impression.Advertiser != "" {
+			owners[impression.Advertiser] = append(owners[impression.Advertiser], impression.TweetID)
+		}
+	}
+	for advertiser, ads := range owners {
+		err = service.profiles.RecordAdOwners(ctx, advertiser, ads)
+		if err != nil {
+			service.logger.Errorf("Error in ReportService.RecordImpressions.RecordAdOwners: %s", err.Error())
+		}
+	}
 
-	dailyUnix := time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, time.Local).Unix() //dailyRepDate
-	monthlyUnix := time.Date(localTime.Year(), localTime.Month(), 1, 0, 0, 0, 0, time.Local).Unix()             //monthlyRepDate
-	_, err := service.reportStore.CreateReport(ctx, event, monthlyUnix, dailyUnix)
+	//the hour of an impression tells all other reports it is counted in
+	type reportKey struct {
+		tweetID string
+		hour    int64
+	}
+	type reportImpressions struct {
+		dates   []*domain.ReportDate
+		viewers map[string]int
+		ids     []string
+	}
+
+	var ids []string
+	for _, impression := range batch.Impressions {
+		if impression.ID != "" {
+			ids = append(ids, impression.ID)
+		}
+	}
+	processed, err := service.reportStore.ProcessedEvents(ctx, ids)
 	if err != nil {
-		service.logger.Errorf("Error in report_service CreateReport(): %s", err.Error())
-		return
-	} else {
-		log.Println("Succesfull updated report")
+		service.logger.Errorf("Error in ReportService.RecordImpressions.ProcessedEvents: %s", err.Error())
+		return err
 	}
 
+	locations := make(map[string]*time.Location)
+	reports := make(map[reportKey]*reportImpressions)
+	var counted []string
+	for _, impression := range batch.Impressions {
+		if processed[impression.ID] {
+			continue
+		}
+
+		location, ok := locations[impression.TweetID]
+		if !ok {
+			location = service.profiles.AdLocation(ctx, impression.TweetID)
+			locations[impression.TweetID] = location
+		}
+
+		dates := domain.ReportDates(impression.Timestamp, location)
+		key := reportKey{tweetID: impression.TweetID, hour: domain.Hourly.Start(time.Unix(impression.Timestamp, 0).In(location)).Unix()}
+		if reports[key] == nil {
+			reports[key] = &reportImpressions{dates: dates, viewers: make(map[string]int)}
+		}
+		reports[key].viewers[impression.Username]++
+		if impression.ID != "" {
+			reports[key].ids = append(reports[key].ids, impression.ID)
+			counted = append(counted, impression.ID)
+		}
+	}
+
+	for key, report := range reports {
+		err = service.reportStore.AddImpressions(ctx, updateID(report.ids), key.tweetID, report.dates, report.viewers)
+		if err != nil {
+			service.logger.Errorf("Error in ReportService.RecordImpressions.AddImpressions: %s", err.Error())
+			return err
+		}
+	}
+
+	err = service.reportStore.MarkEventsProcessed(ctx, counted)
+	if err != nil {
+		service.logger.Errorf("Error in ReportService.RecordImpressions.MarkEventsProcessed: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// updateID names a group of impressions by their IDs, a redelivered batch makes the same
+// groups with the same names. A group without IDs gets none and is always counted
+func updateID(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	sum := sha1.Sum([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(sum[:])
 }
 
-func (service *ReportService) GetReportForAd(ctx context.Context, tweetID string, reportType string, date int64) (*domain.Report, error) {
-	ctx, span := service.tracer.Start(context.TODO(), "ReportService.GetReportForAd")
+// GetReportForAd returns the report of the ad for the given date, only to the advertiser
+func (service *ReportService) GetReportForAd(ctx context.Context, token string, advertiser string, tweetID string, reportType string, date int64) (*domain.Report, error) {
+	ctx, span := service.tracer.Start(ctx, "ReportService.GetReportForAd")
 	defer span.End()
 
 	service.logger.Infoln("ReportService.GetReportForAd : GetReportForAd service reached")
 
+	err := service.checkOwner(ctx, token, advertiser, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
 	result, err := service.reportStore.GetReportForAd(ctx, tweetID, reportType, date)
 	if err != nil {
 		service.logger.Errorf("Error in ReportService GetReportForAd: %s", err.Error())
 		return nil, err
 	}
+	if result != nil {
+		result.ComputeRates()
+		result.Date = reportDate(result.Timestamp, service.profiles.AdvertiserLocation(ctx, advertiser))
+	}
 	return result, nil
 }
 
-func EventToDomain(event events.Event) domain.Event {
+// GetReportSeries returns the reports of the ad for every date between from and to, dates
+// without events get an empty report. A zero from or to falls back to the latest reports
+func (service *ReportService) GetReportSeries(ctx context.Context, token string, advertiser string, tweetID string, reportType string, from int64, to int64) ([]*domain.Report, error) {
+	ctx, span := service.tracer.Start(ctx, "ReportService.GetReportSeries")
+	defer span.End()
+
+	service.logger.Infoln("ReportService.GetReportSeries : GetReportSeries service reached")
+
+	location := service.profiles.AdvertiserLocation(ctx, advertiser)
+	dates, end, err := reportRange(reportType, location, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.checkOwner(ctx, token, advertiser, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := service.reportStore.GetReports(ctx, []string{tweetID}, reportType, dates[0], end-1)
+	if err != nil {
+		service.logger.Errorf("Error in ReportService.GetReportSeries.GetReports: %s", err.Error())
+		return nil, err
+	}
+
+	return fillSeries(tweetID, location, dates, reports), nil
+}
+
+// GetAdvertiserReport sums up the reports of all ads of the advertiser. Reach is summed as
+// well, a user reached by two ads or on two dates counts twice
+func (service *ReportService) GetAdvertiserReport(ctx context.Context, token string, advertiser string, reportType string, from int64, to int64) (*domain.AdvertiserReport, error) {
+	ctx, span := service.tracer.Start(ctx, "ReportService.GetAdvertiserReport")
+	defer span.End()
+
+	service.logger.Infoln("ReportService.GetAdvertiserReport : GetAdvertiserReport service reached")
+
+	location := service.profiles.AdvertiserLocation(ctx, advertiser)
+	dates, end, err := reportRange(reportType, location, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ads, err := service.ads.AdsOf(ctx, token, advertiser)
+	if err != nil {
+		service.logger.Errorf("Error in ReportService.GetAdvertiserReport.AdsOf: %s", err.Error())
+		return nil, err
+	}
+
+	reports := []*domain.Report{}
+	if len(ads) > 0 {
+		reports, err = service.reportStore.GetReports(ctx, ads, reportType, dates[0], end-1)
+		if err != nil {
+			service.logger.Errorf("Error in ReportService.GetAdvertiserReport.GetReports: %s", err.Error())
+			return nil, err
+		}
+	}
+
+	result := domain.AdvertiserReport{
+		Advertiser: advertiser,
+		ReportType: reportType,
+		Timezone:   location.String(),
+		From:       dates[0],
+		To:         dates[len(dates)-1],
+		Ads:        ads,
+		Series:     fillSeries("", location, dates, reports),
+	}
+	for _, report := range result.Series {
+		addReport(&result.Total, report)
+	}
+	result.Total.ComputeRates()
+
+	return &result, nil
+}
+
+func (service *ReportService) checkOwner(ctx context.Context, token string, advertiser string, tweetID string) error {
+	ads, err := service.ads.AdsOf(ctx, token, advertiser)
+	if err != nil {
+		service.logger.Errorf("Error in ReportService.checkOwner.AdsOf: %s", err.Error())
+		return err
+	}
+
+	for _, ad := range ads {
+		if ad == tweetID {
+			return nil
+		}
+	}
+	return fmt.Errorf(errors.NotAdOwnerError)
+}
+
+// reportRange returns the start of every report from the one of from up to the one of to,
+// followed by the end of the last report
+func reportRange(reportType string, location *time.Location, from int64, to int64) ([]int64, int64, error) {
+	granularity, ok := domain.ParseGranularity(reportType)
+	if !ok {
+		return nil, 0, fmt.Errorf(errors.InvalidReportTypeError)
+	}
+
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	if from == 0 {
+		from = defaultFrom(granularity, time.Unix(to, 0).In(location)).Unix()
+	}
+	if from > to {
+		return nil, 0, fmt.Errorf(errors.InvalidRangeError)
+	}
+
+	last := granularity.Start(time.Unix(to, 0).In(location))
+	dates := []int64{}
+	for date := granularity.Start(time.Unix(from, 0).In(location)); !date.After(last); date = granularity.Next(date) {
+		if len(dates) == maxReportDates {
+			return nil, 0, fmt.Errorf(errors.InvalidRangeError)
+		}
+		dates = append(dates, date.Unix())
+	}
+	return dates, granularity.Next(last).Unix(), nil
+}
+
+// defaultFrom returns where a range up to to starts when it has no from
+func defaultFrom(granularity domain.Granularity, to time.Time) time.Time {
+	switch granularity {
+	case domain.Hourly:
+		return to.Add(-23 * time.Hour)
+	case domain.Weekly:
+		return to.AddDate(0, 0, -7*11)
+	case domain.Monthly:
+		return to.AddDate(0, -11, 0)
+	default:
+		return to.AddDate(0, 0, -29)
+	}
+}
+
+// fillSeries sums up the reports per date and returns one report for every date. A report is
+// counted in the date it falls in, reports kept before the advertiser changed its timezone
+// start at other times
+func fillSeries(tweetID string, location *time.Location, dates []int64, reports []*domain.Report) []*domain.Report {
+	series := make([]*domain.Report, 0, len(dates))
+	for _, date := range dates {
+		series = append(series, &domain.Report{TweetID: tweetID, Timestamp: date, Date: reportDate(date, location)})
+	}
+
+	for _, report := range reports {
+		i := sort.Search(len(dates), func(i int) bool { return dates[i] > report.Timestamp }) - 1
+		if i >= 0 {
+			addReport(series[i], report)
+		}
+	}
+
+	for _, report := range series {
+		report.ComputeRates()
+	}
+	return series
+}
+
+func reportDate(timestamp int64, location *time.Location) string {
+	return time.Unix(timestamp, 0).In(location).Format(time.RFC3339)
+}
+
+func addReport(sum *domain.Report, report *domain.Report) {
+	sum.LikeCount += report.LikeCount
+	sum.UnlikeCount += report.UnlikeCount
+	sum.ViewCount += report.ViewCount
+	sum.Timespent += report.Timespent
+	sum.TimespentCount += report.TimespentCount
+	sum.Impressions += report.Impressions
+	sum.Reach += report.Reach
+}
+
+func EventToDomain(event domain.AdEvent) domain.Event {
 
 	return domain.Event{
+		ID:           event.ID,
 		TweetID:      event.TweetID,
 		Type:         event.Type,
 		Timestamp:    event.Timestamp,
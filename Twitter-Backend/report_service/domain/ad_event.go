@@ -0,0 +1,30 @@
+package domain
+
+import (
+	events "github.com/zjalicf/twitter-clone-common/common/saga/create_event"
+)
+
+// AdEvent is the saga event with the ID tweet_service gives it, the embedded fields are
+// encoded next to ID so commands of older producers decode with an empty ID
+type AdEvent struct {
+	ID string
+	events.Event
+}
+
+type AdEventCommand struct {
+	Event AdEvent
+	Type  events.CreateEventCommandType
+}
+
+type AdEventReply struct {
+	Event AdEvent
+	Type  events.CreateEventReplyType
+}
+
+// DeadLetter is an event that could not be added to the reports, published so it can be
+// looked into and sent again
+type DeadLetter struct {
+	Event    AdEvent `json:"event"`
+	Error    string  `json:"error"`
+	FailedAt int64   `json:"failed_at"`
+}
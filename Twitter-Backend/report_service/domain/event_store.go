@@ -6,6 +6,8 @@ import (
 
 type EventStore interface {
 	CreateEvent(context.Context, *Event) (*Event, error)
-	GetTimespentDailyEvents(ctx context.Context, event *Event) (int64, error)
-	GetTimespentMonthlyEvents(ctx context.Context, event *Event) (int64, error)
+	CreateImpressions(ctx context.Context, impressions []Impression) error
+	GetEvents(ctx context.Context, tweetID string, from int64, to int64) ([]*Event, error)
+	GetImpressions(ctx context.Context, tweetID string, from int64, to int64) ([]*Impression, error)
+	GetTweetIDs(ctx context.Context) ([]string, error)
 }
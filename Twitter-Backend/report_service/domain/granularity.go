@@ -0,0 +1,83 @@
+package domain
+
+import "time"
+
+// Granularity is the length of the time a report sums up events over
+type Granularity string
+
+const (
+	Hourly  Granularity = "hourly"
+	Daily   Granularity = "daily"
+	Weekly  Granularity = "weekly"
+	Monthly Granularity = "monthly"
+)
+
+// Granularities holds every granularity reports are kept in
+var Granularities = []Granularity{Hourly, Daily, Weekly, Monthly}
+
+func ParseGranularity(value string) (Granularity, bool) {
+	for _, granularity := range Granularities {
+		if string(granularity) == value {
+			return granularity, true
+		}
+	}
+	return "", false
+}
+
+// Start returns the start of the report t falls in, in the location of t. Weeks are ISO
+// weeks and start on Monday
+func (granularity Granularity) Start(t time.Time) time.Time {
+	switch granularity {
+	case Hourly:
+		//truncating the wall clock keeps the repeated hour of a DST change apart
+		_, offset := t.Zone()
+		zone := time.Duration(offset) * time.Second
+		return t.Add(zone).Truncate(time.Hour).Add(-zone)
+	case Weekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the report after the one starting at start
+func (granularity Granularity) Next(start time.Time) time.Time {
+	switch granularity {
+	case Hourly:
+		return start.Add(time.Hour)
+	case Weekly:
+		return start.AddDate(0, 0, 7)
+	case Monthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// ReportDate is one report an event is counted in, the report sums up the events from
+// Timestamp up to End
+type ReportDate struct {
+	Granularity Granularity
+	Timestamp   int64
+	End         int64
+}
+
+// ReportDates returns the report of every granularity the timestamp falls in, reports
+// start at midnight in the location
+func ReportDates(timestamp int64, location *time.Location) []*ReportDate {
+	t := time.Unix(timestamp, 0).In(location)
+
+	dates := make([]*ReportDate, 0, len(Granularities))
+	for _, granularity := range Granularities {
+		start := granularity.Start(t)
+		dates = append(dates, &ReportDate{
+			Granularity: granularity,
+			Timestamp:   start.Unix(),
+			End:         granularity.Next(start).Unix(),
+		})
+	}
+	return dates
+}
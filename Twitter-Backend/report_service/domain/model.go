@@ -2,17 +2,60 @@ package domain
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Report sums up the events of an ad tweet in an hour, day, week or month. Timespent is the
+// total time spent on the ad over TimespentCount visits. Impressions counts the times the ad
+// was served and Reach the users it was served to. AvgTimespent, CTR and EngagementRate are
+// not stored, they are computed from the counts when the report is read
 type Report struct {
-	ID          primitive.ObjectID `bson:"_id"`
-	TweetID     string             `json:"tweet_id" bson:"tweet_id"`
-	Timestamp   int64              `json:"timestamp" bson:"timestamp"`
-	LikeCount   int                `json:"like_count" bson:"like_count"`
-	UnlikeCount int                `json:"unlike_count" bson:"unlike_count"`
-	ViewCount   int                `json:"view_count" bson:"view_count"`
-	Timespent   int                `json:"time_spent" bson:"time_spent"`
+	ID             primitive.ObjectID `bson:"_id"`
+	TweetID        string             `json:"tweet_id" bson:"tweet_id"`
+	Timestamp      int64              `json:"timestamp" bson:"timestamp"`
+	Date           string             `json:"date,omitempty" bson:"-"`
+	LikeCount      int                `json:"like_count" bson:"like_count"`
+	UnlikeCount    int                `json:"unlike_count" bson:"unlike_count"`
+	ViewCount      int                `json:"view_count" bson:"view_count"`
+	Timespent      int                `json:"time_spent" bson:"time_spent"`
+	TimespentCount int                `json:"time_spent_events" bson:"time_spent_events"`
+	Impressions    int                `json:"impressions" bson:"impressions"`
+	Reach          int                `json:"reach" bson:"reach"`
+	AvgTimespent   float64            `json:"average_time_spent" bson:"-"`
+	CTR            float64            `json:"ctr" bson:"-"`
+	EngagementRate float64            `json:"engagement_rate" bson:"-"`
+}
+
+// ComputeRates sets AvgTimespent, CTR, the profile views from the ad per impression, and
+// EngagementRate, the likes and profile views per impression. The rates stay zero without
+// impressions
+func (report *Report) ComputeRates() {
+	report.AvgTimespent = 0
+	if report.TimespentCount != 0 {
+		report.AvgTimespent = float64(report.Timespent) / float64(report.TimespentCount)
+	}
+
+	report.CTR = 0
+	report.EngagementRate = 0
+	if report.Impressions == 0 {
+		return
+	}
+	report.CTR = float64(report.ViewCount) / float64(report.Impressions)
+	report.EngagementRate = float64(report.LikeCount+report.ViewCount) / float64(report.Impressions)
+}
+
+// AdvertiserReport sums up the reports of all ads of an advertiser between From and To,
+// Series holds the sums per report date and Total the sum over the whole range
+type AdvertiserReport struct {
+	Advertiser string    `json:"advertiser"`
+	ReportType string    `json:"report_type"`
+	Timezone   string    `json:"timezone"`
+	From       int64     `json:"from"`
+	To         int64     `json:"to"`
+	Ads        []string  `json:"ads"`
+	Total      Report    `json:"total"`
+	Series     []*Report `json:"series"`
 }
 
 type Event struct {
+	ID           string
 	TweetID      string
 	Type         string
 	Timestamp    int64
@@ -20,3 +63,16 @@ type Event struct {
 	DailySpent   int64
 	MonthlySpent int64
 }
+
+// Impression is one ad served to Username in a feed, published by tweet_service in batches
+type Impression struct {
+	ID         string `json:"id"`
+	TweetID    string `json:"tweet_id"`
+	Advertiser string `json:"advertiser"`
+	Username   string `json:"username"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+type ImpressionBatch struct {
+	Impressions []Impression `json:"impressions"`
+}
@@ -0,0 +1,76 @@
+package domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type TargetType string
+
+const (
+	TargetTweet TargetType = "tweet"
+	TargetUser  TargetType = "user"
+)
+
+type ReasonCategory string
+
+const (
+	ReasonSpam           ReasonCategory = "spam"
+	ReasonHarassment     ReasonCategory = "harassment"
+	ReasonHateSpeech     ReasonCategory = "hate_speech"
+	ReasonViolence       ReasonCategory = "violence"
+	ReasonMisinformation ReasonCategory = "misinformation"
+	ReasonImpersonation  ReasonCategory = "impersonation"
+	ReasonOther          ReasonCategory = "other"
+)
+
+type ModerationStatus string
+
+const (
+	StatusOpen      ModerationStatus = "open"
+	StatusActioned  ModerationStatus = "actioned"
+	StatusDismissed ModerationStatus = "dismissed"
+)
+
+const (
+	ActionHide    = "hide"
+	ActionSuspend = "suspend"
+	ActionDismiss = "dismiss"
+)
+
+// ContentReport is a single user's report, as sent by the client
+type ContentReport struct {
+	TargetType  TargetType     `json:"target_type"`
+	TargetID    string         `json:"target_id"`
+	Reason      ReasonCategory `json:"reason"`
+	Description string         `json:"description"`
+}
+
+// ModerationReport groups every open report filed against the same target,
+// so moderators see one queue entry per tweet or account
+type ModerationReport struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	TargetType   TargetType         `bson:"target_type" json:"target_type"`
+	TargetID     string             `bson:"target_id" json:"target_id"`
+	Reasons      []ReasonCategory   `bson:"reasons" json:"reasons"`
+	Descriptions []string           `bson:"descriptions" json:"descriptions"`
+	Reporters    []string           `bson:"reporters" json:"-"`
+	ReportCount  int                `bson:"report_count" json:"report_count"`
+	Status       ModerationStatus   `bson:"status" json:"status"`
+	Action       string             `bson:"action,omitempty" json:"action,omitempty"`
+	Note         string             `bson:"note,omitempty" json:"note,omitempty"`
+	ModeratedBy  string             `bson:"moderated_by,omitempty" json:"moderated_by,omitempty"`
+	CreatedAt    int64              `bson:"created_at" json:"created_at"`
+	UpdatedAt    int64              `bson:"updated_at" json:"updated_at"`
+}
+
+type ModerationAction struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+func (reason ReasonCategory) IsValid() bool {
+	switch reason {
+	case ReasonSpam, ReasonHarassment, ReasonHateSpeech, ReasonViolence,
+		ReasonMisinformation, ReasonImpersonation, ReasonOther:
+		return true
+	}
+	return false
+}
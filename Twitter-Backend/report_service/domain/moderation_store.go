@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ModerationStore interface {
+	FileReport(ctx context.Context, report *ContentReport, reporter string) (*ModerationReport, error)
+	GetQueue(ctx context.Context, status ModerationStatus) ([]*ModerationReport, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*ModerationReport, error)
+	Resolve(ctx context.Context, report *ModerationReport) error
+}
@@ -0,0 +1,9 @@
+package domain
+
+// ReportProfile holds the report settings of an advertiser. Reports of its ads start at
+// midnight in Timezone, an IANA name
+type ReportProfile struct {
+	Advertiser string `json:"advertiser" bson:"advertiser"`
+	Timezone   string `json:"timezone" bson:"timezone"`
+	UpdatedAt  int64  `json:"updated_at" bson:"updated_at"`
+}
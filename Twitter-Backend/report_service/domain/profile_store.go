@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"context"
+)
+
+type ProfileStore interface {
+	SaveProfile(ctx context.Context, profile *ReportProfile) error
+	GetProfile(ctx context.Context, advertiser string) (*ReportProfile, error)
+	SaveAdOwners(ctx context.Context, advertiser string, tweetIDs []string) error
+	GetAdOwner(ctx context.Context, tweetID string) (string, error)
+}
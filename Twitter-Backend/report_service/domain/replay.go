@@ -0,0 +1,32 @@
+package domain
+
+// ReplayRequest asks for the reports of TweetID, or of every ad with events when it is
+// empty, to be rebuilt from the raw events between From and To. A zero From replays from
+// the first event, a zero To up to now. DryRun only compares the rebuilt reports
+type ReplayRequest struct {
+	TweetID string `json:"tweet_id"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// ReplayCheck compares the reports of an ad in one granularity between From and To before
+// and after the replay. Current and Rebuilt sum up the reports, Changed counts the reports
+// that differ
+type ReplayCheck struct {
+	TweetID     string      `json:"tweet_id"`
+	Granularity Granularity `json:"granularity"`
+	From        int64       `json:"from"`
+	To          int64       `json:"to"`
+	Reports     int         `json:"reports"`
+	Changed     int         `json:"changed"`
+	Current     Report      `json:"current"`
+	Rebuilt     Report      `json:"rebuilt"`
+}
+
+type ReplayResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Tweets  int            `json:"tweets"`
+	Changed int            `json:"changed"`
+	Checks  []*ReplayCheck `json:"checks"`
+}
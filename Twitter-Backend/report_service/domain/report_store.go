@@ -6,6 +6,11 @@ import (
 )
 
 type ReportStore interface {
-	CreateReport(context.Context, *events.Event, int64, int64) (*events.Event, error)
+	CreateReport(ctx context.Context, eventID string, event *events.Event, dates []*ReportDate) (*events.Event, error)
 	GetReportForAd(ctx context.Context, tweetID string, reportType string, date int64) (*Report, error)
+	GetReports(ctx context.Context, tweetIDs []string, reportType string, from int64, to int64) ([]*Report, error)
+	AddImpressions(ctx context.Context, updateID string, tweetID string, dates []*ReportDate, viewers map[string]int) error
+	ReplaceReports(ctx context.Context, tweetID string, granularity Granularity, from int64, to int64, reports []*Report, reach map[int64][]string) error
+	ProcessedEvents(ctx context.Context, eventIDs []string) (map[string]bool, error)
+	MarkEventsProcessed(ctx context.Context, eventIDs []string) error
 }
@@ -0,0 +1,16 @@
+package errors
+
+const (
+	InvalidReportError      = "invalid report, target and reason are required"
+	AlreadyReportedError    = "you have already reported this content"
+	ReportNotFoundError     = "report not found"
+	ReportAlreadyClosed     = "report is already closed"
+	InvalidActionError      = "action is not allowed for this report"
+	ModeratorOnlyError      = "only moderators can access the moderation queue"
+	ServiceUnavailableError = "service is unavailable at the moment"
+	InvalidReportTypeError  = "report type must be hourly, daily, weekly or monthly"
+	InvalidRangeError       = "invalid range, from must not be after to and the range may hold at most 400 reports"
+	NotAdOwnerError         = "reports are only available for your own ads"
+	InvalidTimezoneError    = "unknown timezone, use an IANA name like Europe/Belgrade"
+	InvalidReplayError      = "a replay needs a tweet or a time range"
+)
@@ -3,21 +3,26 @@ package handlers
 import (
 	events "github.com/zjalicf/twitter-clone-common/common/saga/create_event"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"log"
 	"report_service/application"
+	"report_service/domain"
+	"time"
 )
 
 type CreateReportCommandHandler struct {
-	reportService     *application.ReportService
-	replyPublisher    saga.Publisher
-	commandSubscriber saga.Subscriber
+	reportService       *application.ReportService
+	replyPublisher      saga.Publisher
+	deadLetterPublisher saga.Publisher
+	commandSubscriber   saga.Subscriber
 }
 
-func NewCreateEventCommandHandler(reportService *application.ReportService, replyPublisher saga.Publisher, subscriber saga.Subscriber) (*CreateReportCommandHandler, error) {
+func NewCreateEventCommandHandler(reportService *application.ReportService, replyPublisher saga.Publisher, deadLetterPublisher saga.Publisher, subscriber saga.Subscriber) (*CreateReportCommandHandler, error) {
 	o := &CreateReportCommandHandler{
-		reportService:     reportService,
-		replyPublisher:    replyPublisher,
-		commandSubscriber: subscriber,
+		reportService:       reportService,
+		replyPublisher:      replyPublisher,
+		deadLetterPublisher: deadLetterPublisher,
+		commandSubscriber:   subscriber,
 	}
 	//prijava za slusanje komandi
 	err := o.commandSubscriber.Subscribe(o.handle)
@@ -27,21 +32,40 @@ func NewCreateEventCommandHandler(reportService *application.ReportService, repl
 	return o, nil
 }
 
-// hendlovanje komandama
-func (handler *CreateReportCommandHandler) handle(command *events.CreateEventCommand) {
-	reply := events.CreateEventReply{Event: command.Event}
+// hendlovanje komandama. A failed command is returned to be redelivered, the event only goes
+// to the dead letter when its last delivery fails too
+func (handler *CreateReportCommandHandler) handle(command *domain.AdEventCommand, delivery jetstream.Delivery) error {
+	reply := domain.AdEventReply{}
 
 	switch command.Type {
 
 	case events.UpdateCassandra:
 		log.Println("Primljen event update cassandra")
-		handler.reportService.CreateEvent(command.Event)
-		reply.Type = events.CassandraUpadated
+		err := handler.reportService.CreateEvent(&command.Event)
+		if err != nil {
+			if !delivery.Last {
+				return err
+			}
+			//saga staje, event ide na dead letter
+			handler.deadLetter(command.Event, err)
+			reply.Type = events.UnknownReply
+		} else {
+			reply.Type = events.CassandraUpadated
+		}
 
 	case events.UpdateMongo:
 		log.Println("Primljen event update mongo")
-		handler.reportService.CreateReport(&command.Event)
-		reply.Type = events.MongoUpdated
+		err := handler.reportService.CreateReport(&command.Event)
+		if err != nil {
+			if !delivery.Last {
+				return err
+			}
+			//saga staje, event ide na dead letter
+			handler.deadLetter(command.Event, err)
+			reply.Type = events.UnknownReply
+		} else {
+			reply.Type = events.MongoUpdated
+		}
 
 	default:
 		log.Println("Unknown reply report handler")
@@ -49,9 +73,23 @@ func (handler *CreateReportCommandHandler) handle(command *events.CreateEventCom
 	}
 
 	if reply.Type != events.UnknownReply {
+		reply.Event = command.Event
 		log.Println("event publish in report handler")
-		_ = handler.replyPublisher.Publish(reply)
-		log.Printf("event is %s", reply.Type)
+		log.Printf("event is %d", reply.Type)
+		return handler.replyPublisher.Publish(reply)
+	}
+	return nil
+}
 
+// deadLetter publishes an event that could not be added to the reports
+func (handler *CreateReportCommandHandler) deadLetter(event domain.AdEvent, cause error) {
+	deadLetter := domain.DeadLetter{
+		Event:    event,
+		Error:    cause.Error(),
+		FailedAt: time.Now().Unix(),
+	}
+	err := handler.deadLetterPublisher.Publish(deadLetter)
+	if err != nil {
+		log.Printf("Error in CreateReportCommandHandler.deadLetter, event %s is lost: %s", event.ID, err.Error())
 	}
 }
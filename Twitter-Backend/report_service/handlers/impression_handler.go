@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+	"report_service/application"
+	"report_service/domain"
+)
+
+// ImpressionHandler adds the impression batches published by tweet_service to the reports
+type ImpressionHandler struct {
+	reportService *application.ReportService
+	subscriber    saga.Subscriber
+	tracer        trace.Tracer
+	logging       *logrus.Logger
+}
+
+func NewImpressionHandler(reportService *application.ReportService, subscriber saga.Subscriber, tracer trace.Tracer, logging *logrus.Logger) (*ImpressionHandler, error) {
+	handler := &ImpressionHandler{
+		reportService: reportService,
+		subscriber:    subscriber,
+		tracer:        tracer,
+		logging:       logging,
+	}
+
+	err := handler.subscriber.Subscribe(handler.handle)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func (handler *ImpressionHandler) handle(batch *domain.ImpressionBatch) {
+	ctx, span := handler.tracer.Start(context.Background(), "ImpressionHandler.handle")
+	defer span.End()
+
+	err := handler.reportService.RecordImpressions(ctx, batch)
+	if err != nil {
+		handler.logging.Errorf("ImpressionHandler.handle : %s", err)
+	}
+}
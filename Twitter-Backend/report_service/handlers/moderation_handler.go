@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"report_service/application"
+	"report_service/authorization"
+	"report_service/domain"
+	"report_service/errors"
+	"strings"
+)
+
+const moderatorRole = "Moderator"
+
+type ModerationHandler struct {
+	service *application.ModerationService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewModerationHandler(service *application.ModerationService, tracer trace.Tracer, logging *logrus.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *ModerationHandler) Init(router *mux.Router) {
+	router.HandleFunc("/moderation/reports", handler.FileReport).Methods("POST")
+	router.HandleFunc("/moderation/queue", handler.GetQueue).Methods("GET")
+	router.HandleFunc("/moderation/reports/{id}/action", handler.TakeAction).Methods("POST")
+}
+
+func (handler *ModerationHandler) FileReport(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ModerationHandler.FileReport")
+	defer span.End()
+
+	handler.logging.Infoln("ModerationHandler.FileReport : FileReport endpoint reached")
+
+	var report domain.ContentReport
+	err := json.NewDecoder(req.Body).Decode(&report)
+	if err != nil {
+		handler.logging.Errorf("ModerationHandler.FileReport.Decode() : %s", err)
+		http.Error(writer, errors.InvalidReportError, http.StatusBadRequest)
+		return
+	}
+
+	claims := getClaims(req)
+
+	result, err := handler.service.FileReport(ctx, &report, claims["username"])
+	if err != nil {
+		handler.logging.Errorf("ModerationHandler.FileReport : %s", err)
+		switch err.Error() {
+		case errors.InvalidReportError:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.AlreadyReportedError:
+			http.Error(writer, err.Error(), http.StatusConflict)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(result, writer)
+}
+
+func (handler *ModerationHandler) GetQueue(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ModerationHandler.GetQueue")
+	defer span.End()
+
+	handler.logging.Infoln("ModerationHandler.GetQueue : GetQueue endpoint reached")
+
+	if getClaims(req)["userType"] != moderatorRole {
+		http.Error(writer, errors.ModeratorOnlyError, http.StatusForbidden)
+		return
+	}
+
+	status := domain.ModerationStatus(req.URL.Query().Get("status"))
+
+	reports, err := handler.service.GetQueue(ctx, status)
+	if err != nil {
+		handler.logging.Errorf("ModerationHandler.GetQueue : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(reports, writer)
+}
+
+func (handler *ModerationHandler) TakeAction(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ModerationHandler.TakeAction")
+	defer span.End()
+
+	handler.logging.Infoln("ModerationHandler.TakeAction : TakeAction endpoint reached")
+
+	claims := getClaims(req)
+	if claims["userType"] != moderatorRole {
+		http.Error(writer, errors.ModeratorOnlyError, http.StatusForbidden)
+		return
+	}
+
+	var action domain.ModerationAction
+	err := json.NewDecoder(req.Body).Decode(&action)
+	if err != nil {
+		handler.logging.Errorf("ModerationHandler.TakeAction.Decode() : %s", err)
+		http.Error(writer, errors.InvalidActionError, http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(req)
+	report, err := handler.service.TakeAction(ctx, vars["id"], &action, claims["username"], req.Header.Get("Authorization"))
+	if err != nil {
+		handler.logging.Errorf("ModerationHandler.TakeAction : %s", err)
+		switch err.Error() {
+		case errors.ReportNotFoundError:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.ReportAlreadyClosed:
+			http.Error(writer, err.Error(), http.StatusConflict)
+		case errors.InvalidActionError:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.ServiceUnavailableError:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(report, writer)
+}
+
+func getClaims(req *http.Request) map[string]string {
+	bearerToken := strings.Split(req.Header.Get("Authorization"), "Bearer ")
+	if len(bearerToken) < 2 {
+		return map[string]string{}
+	}
+
+	token := authorization.GetToken(bearerToken[1])
+	if token == nil {
+		return map[string]string{}
+	}
+
+	return authorization.GetMapClaims(token.Bytes())
+}
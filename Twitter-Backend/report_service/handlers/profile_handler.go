@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"report_service/application"
+	"report_service/domain"
+	"report_service/errors"
+)
+
+type ProfileHandler struct {
+	service *application.ProfileService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewProfileHandler(service *application.ProfileService, tracer trace.Tracer, logging *logrus.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *ProfileHandler) Init(router *mux.Router) {
+	router.HandleFunc("/profile", handler.GetProfile).Methods("GET")
+	router.HandleFunc("/profile", handler.SaveProfile).Methods("PUT")
+}
+
+func (handler *ProfileHandler) GetProfile(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ProfileHandler.GetProfile")
+	defer span.End()
+
+	handler.logging.Infoln("ProfileHandler.GetProfile : GetProfile endpoint reached")
+
+	profile, err := handler.service.GetProfile(ctx, getClaims(req)["username"])
+	if err != nil {
+		handler.logging.Errorf("ProfileHandler.GetProfile : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(profile, writer)
+}
+
+// SaveProfile sets the timezone the reports of the user in the token are kept in
+func (handler *ProfileHandler) SaveProfile(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ProfileHandler.SaveProfile")
+	defer span.End()
+
+	handler.logging.Infoln("ProfileHandler.SaveProfile : SaveProfile endpoint reached")
+
+	var profile domain.ReportProfile
+	err := json.NewDecoder(req.Body).Decode(&profile)
+	if err != nil {
+		handler.logging.Errorf("ProfileHandler.SaveProfile.Decode() : %s", err)
+		http.Error(writer, errors.InvalidTimezoneError, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := handler.service.SaveProfile(ctx, req.Header.Get("Authorization"), getClaims(req)["username"], &profile)
+	if err != nil {
+		handler.logging.Errorf("ProfileHandler.SaveProfile : %s", err)
+		switch err.Error() {
+		case errors.InvalidTimezoneError:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.ServiceUnavailableError:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(saved, writer)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"report_service/application"
+	"report_service/domain"
+	"report_service/errors"
+)
+
+type ReplayHandler struct {
+	service *application.ReplayService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewReplayHandler(service *application.ReplayService, tracer trace.Tracer, logging *logrus.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *ReplayHandler) Init(router *mux.Router) {
+	router.HandleFunc("/replay", handler.Replay).Methods("POST")
+}
+
+// Replay rebuilds reports from the raw events, {"dry_run": true} only compares them with the
+// current reports
+func (handler *ReplayHandler) Replay(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ReplayHandler.Replay")
+	defer span.End()
+
+	handler.logging.Infoln("ReplayHandler.Replay : Replay endpoint reached")
+
+	if getClaims(req)["userType"] != moderatorRole {
+		http.Error(writer, "only moderators can replay reports", http.StatusForbidden)
+		return
+	}
+
+	var request domain.ReplayRequest
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		handler.logging.Errorf("ReplayHandler.Replay.Decode() : %s", err)
+		http.Error(writer, errors.InvalidReplayError, http.StatusBadRequest)
+		return
+	}
+
+	result, err := handler.service.Replay(ctx, &request)
+	if err != nil {
+		handler.logging.Errorf("ReplayHandler.Replay : %s", err)
+		switch err.Error() {
+		case errors.InvalidReplayError, errors.InvalidRangeError:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(result, writer)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"fmt"
 	"github.com/casbin/casbin"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -10,6 +12,7 @@ import (
 	"report_service/application"
 	"report_service/authorization"
 	"report_service/domain"
+	"report_service/errors"
 	"strconv"
 )
 
@@ -37,6 +40,8 @@ func (handler *ReportHandler) Init(router *mux.Router) {
 		log.Fatal(err)
 	}
 
+	router.HandleFunc("/series/{id}/{reportType}", handler.GetReportSeries).Methods("GET")
+	router.HandleFunc("/advertiser/{reportType}", handler.GetAdvertiserReport).Methods("GET")
 	router.HandleFunc("/{id}/{reportType}/{date}", handler.GetReportForAd).Methods("GET")
 	http.Handle("/", router)
 	log.Fatal(http.ListenAndServe(":8005", authorization.Authorizer(reportEnforcer)(router)))
@@ -54,12 +59,150 @@ func (handler *ReportHandler) GetReportForAd(writer http.ResponseWriter, req *ht
 
 	log.Printf("TweetID : %s, reportType : %s, timestamp: %s", vars["id"], vars["reportType"], vars["date"])
 
-	ad, err := handler.service.GetReportForAd(ctx, vars["id"], vars["reportType"], int64(timestamp))
+	ad, err := handler.service.GetReportForAd(ctx, req.Header.Get("Authorization"), getClaims(req)["username"], vars["id"], vars["reportType"], int64(timestamp))
 	if err != nil {
 		handler.logging.Errorf("ReportHandler.GetReportForAd : %s", err)
+		if err.Error() == errors.NotAdOwnerError {
+			http.Error(writer, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(writer, "Error in handler GetReportForAd", http.StatusInternalServerError)
 		return
 	}
 	jsonResponse(ad, writer)
 	writer.WriteHeader(http.StatusOK)
 }
+
+// GetReportSeries returns one report per date between ?from= and ?to=, ?format=csv or
+// ?format=json serves them as a file
+func (handler *ReportHandler) GetReportSeries(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ReportHandler.GetReportSeries")
+	defer span.End()
+
+	handler.logging.Infoln("ReportHandler.GetReportSeries : Get Report Series endpoint reached")
+
+	from, to, ok := reportRange(req)
+	if !ok {
+		http.Error(writer, errors.InvalidRangeError, http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(req)
+	series, err := handler.service.GetReportSeries(ctx, req.Header.Get("Authorization"), getClaims(req)["username"], vars["id"], vars["reportType"], from, to)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	filename := fmt.Sprintf("report_%s_%s", vars["id"], vars["reportType"])
+	handler.export(writer, req.URL.Query().Get("format"), filename, series, series)
+}
+
+// GetAdvertiserReport sums up the reports of all ads of the user in the token
+func (handler *ReportHandler) GetAdvertiserReport(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ReportHandler.GetAdvertiserReport")
+	defer span.End()
+
+	handler.logging.Infoln("ReportHandler.GetAdvertiserReport : Get Advertiser Report endpoint reached")
+
+	from, to, ok := reportRange(req)
+	if !ok {
+		http.Error(writer, errors.InvalidRangeError, http.StatusBadRequest)
+		return
+	}
+
+	username := getClaims(req)["username"]
+	report, err := handler.service.GetAdvertiserReport(ctx, req.Header.Get("Authorization"), username, mux.Vars(req)["reportType"], from, to)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	filename := fmt.Sprintf("report_%s_%s", username, report.ReportType)
+	handler.export(writer, req.URL.Query().Get("format"), filename, report, report.Series)
+}
+
+// export writes object as json, or as a json or csv attachment. The csv file holds the rows only
+func (handler *ReportHandler) export(writer http.ResponseWriter, format string, filename string, object interface{}, rows []*domain.Report) {
+	switch format {
+	case "":
+		jsonResponse(object, writer)
+	case "json":
+		writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", filename))
+		jsonResponse(object, writer)
+	case "csv":
+		writer.Header().Set("Content-Type", "text/csv")
+		writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		err := writeReportsCSV(writer, rows)
+		if err != nil {
+			handler.logging.Errorf("ReportHandler.export : %s", err)
+		}
+	default:
+		http.Error(writer, "format must be csv or json", http.StatusBadRequest)
+	}
+}
+
+func (handler *ReportHandler) writeError(writer http.ResponseWriter, err error) {
+	handler.logging.Errorf("ReportHandler : %s", err)
+
+	switch err.Error() {
+	case errors.NotAdOwnerError:
+		http.Error(writer, err.Error(), http.StatusForbidden)
+	case errors.InvalidReportTypeError, errors.InvalidRangeError:
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	case errors.ServiceUnavailableError:
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// reportRange reads ?from= and ?to= as unix timestamps, a missing one is returned as 0
+func reportRange(req *http.Request) (int64, int64, bool) {
+	bounds := [2]int64{}
+	for i, name := range []string{"from", "to"} {
+		value := req.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		bound, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || bound < 0 {
+			return 0, 0, false
+		}
+		bounds[i] = bound
+	}
+	return bounds[0], bounds[1], true
+}
+
+func writeReportsCSV(writer http.ResponseWriter, reports []*domain.Report) error {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write([]string{"timestamp", "date", "tweet_id", "impressions", "reach", "like_count",
+		"unlike_count", "view_count", "time_spent", "time_spent_events", "average_time_spent", "ctr", "engagement_rate"})
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		err = csvWriter.Write([]string{
+			strconv.FormatInt(report.Timestamp, 10),
+			report.Date,
+			report.TweetID,
+			strconv.Itoa(report.Impressions),
+			strconv.Itoa(report.Reach),
+			strconv.Itoa(report.LikeCount),
+			strconv.Itoa(report.UnlikeCount),
+			strconv.Itoa(report.ViewCount),
+			strconv.Itoa(report.Timespent),
+			strconv.Itoa(report.TimespentCount),
+			strconv.FormatFloat(report.AvgTimespent, 'f', 2, 64),
+			strconv.FormatFloat(report.CTR, 'f', 4, 64),
+			strconv.FormatFloat(report.EngagementRate, 'f', 4, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
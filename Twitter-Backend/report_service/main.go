@@ -3,6 +3,8 @@ package main
 import (
 	"report_service/startup"
 	"report_service/startup/config"
+	//the image has no zoneinfo, advertiser timezones are loaded from the embedded copy
+	_ "time/tzdata"
 )
 
 func main() {
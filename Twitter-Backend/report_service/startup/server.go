@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/nats"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.opentelemetry.io/otel"
@@ -114,15 +115,31 @@ func (server *Server) Start() {
 
 	cassandraStore.CreateTables()
 
-	replyPublisher := server.initPublisher(server.config.CreateReportReplySubject)
-	commandSubscriber := server.initSubscriber(server.config.CreateReportCommandSubject, QueueGroup)
+	replyPublisher := server.initSagaPublisher(server.config.CreateReportReplySubject)
+	deadLetterPublisher := server.initSagaPublisher(server.config.CreateReportDeadLetterSubject)
+	commandSubscriber := server.initSagaSubscriber(server.config.CreateReportCommandSubject, QueueGroup)
 
-	reportService := server.initReportService(cassandraStore, reportStore, tracer)
+	adClient := application.NewAdClient()
+	profileStore := server.initProfileStore(mongoClient, tracer)
+	profileService := server.initProfileService(profileStore, adClient, tracer)
+	profileHandler := server.initProfileHandler(profileService, tracer)
+
+	reportService := server.initReportService(cassandraStore, reportStore, adClient, profileService, tracer)
 	reportHandler := server.initReportHandler(reportService, tracer)
 
-	server.initCreateEventHandler(reportService, replyPublisher, commandSubscriber)
+	replayService := server.initReplayService(cassandraStore, reportStore, profileService, tracer)
+	replayHandler := server.initReplayHandler(replayService, tracer)
+
+	moderationStore := server.initModerationStore(mongoClient, tracer)
+	moderationService := server.initModerationService(moderationStore, tracer)
+	moderationHandler := server.initModerationHandler(moderationService, tracer)
+
+	server.initCreateEventHandler(reportService, replyPublisher, deadLetterPublisher, commandSubscriber)
 
-	server.start(reportHandler)
+	impressionSubscriber := server.initSubscriber(server.config.ImpressionEventsSubject, QueueGroup)
+	server.initImpressionHandler(reportService, impressionSubscriber, tracer)
+
+	server.start(reportHandler, moderationHandler, profileHandler, replayHandler)
 
 }
 
@@ -169,14 +186,57 @@ func (server *Server) initReportStore(client *mongo.Client, tracer trace.Tracer)
 	return store2
 }
 
-func (server *Server) initReportService(eventStore domain.EventStore, reportStore domain.ReportStore, tracer trace.Tracer) *application.ReportService {
-	return application.NewReportService(eventStore, reportStore, tracer, Logger)
+func (server *Server) initReportService(eventStore domain.EventStore, reportStore domain.ReportStore, adClient *application.AdClient, profileService *application.ProfileService, tracer trace.Tracer) *application.ReportService {
+	return application.NewReportService(eventStore, reportStore, adClient, profileService, tracer, Logger)
 }
 
 func (server *Server) initReportHandler(service *application.ReportService, tracer trace.Tracer) *handlers.ReportHandler {
 	return handlers.NewReportHandler(service, tracer, Logger)
 }
 
+func (server *Server) initReplayService(eventStore domain.EventStore, reportStore domain.ReportStore, profileService *application.ProfileService, tracer trace.Tracer) *application.ReplayService {
+	return application.NewReplayService(eventStore, reportStore, profileService, tracer, Logger)
+}
+
+func (server *Server) initReplayHandler(service *application.ReplayService, tracer trace.Tracer) *handlers.ReplayHandler {
+	return handlers.NewReplayHandler(service, tracer, Logger)
+}
+
+func (server *Server) initProfileStore(client *mongo.Client, tracer trace.Tracer) domain.ProfileStore {
+	return store.NewProfileMongoDBStore(client, tracer, Logger)
+}
+
+// initProfileService reports ads of advertisers without a profile in REPORT_TIMEZONE, or in
+// the local timezone when it is not set
+func (server *Server) initProfileService(profileStore domain.ProfileStore, adClient *application.AdClient, tracer trace.Tracer) *application.ProfileService {
+	location := time.Local
+	if server.config.ReportTimezone != "" {
+		loaded, err := time.LoadLocation(server.config.ReportTimezone)
+		if err != nil {
+			log.Printf("Error in server initProfileService(): %s", err.Error())
+		} else {
+			location = loaded
+		}
+	}
+	return application.NewProfileService(profileStore, adClient, location, tracer, Logger)
+}
+
+func (server *Server) initProfileHandler(service *application.ProfileService, tracer trace.Tracer) *handlers.ProfileHandler {
+	return handlers.NewProfileHandler(service, tracer, Logger)
+}
+
+func (server *Server) initModerationStore(client *mongo.Client, tracer trace.Tracer) domain.ModerationStore {
+	return store.NewModerationMongoDBStore(client, tracer, Logger)
+}
+
+func (server *Server) initModerationService(moderationStore domain.ModerationStore, tracer trace.Tracer) *application.ModerationService {
+	return application.NewModerationService(moderationStore, tracer, Logger)
+}
+
+func (server *Server) initModerationHandler(service *application.ModerationService, tracer trace.Tracer) *handlers.ModerationHandler {
+	return handlers.NewModerationHandler(service, tracer, Logger)
+}
+
 func (server *Server) initPublisher(subject string) saga.Publisher {
 	publisher, err := nats.NewNATSPublisher(
 		server.config.NatsHost, server.config.NatsPort,
@@ -199,17 +259,52 @@ func (server *Server) initSubscriber(subject string, queueGroup string) saga.Sub
 	return subscriber
 }
 
-func (server *Server) initCreateEventHandler(reportService *application.ReportService, publisher saga.Publisher, subscriber saga.Subscriber) {
-	_, err := handlers.NewCreateEventCommandHandler(reportService, publisher, subscriber)
+// initSagaPublisher publishes to a JetStream stream, saga messages are kept until the
+// services handling them are up
+func (server *Server) initSagaPublisher(subject string) saga.Publisher {
+	publisher, err := jetstream.NewJetStreamPublisher(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject)
+	if err != nil {
+		log.Printf("Error in server initSagaPublisher(): %s", err.Error())
+		log.Fatal(err)
+	}
+	return publisher
+}
+
+func (server *Server) initSagaSubscriber(subject string, queueGroup string) saga.Subscriber {
+	subscriber, err := jetstream.NewJetStreamSubscriber(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject, queueGroup)
+	if err != nil {
+		log.Printf("Error in server initSagaSubscriber(): %s", err.Error())
+		log.Fatal(err)
+	}
+	return subscriber
+}
+
+func (server *Server) initCreateEventHandler(reportService *application.ReportService, publisher saga.Publisher, deadLetterPublisher saga.Publisher, subscriber saga.Subscriber) {
+	_, err := handlers.NewCreateEventCommandHandler(reportService, publisher, deadLetterPublisher, subscriber)
 	if err != nil {
 		log.Printf("Error in server initCreateEventHandler(): %s", err.Error())
 		log.Fatal(err)
 	}
 }
 
+func (server *Server) initImpressionHandler(reportService *application.ReportService, subscriber saga.Subscriber, tracer trace.Tracer) {
+	_, err := handlers.NewImpressionHandler(reportService, subscriber, tracer, Logger)
+	if err != nil {
+		log.Printf("Error in server initImpressionHandler(): %s", err.Error())
+		log.Fatal(err)
+	}
+}
+
 // start
-func (server *Server) start(authHandler *handlers.ReportHandler) {
+func (server *Server) start(authHandler *handlers.ReportHandler, moderationHandler *handlers.ModerationHandler, profileHandler *handlers.ProfileHandler, replayHandler *handlers.ReplayHandler) {
 	router := mux.NewRouter()
+	moderationHandler.Init(router)
+	profileHandler.Init(router)
+	replayHandler.Init(router)
 	authHandler.Init(router)
 
 	srv := &http.Server{
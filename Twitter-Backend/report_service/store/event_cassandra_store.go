@@ -8,14 +8,23 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"os"
 	"report_service/domain"
-	"time"
 )
 
 const (
-	DATABASE_CASSANDRA = "events"
-	COLLECTION_EVENT   = "events"
+	DATABASE_CASSANDRA    = "events"
+	COLLECTION_EVENT      = "events"
+	COLLECTION_AD_EVENT   = "ad_events"
+	COLLECTION_IMPRESSION = "impressions"
 )
 
+// eventTables holds the tables raw events are read from. Events with an ID are kept in
+// ad_events, the events table holds the events kept before events had one
+var eventTables = []string{COLLECTION_EVENT, COLLECTION_AD_EVENT}
+
+// eventTypes holds every event type kept in the event tables, the tables are clustered by
+// type before timestamp so ranges are read type by type
+var eventTypes = []string{"Liked", "Unliked", "Timespent", "ViewCount"}
+
 type EventCassandraStore struct {
 	session *gocql.Session
 	logger  *logrus.Logger
@@ -71,8 +80,23 @@ func (store *EventCassandraStore) CreateTables() {
 	if err != nil {
 		store.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
 	}
+
+	err = store.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id UUID, event_type text, timestamp int, event_id UUID, timespent int, PRIMARY KEY ((id), event_type, timestamp, event_id))`, COLLECTION_AD_EVENT)).Exec()
+
+	if err != nil {
+		store.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = store.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id UUID, timestamp int, impression_id UUID, username text, PRIMARY KEY ((id), timestamp, impression_id))`, COLLECTION_IMPRESSION)).Exec()
+
+	if err != nil {
+		store.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
 }
 
+// CreateEvent keeps the raw event, a redelivered event overwrites itself
 func (store *EventCassandraStore) CreateEvent(ctx context.Context, event *domain.Event) (*domain.Event, error) {
 	ctx, span := store.tracer.Start(ctx, "EventStore.CreateEvent")
 	defer span.End()
@@ -80,10 +104,19 @@ func (store *EventCassandraStore) CreateEvent(ctx context.Context, event *domain
 	store.logger.Infoln("EventCassandra.CreateEvent : reached CreateEvent in store")
 
 	tweetID, err := gocql.ParseUUID(event.TweetID)
-	insert := fmt.Sprintf("INSERT INTO %s (id, event_type, timestamp, timespent) VALUES (?, ?, ?, ?)", COLLECTION_EVENT)
+	if err != nil {
+		store.logger.Errorf("Error in EventCassandra.CreateEvent : %s", err)
+		return nil, err
+	}
+	eventID, err := gocql.ParseUUID(event.ID)
+	if err != nil {
+		store.logger.Errorf("Error in EventCassandra.CreateEvent : %s", err)
+		return nil, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (id, event_type, timestamp, event_id, timespent) VALUES (?, ?, ?, ?, ?)", COLLECTION_AD_EVENT)
 
 	err = store.session.Query(
-		insert, tweetID, event.Type, event.Timestamp, event.Timespent).Exec()
+		insert, tweetID, event.Type, event.Timestamp, eventID, event.Timespent).Exec()
 	if err != nil {
 		store.logger.Errorf("Error in EventCassandra.CreateEvent : %s", err)
 		return nil, err
@@ -91,69 +124,124 @@ func (store *EventCassandraStore) CreateEvent(ctx context.Context, event *domain
 	return event, nil
 }
 
-func (store *EventCassandraStore) GetTimespentMonthlyEvents(ctx context.Context, event *domain.Event) (int64, error) {
-	ctx, span := store.tracer.Start(ctx, "EventStore.GetTimespentMonthlyEvents")
+// CreateImpressions keeps the raw impressions, a redelivered impression overwrites itself
+func (store *EventCassandraStore) CreateImpressions(ctx context.Context, impressions []domain.Impression) error {
+	ctx, span := store.tracer.Start(ctx, "EventStore.CreateImpressions")
 	defer span.End()
 
-	store.logger.Infoln("EventCassandra.CreateEvent : reached CreateEvent in store")
+	store.logger.Infoln("EventCassandra.CreateImpressions : reached CreateImpressions in store")
 
-	thisT := time.Unix(event.Timestamp, 0)
-	localTime := time.Date(thisT.Year(), thisT.Month(), thisT.Day(), thisT.Hour()+1, 0, 0, 0, time.Local)
-
-	firstMonthDate := time.Date(localTime.Year(), localTime.Month(), 1, 0, 0, 0, 0, time.Local)
-	lastMonthDate := time.Date(localTime.Year(), localTime.Month()+1, 1, 0, 0, 0, 0, time.Local)
-
-	insert := fmt.Sprintf("SELECT COUNT(timespent), SUM(timespent) FROM %s WHERE id = ? "+
-		"AND event_type = ? AND timestamp >= ? AND timestamp < ?",
-		COLLECTION_EVENT)
-	scanner := store.session.Query(
-		insert, event.TweetID, event.Type, firstMonthDate.Unix(), lastMonthDate.Unix()).Iter().Scanner()
-
-	var timeSum int64
-	var entries int64
-	for scanner.Next() {
-		err := scanner.Scan(&entries, &timeSum)
+	insert := fmt.Sprintf("INSERT INTO %s (id, timestamp, impression_id, username) VALUES (?, ?, ?, ?)", COLLECTION_IMPRESSION)
+	for _, impression := range impressions {
+		tweetID, err := gocql.ParseUUID(impression.TweetID)
 		if err != nil {
-			store.logger.Errorf("Error in getting timespent ==> EventStore.GetTimespentMonthlyEvents: %s", err.Error())
-			return 0, err
+			store.logger.Errorf("Error in EventCassandra.CreateImpressions : %s", err)
+			continue
+		}
+		impressionID, err := gocql.ParseUUID(impression.ID)
+		if err != nil {
+			store.logger.Errorf("Error in EventCassandra.CreateImpressions : %s", err)
+			continue
 		}
-	}
 
-	if entries == 0 {
-		return 0, nil
+		err = store.session.Query(insert, tweetID, impression.Timestamp, impressionID, impression.Username).Exec()
+		if err != nil {
+			store.logger.Errorf("Error in EventCassandra.CreateImpressions : %s", err)
+			return err
+		}
 	}
-	return timeSum / entries, nil
+	return nil
 }
 
-func (store *EventCassandraStore) GetTimespentDailyEvents(ctx context.Context, event *domain.Event) (int64, error) {
-	ctx, span := store.tracer.Start(ctx, "EventStore.GetTimespentDailyEvents")
+// GetEvents returns the events of the tweet from from up to to
+func (store *EventCassandraStore) GetEvents(ctx context.Context, tweetID string, from int64, to int64) ([]*domain.Event, error) {
+	ctx, span := store.tracer.Start(ctx, "EventStore.GetEvents")
 	defer span.End()
 
-	store.logger.Infoln("EventCassandra.CreateEvent : reached CreateEvent in store")
+	store.logger.Infoln("EventCassandra.GetEvents : reached GetEvents in store")
+
+	var result []*domain.Event
+	for _, table := range eventTables {
+		query := fmt.Sprintf("SELECT event_type, timestamp, timespent FROM %s WHERE id = ? "+
+			"AND event_type = ? AND timestamp >= ? AND timestamp < ?", table)
+
+		for _, eventType := range eventTypes {
+			scanner := store.session.Query(query, tweetID, eventType, from, to).Iter().Scanner()
+			for scanner.Next() {
+				event := domain.Event{TweetID: tweetID}
+				err := scanner.Scan(&event.Type, &event.Timestamp, &event.Timespent)
+				if err != nil {
+					store.logger.Errorf("Error in EventCassandra.GetEvents : %s", err)
+					return nil, err
+				}
+				result = append(result, &event)
+			}
+			if err := scanner.Err(); err != nil {
+				store.logger.Errorf("Error in EventCassandra.GetEvents : %s", err)
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
 
-	thisT := time.Unix(event.Timestamp, 0)
-	localTime := time.Date(thisT.Year(), thisT.Month(), thisT.Day(), thisT.Hour()+1, 0, 0, 0, time.Local)
-	startOfDay := time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, time.Local)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+// GetImpressions returns the impressions of the ad from from up to to
+func (store *EventCassandraStore) GetImpressions(ctx context.Context, tweetID string, from int64, to int64) ([]*domain.Impression, error) {
+	ctx, span := store.tracer.Start(ctx, "EventStore.GetImpressions")
+	defer span.End()
 
-	insert := fmt.Sprintf("SELECT COUNT(timespent), SUM(timespent) FROM %s WHERE id = ? "+
-		"AND event_type = ? AND timestamp >= ? AND timestamp < ?", COLLECTION_EVENT)
+	store.logger.Infoln("EventCassandra.GetImpressions : reached GetImpressions in store")
 
 	scanner := store.session.Query(
-		insert, event.TweetID, event.Type, startOfDay.Unix(), endOfDay.Unix()).Iter().Scanner()
+		fmt.Sprintf("SELECT timestamp, impression_id, username FROM %s WHERE id = ? AND timestamp >= ? AND timestamp < ?", COLLECTION_IMPRESSION),
+		tweetID, from, to).Iter().Scanner()
 
-	var timeSum int64
-	var entries int64
+	var result []*domain.Impression
 	for scanner.Next() {
-		err := scanner.Scan(&entries, &timeSum)
+		impression := domain.Impression{TweetID: tweetID}
+		var impressionID gocql.UUID
+		err := scanner.Scan(&impression.Timestamp, &impressionID, &impression.Username)
 		if err != nil {
-			store.logger.Errorf("Error in getting timespent ==> EventStore.GetTimespentMonthlyEvents: %s", err.Error())
-			return 0, err
+			store.logger.Errorf("Error in EventCassandra.GetImpressions : %s", err)
+			return nil, err
 		}
+		impression.ID = impressionID.String()
+		result = append(result, &impression)
+	}
+	if err := scanner.Err(); err != nil {
+		store.logger.Errorf("Error in EventCassandra.GetImpressions : %s", err)
+		return nil, err
 	}
+	return result, nil
+}
+
+// GetTweetIDs returns every tweet with events or impressions, it reads the whole token ring
+func (store *EventCassandraStore) GetTweetIDs(ctx context.Context) ([]string, error) {
+	ctx, span := store.tracer.Start(ctx, "EventStore.GetTweetIDs")
+	defer span.End()
 
-	if entries == 0 {
-		return 0, nil
+	store.logger.Infoln("EventCassandra.GetTweetIDs : reached GetTweetIDs in store")
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, table := range append(eventTables, COLLECTION_IMPRESSION) {
+		scanner := store.session.Query(fmt.Sprintf("SELECT DISTINCT id FROM %s", table)).Iter().Scanner()
+		for scanner.Next() {
+			var id gocql.UUID
+			err := scanner.Scan(&id)
+			if err != nil {
+				store.logger.Errorf("Error in EventCassandra.GetTweetIDs : %s", err)
+				return nil, err
+			}
+			if !seen[id.String()] {
+				seen[id.String()] = true
+				result = append(result, id.String())
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			store.logger.Errorf("Error in EventCassandra.GetTweetIDs : %s", err)
+			return nil, err
+		}
 	}
-	return timeSum / entries, nil
+	return result, nil
 }
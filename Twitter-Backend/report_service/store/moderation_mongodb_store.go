@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"report_service/domain"
+	"report_service/errors"
+	"time"
+)
+
+const (
+	COLLECTION_MODERATION = "moderation_reports"
+)
+
+type ModerationMongoDBStore struct {
+	reports *mongo.Collection
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewModerationMongoDBStore(client *mongo.Client, tracer trace.Tracer, logging *logrus.Logger) domain.ModerationStore {
+	reports := client.Database(DATABASE).Collection(COLLECTION_MODERATION)
+
+	//only one open report may exist per target, concurrent upserts fail instead of creating duplicates
+	_, err := reports.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": domain.StatusOpen}),
+	})
+	if err != nil {
+		logging.Errorf("ModerationStore : failed to create index: %s", err)
+	}
+
+	return &ModerationMongoDBStore{
+		reports: reports,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (store *ModerationMongoDBStore) FileReport(ctx context.Context, report *domain.ContentReport, reporter string) (*domain.ModerationReport, error) {
+	ctx, span := store.tracer.Start(ctx, "ModerationStore.FileReport")
+	defer span.End()
+
+	store.logging.Infoln("ModerationStore.FileReport : reached FileReport in store")
+
+	//the reporter is part of the filter, so a second report by the same user does not match the open
+	//report and its upsert fails on the unique index instead of being counted twice
+	target := bson.M{"target_type": report.TargetType, "target_id": report.TargetID, "status": domain.StatusOpen,
+		"reporters": bson.M{"$ne": reporter}}
+
+	now := time.Now().Unix()
+	update := bson.M{
+		"$addToSet": bson.M{"reporters": reporter, "reasons": report.Reason},
+		"$inc":      bson.M{"report_count": 1},
+		"$set":      bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+		},
+	}
+	if report.Description != "" {
+		update["$push"] = bson.M{"descriptions": report.Description}
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var result domain.ModerationReport
+	err := store.reports.FindOneAndUpdate(ctx, target, update, opts).Decode(&result)
+	if mongo.IsDuplicateKeyError(err) {
+		reported, countErr := store.reports.CountDocuments(ctx, bson.M{"target_type": report.TargetType,
+			"target_id": report.TargetID, "status": domain.StatusOpen, "reporters": reporter})
+		if countErr != nil {
+			store.logging.Errorf("ModerationStore.FileReport.CountDocuments() : %s", countErr)
+			return nil, countErr
+		}
+		if reported != 0 {
+			return nil, fmt.Errorf(errors.AlreadyReportedError)
+		}
+
+		//another report created the entry in the meantime, it can be updated now
+		err = store.reports.FindOneAndUpdate(ctx, target, update, opts).Decode(&result)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf(errors.AlreadyReportedError)
+		}
+	}
+	if err != nil {
+		store.logging.Errorf("ModerationStore.FileReport.FindOneAndUpdate() : %s", err)
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (store *ModerationMongoDBStore) GetQueue(ctx context.Context, status domain.ModerationStatus) ([]*domain.ModerationReport, error) {
+	ctx, span := store.tracer.Start(ctx, "ModerationStore.GetQueue")
+	defer span.End()
+
+	store.logging.Infoln("ModerationStore.GetQueue : reached GetQueue in store")
+
+	opts := options.Find().SetSort(bson.D{{Key: "report_count", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := store.reports.Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		store.logging.Errorf("ModerationStore.GetQueue.Find() : %s", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*domain.ModerationReport
+	for cursor.Next(ctx) {
+		var report domain.ModerationReport
+		err = cursor.Decode(&report)
+		if err != nil {
+			store.logging.Errorf("ModerationStore.GetQueue.Decode() : %s", err)
+			return nil, err
+		}
+		reports = append(reports, &report)
+	}
+
+	return reports, cursor.Err()
+}
+
+func (store *ModerationMongoDBStore) Get(ctx context.Context, id primitive.ObjectID) (*domain.ModerationReport, error) {
+	ctx, span := store.tracer.Start(ctx, "ModerationStore.Get")
+	defer span.End()
+
+	store.logging.Infoln("ModerationStore.Get : reached Get in store")
+
+	var report domain.ModerationReport
+	err := store.reports.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf(errors.ReportNotFoundError)
+		}
+		store.logging.Errorf("ModerationStore.Get.FindOne() : %s", err)
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (store *ModerationMongoDBStore) Resolve(ctx context.Context, report *domain.ModerationReport) error {
+	ctx, span := store.tracer.Start(ctx, "ModerationStore.Resolve")
+	defer span.End()
+
+	store.logging.Infoln("ModerationStore.Resolve : reached Resolve in store")
+
+	result, err := store.reports.UpdateOne(ctx,
+		bson.M{"_id": report.ID, "status": domain.StatusOpen},
+		bson.M{"$set": bson.M{
+			"status":       report.Status,
+			"action":       report.Action,
+			"note":         report.Note,
+			"moderated_by": report.ModeratedBy,
+			"updated_at":   report.UpdatedAt,
+		}})
+	if err != nil {
+		store.logging.Errorf("ModerationStore.Resolve.UpdateOne() : %s", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf(errors.ReportAlreadyClosed)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+	"report_service/domain"
+)
+
+const (
+	COLLECTION_PROFILES  = "report_profiles"
+	COLLECTION_AD_OWNERS = "ad_owners"
+)
+
+type ProfileMongoDBStore struct {
+	profiles *mongo.Collection
+	adOwners *mongo.Collection
+	tracer   trace.Tracer
+	logging  *logrus.Logger
+}
+
+func NewProfileMongoDBStore(client *mongo.Client, tracer trace.Tracer, logging *logrus.Logger) domain.ProfileStore {
+	profiles := client.Database(DATABASE).Collection(COLLECTION_PROFILES)
+	adOwners := client.Database(DATABASE).Collection(COLLECTION_AD_OWNERS)
+
+	indexes := []struct {
+		collection *mongo.Collection
+		key        string
+	}{
+		{profiles, "advertiser"},
+		{adOwners, "tweet_id"},
+	}
+	for _, index := range indexes {
+		_, err := index.collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+			Keys:    bson.D{{Key: index.key, Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			logging.Errorf("ProfileStore : failed to create index: %s", err)
+		}
+	}
+
+	return &ProfileMongoDBStore{
+		profiles: profiles,
+		adOwners: adOwners,
+		tracer:   tracer,
+		logging:  logging,
+	}
+}
+
+func (store *ProfileMongoDBStore) SaveProfile(ctx context.Context, profile *domain.ReportProfile) error {
+	ctx, span := store.tracer.Start(ctx, "ProfileStore.SaveProfile")
+	defer span.End()
+
+	store.logging.Infoln("ProfileStore.SaveProfile : reached SaveProfile in store")
+
+	_, err := store.profiles.UpdateOne(ctx,
+		bson.M{"advertiser": profile.Advertiser},
+		bson.M{"$set": bson.M{"timezone": profile.Timezone, "updated_at": profile.UpdatedAt}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		store.logging.Errorf("ProfileStore.SaveProfile.UpdateOne() : %s", err)
+		return err
+	}
+	return nil
+}
+
+// GetProfile returns the profile of the advertiser, or nil if it never saved one
+func (store *ProfileMongoDBStore) GetProfile(ctx context.Context, advertiser string) (*domain.ReportProfile, error) {
+	ctx, span := store.tracer.Start(ctx, "ProfileStore.GetProfile")
+	defer span.End()
+
+	var profile domain.ReportProfile
+	err := store.profiles.FindOne(ctx, bson.M{"advertiser": advertiser}).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		store.logging.Errorf("ProfileStore.GetProfile.FindOne() : %s", err)
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SaveAdOwners records the advertiser as the owner of the ads, an ad keeps its first owner
+func (store *ProfileMongoDBStore) SaveAdOwners(ctx context.Context, advertiser string, tweetIDs []string) error {
+	ctx, span := store.tracer.Start(ctx, "ProfileStore.SaveAdOwners")
+	defer span.End()
+
+	if len(tweetIDs) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(tweetIDs))
+	for _, tweetID := range tweetIDs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"tweet_id": tweetID}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{"advertiser": advertiser}}).
+			SetUpsert(true))
+	}
+
+	_, err := store.adOwners.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		store.logging.Errorf("ProfileStore.SaveAdOwners.BulkWrite() : %s", err)
+		return err
+	}
+	return nil
+}
+
+// GetAdOwner returns the advertiser of the ad, or an empty string if it is not known yet
+func (store *ProfileMongoDBStore) GetAdOwner(ctx context.Context, tweetID string) (string, error) {
+	ctx, span := store.tracer.Start(ctx, "ProfileStore.GetAdOwner")
+	defer span.End()
+
+	var owner struct {
+		Advertiser string `bson:"advertiser"`
+	}
+	err := store.adOwners.FindOne(ctx, bson.M{"tweet_id": tweetID}).Decode(&owner)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		store.logging.Errorf("ProfileStore.GetAdOwner.FindOne() : %s", err)
+		return "", err
+	}
+	return owner.Advertiser, nil
+}
+
+// onlyDuplicates tells if every write of a bulk write failed on a unique index, concurrent
+// upserts of the same document fail that way once the first one inserted it
+func onlyDuplicates(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			return false
+		}
+	}
+	return true
+}
@@ -6,206 +6,421 @@ import (
 	"github.com/sirupsen/logrus"
 	events "github.com/zjalicf/twitter-clone-common/common/saga/create_event"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel/trace"
 	"log"
 	"report_service/domain"
+	"report_service/errors"
+	"time"
 )
 
 const (
-	DATABASE           = "report_mongo"
-	COLLECTION_DAILY   = "daily_reports"
-	COLLECTION_MONTHLY = "monthly_reports"
-	UnknownEventError  = "Unknown event type"
+	DATABASE                 = "report_mongo"
+	COLLECTION_HOURLY        = "hourly_reports"
+	COLLECTION_DAILY         = "daily_reports"
+	COLLECTION_WEEKLY        = "weekly_reports"
+	COLLECTION_MONTHLY       = "monthly_reports"
+	COLLECTION_HOURLY_REACH  = "hourly_reach"
+	COLLECTION_DAILY_REACH   = "daily_reach"
+	COLLECTION_WEEKLY_REACH  = "weekly_reach"
+	COLLECTION_MONTHLY_REACH = "monthly_reach"
+	COLLECTION_PROCESSED     = "processed_events"
+	UnknownEventError        = "Unknown event type"
+
+	duplicateKeyCode = 11000
+
+	//redeliveries come within minutes, a week keeps the markers far longer than needed
+	processedEventTTL = 7 * 24 * time.Hour
+
+	//a report keeps the IDs of the updates counted in it last, an update is retried within
+	//minutes of counting it in some of its reports
+	countedUpdates = 1000
 )
 
+// ReportMongoDBStore keeps the reports of every granularity in a collection of its own,
+// next to a reach collection holding the users reached in each report. The processed
+// collection holds the IDs of the events and impressions counted in all their reports
 type ReportMongoDBStore struct {
-	dailyReports   *mongo.Collection
-	monthlyReports *mongo.Collection
-	tracer         trace.Tracer
-	logging        *logrus.Logger
+	reports   map[domain.Granularity]*mongo.Collection
+	reach     map[domain.Granularity]*mongo.Collection
+	processed *mongo.Collection
+	tracer    trace.Tracer
+	logging   *logrus.Logger
 }
 
 func NewReportMongoDBStore(client *mongo.Client, tracer trace.Tracer, logging *logrus.Logger) domain.ReportStore {
-	dailyReports := client.Database(DATABASE).Collection(COLLECTION_DAILY)
-	monthlyReports := client.Database(DATABASE).Collection(COLLECTION_MONTHLY)
+	database := client.Database(DATABASE)
+	reports := map[domain.Granularity]*mongo.Collection{
+		domain.Hourly:  database.Collection(COLLECTION_HOURLY),
+		domain.Daily:   database.Collection(COLLECTION_DAILY),
+		domain.Weekly:  database.Collection(COLLECTION_WEEKLY),
+		domain.Monthly: database.Collection(COLLECTION_MONTHLY),
+	}
+	reach := map[domain.Granularity]*mongo.Collection{
+		domain.Hourly:  database.Collection(COLLECTION_HOURLY_REACH),
+		domain.Daily:   database.Collection(COLLECTION_DAILY_REACH),
+		domain.Weekly:  database.Collection(COLLECTION_WEEKLY_REACH),
+		domain.Monthly: database.Collection(COLLECTION_MONTHLY_REACH),
+	}
+
+	//reports are upserted by tweet and date, the index keeps concurrent upserts from creating
+	//a second report. Duplicates left by older versions fail it until a replay removes them
+	for _, collection := range reports {
+		_, err := collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "tweet_id", Value: 1}, {Key: "timestamp", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			logging.Errorf("ReportStore : failed to create index: %s", err)
+		}
+	}
+
+	//a user is reached once per report, inserting the same user again fails
+	for _, collection := range reach {
+		_, err := collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+			Keys:    bson.D{{Key: "tweet_id", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		})
+		if err != nil {
+			logging.Errorf("ReportStore : failed to create index: %s", err)
+		}
+	}
+
+	processed := database.Collection(COLLECTION_PROCESSED)
+	_, err := processed.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "processed_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(processedEventTTL.Seconds())),
+	})
+	if err != nil {
+		logging.Errorf("ReportStore : failed to create index: %s", err)
+	}
+
 	return &ReportMongoDBStore{
-		dailyReports:   dailyReports,
-		monthlyReports: monthlyReports,
-		tracer:         tracer,
-		logging:        logging,
+		reports:   reports,
+		reach:     reach,
+		processed: processed,
+		tracer:    tracer,
+		logging:   logging,
 	}
 }
 
-func (store *ReportMongoDBStore) GetReportForAd(ctx context.Context, tweetID string, reportType string, timestamp int64) (*domain.Report, error) {
-	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.GetReportForAd")
+// ProcessedEvents returns which of the events are counted in all their reports
+func (store *ReportMongoDBStore) ProcessedEvents(ctx context.Context, eventIDs []string) (map[string]bool, error) {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.ProcessedEvents")
 	defer span.End()
 
-	store.logging.Infoln("ReportStore.GetReportForAd : reached Get Report For Ad in store")
+	processed := make(map[string]bool)
+	if len(eventIDs) == 0 {
+		return processed, nil
+	}
 
-	if reportType == "daily" {
+	cursor, err := store.processed.Find(ctx, bson.M{"_id": bson.M{"$in": eventIDs}})
+	if err != nil {
+		store.logging.Errorf("ReportStore.ProcessedEvents.Find() : %s", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-		result, err := store.filterOneDaily(bson.M{"tweet_id": tweetID, "timestamp": timestamp})
+	for cursor.Next(ctx) {
+		var marker struct {
+			ID string `bson:"_id"`
+		}
+		err = cursor.Decode(&marker)
 		if err != nil {
-			store.logging.Errorf("ReportStore.GetReportForAd.FilterOneDaily() : %s", err)
 			return nil, err
 		}
-		return result, nil
+		processed[marker.ID] = true
+	}
+	return processed, cursor.Err()
+}
 
-	} else if reportType == "monthly" {
+// MarkEventsProcessed records that the events are counted in all their reports, marking an
+// event again changes nothing
+func (store *ReportMongoDBStore) MarkEventsProcessed(ctx context.Context, eventIDs []string) error {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.MarkEventsProcessed")
+	defer span.End()
 
-		result, err := store.filterOneMonthly(bson.M{"tweet_id": tweetID, "timestamp": timestamp})
-		if err != nil {
-			store.logging.Errorf("ReportStore.GetReportForAd.FilterOneMonthly() : %s", err)
-			return nil, err
-		}
-		return result, nil
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	markers := make([]interface{}, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		markers = append(markers, bson.M{"_id": eventID, "processed_at": now})
+	}
+
+	_, err := store.processed.InsertMany(ctx, markers, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		store.logging.Errorf("ReportStore.MarkEventsProcessed.InsertMany() : %s", err)
+		return err
+	}
+	return nil
+}
+
+func (store *ReportMongoDBStore) GetReportForAd(ctx context.Context, tweetID string, reportType string, timestamp int64) (*domain.Report, error) {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.GetReportForAd")
+	defer span.End()
+
+	store.logging.Infoln("ReportStore.GetReportForAd : reached Get Report For Ad in store")
+
+	collection, ok := store.reports[domain.Granularity(reportType)]
+	if !ok {
+		return nil, fmt.Errorf(errors.InvalidReportTypeError)
+	}
+
+	result, err := store.filterOne(ctx, collection, bson.M{"tweet_id": tweetID, "timestamp": timestamp})
+	if err != nil {
+		store.logging.Errorf("ReportStore.GetReportForAd.filterOne() : %s", err)
+		return nil, err
+	}
+	return result, nil
+}
 
+// GetReports returns the reports of the tweets dated between from and to, oldest first
+func (store *ReportMongoDBStore) GetReports(ctx context.Context, tweetIDs []string, reportType string, from int64, to int64) ([]*domain.Report, error) {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.GetReports")
+	defer span.End()
+
+	store.logging.Infoln("ReportStore.GetReports : reached GetReports in store")
+
+	collection, ok := store.reports[domain.Granularity(reportType)]
+	if !ok {
+		return nil, fmt.Errorf(errors.InvalidReportTypeError)
+	}
+
+	filter := bson.M{"tweet_id": bson.M{"$in": tweetIDs}, "timestamp": bson.M{"$gte": from, "$lte": to}}
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: 1}}).
+		SetProjection(bson.M{"counted": 0}))
+	if err != nil {
+		store.logging.Errorf("ReportStore.GetReports.Find() : %s", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports, err := decode(cursor)
+	if err != nil {
+		store.logging.Errorf("ReportStore.GetReports.decode() : %s", err)
+		return nil, err
 	}
-	return nil, nil
+	return reports, nil
 }
 
-func (store *ReportMongoDBStore) CreateReport(ctx context.Context, event *events.Event,
-	monthlyUnix, dailyUnix int64) (*events.Event, error) {
+// CreateReport counts the event in the report of every date. Each report is updated by a
+// single upsert, concurrent events neither lose counts nor create a second report. A report
+// that already counted the event ID is left as it is
+func (store *ReportMongoDBStore) CreateReport(ctx context.Context, eventID string, event *events.Event, dates []*domain.ReportDate) (*events.Event, error) {
 	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.CreateReport")
 	defer span.End()
 
 	store.logging.Infoln("ReportStore.CreateReport : reached CreateReport in store")
 
-	oneDaily, err := store.filterOneDaily(bson.M{"tweet_id": event.TweetID, "timestamp": dailyUnix})
+	update, err := eventUpdate(event)
 	if err != nil {
-		store.logging.Errorf("Error in ReportMongoStore.filterOneDaily(), filterOneDaily: %s", err.Error())
-		report := domain.Report{
-			ID:          primitive.NewObjectID(),
-			TweetID:     event.TweetID,
-			Timestamp:   dailyUnix,
-			LikeCount:   0,
-			UnlikeCount: 0,
-			ViewCount:   0,
-			Timespent:   0,
-		}
+		return nil, err
+	}
 
-		if event.Type == "Liked" {
-			report.LikeCount++
-		} else if event.Type == "Unliked" {
-			report.UnlikeCount++
-		} else if event.Type == "Timespent" {
-			report.Timespent = int(event.DailySpent)
-		} else if event.Type == "ViewCount" {
-			report.ViewCount++
-		} else {
-			return nil, fmt.Errorf(UnknownEventError)
+	for _, date := range dates {
+		collection, ok := store.reports[date.Granularity]
+		if !ok {
+			return nil, fmt.Errorf(errors.InvalidReportTypeError)
 		}
 
-		_, err = store.dailyReports.InsertOne(ctx, report)
+		err = upsertCounted(ctx, collection, eventID, bson.M{"tweet_id": event.TweetID, "timestamp": date.Timestamp}, update)
 		if err != nil {
-			store.logging.Errorf("Error in ReportMongoStore, daily_report: %s", err.Error())
+			store.logging.Errorf("Error in report_mongodb CreateReport() %s: %s", date.Granularity, err.Error())
 			return nil, err
 		}
-	} else {
-
-		//like update
-		if event.Type == "Liked" {
-			oneDaily.LikeCount = oneDaily.LikeCount + 1
-		} else if event.Type == "Unliked" {
-			//unline update
-			oneDaily.UnlikeCount = oneDaily.UnlikeCount + 1
-		} else if event.Type == "Timespent" {
-			oneDaily.Timespent = int(event.DailySpent)
-		} else if event.Type == "ViewCount" {
-			//view update
-			oneDaily.ViewCount = oneDaily.ViewCount + 1
-		} else {
-			return nil, fmt.Errorf(UnknownEventError)
-		}
+	}
 
-		_, err = store.dailyReports.UpdateOne(context.TODO(), bson.M{"_id": oneDaily.ID}, bson.M{"$set": oneDaily})
-		if err != nil {
-			store.logging.Errorf("Error in report_mongodb CreateReport() Unlike monthly: %s", err.Error())
-			return nil, err
-		}
+	return event, nil
+}
 
+// eventUpdate returns the counters the event adds to its reports, time spent is summed up
+// with the number of Timespent events next to it
+func eventUpdate(event *events.Event) (bson.M, error) {
+	switch event.Type {
+	case "Liked":
+		return bson.M{"$inc": bson.M{"like_count": 1}}, nil
+	case "Unliked":
+		return bson.M{"$inc": bson.M{"unlike_count": 1}}, nil
+	case "Timespent":
+		return bson.M{"$inc": bson.M{"time_spent": event.Timespent, "time_spent_events": 1}}, nil
+	case "ViewCount":
+		return bson.M{"$inc": bson.M{"view_count": 1}}, nil
+	default:
+		return nil, fmt.Errorf(UnknownEventError)
+	}
+}
+
+// upsertReport applies the update to the report, creating it if needed. Two upserts creating
+// the same report race on the unique index, the one that loses is retried as an update
+func upsertReport(ctx context.Context, collection *mongo.Collection, filter bson.M, update bson.M) error {
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	}
+	return err
+}
+
+// upsertCounted is upsertReport counting the update once. The filter only matches a report
+// that did not count the update ID yet, so for a report that did the upsert tries to create
+// it a second time and fails on the unique index. Updates without an ID are always applied
+func upsertCounted(ctx context.Context, collection *mongo.Collection, updateID string, filter bson.M, update bson.M) error {
+	if updateID == "" {
+		return upsertReport(ctx, collection, filter, update)
+	}
+
+	counted := bson.M{"counted": bson.M{"$ne": updateID}}
+	for key, value := range filter {
+		counted[key] = value
+	}
+	push := bson.M{"$push": bson.M{"counted": bson.M{"$each": bson.A{updateID}, "$slice": -countedUpdates}}}
+	for key, value := range update {
+		push[key] = value
 	}
-	//monthly
-	oneMonthly, err := store.filterOneMonthly(bson.M{"tweet_id": event.TweetID, "timestamp": monthlyUnix})
-	if err != nil {
-		store.logging.Errorf("Error in ReportMongoStore.filterOneMonthly(), filterOneMonthly: %s", err.Error())
-		report := domain.Report{
-			ID:          primitive.NewObjectID(),
-			TweetID:     event.TweetID,
-			Timestamp:   monthlyUnix,
-			LikeCount:   0,
-			UnlikeCount: 0,
-			ViewCount:   0,
-			Timespent:   0,
-		}
 
-		if event.Type == "Liked" {
-			report.LikeCount++
-		} else if event.Type == "Unliked" {
-			report.UnlikeCount++
-		} else if event.Type == "Timespent" {
-			report.Timespent = int(event.MonthlySpent)
-		} else if event.Type == "ViewCount" {
-			report.ViewCount++
-		} else {
-			return nil, fmt.Errorf(UnknownEventError)
+	err := upsertReport(ctx, collection, counted, push)
+	if mongo.IsDuplicateKeyError(err) {
+		//the report exists and its update was retried, so it already counts the ID
+		return nil
+	}
+	return err
+}
+
+// AddImpressions adds the impressions of the ad to the report of every date once per update
+// ID, viewers holds the number of impressions per user. The reach of a report is the number
+// of users recorded in it, so a retried update does not change it either
+func (store *ReportMongoDBStore) AddImpressions(ctx context.Context, updateID string, tweetID string, dates []*domain.ReportDate, viewers map[string]int) error {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.AddImpressions")
+	defer span.End()
+
+	store.logging.Infoln("ReportStore.AddImpressions : reached AddImpressions in store")
+
+	total := 0
+	for _, count := range viewers {
+		total += count
+	}
+
+	for _, date := range dates {
+		reports, ok := store.reports[date.Granularity]
+		if !ok {
+			return fmt.Errorf(errors.InvalidReportTypeError)
 		}
-		_, err = store.monthlyReports.InsertOne(ctx, report)
+
+		err := store.addReach(ctx, store.reach[date.Granularity], tweetID, date.Timestamp, viewers)
 		if err != nil {
-			store.logging.Errorf("Error in ReportMongoStore, monthly_reporst: %s", err.Error())
-			return nil, err
-		}
-	} else {
-
-		//like update
-		if event.Type == "Liked" {
-			oneMonthly.LikeCount = oneMonthly.LikeCount + 1
-
-		} else if event.Type == "Unliked" {
-			//unline update
-			oneMonthly.UnlikeCount = oneMonthly.UnlikeCount + 1
-
-		} else if event.Type == "Timespent" {
-			oneMonthly.Timespent = int(event.MonthlySpent)
-		} else if event.Type == "ViewCount" {
-			//view update
-			oneMonthly.ViewCount = oneMonthly.ViewCount + 1
-		} else {
-			return nil, fmt.Errorf(UnknownEventError)
+			store.logging.Errorf("ReportStore.AddImpressions.addReach() : %s", err)
+			return err
 		}
-		_, err = store.monthlyReports.UpdateOne(context.TODO(), bson.M{"_id": oneMonthly.ID}, bson.M{"$set": oneMonthly})
+
+		reached, err := store.reach[date.Granularity].CountDocuments(ctx, bson.M{"tweet_id": tweetID, "timestamp": date.Timestamp})
 		if err != nil {
-			store.logging.Errorf("Error in report_mongodb CreateReport() Like monthly: %s", err.Error())
-			return nil, err
+			store.logging.Errorf("ReportStore.AddImpressions.CountDocuments() : %s", err)
+			return err
 		}
 
+		err = upsertCounted(ctx, reports, updateID,
+			bson.M{"tweet_id": tweetID, "timestamp": date.Timestamp},
+			bson.M{"$inc": bson.M{"impressions": total}, "$max": bson.M{"reach": reached}})
+		if err != nil {
+			store.logging.Errorf("ReportStore.AddImpressions.UpdateOne() : %s", err)
+			return err
+		}
 	}
 
-	return event, nil
+	return nil
 }
 
-func (store *ReportMongoDBStore) filterDaily(filter interface{}) ([]*domain.Report, error) {
-	cursor, err := store.dailyReports.Find(context.TODO(), filter)
-	defer cursor.Close(context.TODO())
+// ReplaceReports makes the given reports the reports of the tweet from from up to to, other
+// reports in the range are removed. reach holds the users reached in each report. Reads in
+// between see the range empty
+func (store *ReportMongoDBStore) ReplaceReports(ctx context.Context, tweetID string, granularity domain.Granularity, from int64, to int64, reports []*domain.Report, reach map[int64][]string) error {
+	ctx, span := store.tracer.Start(ctx, "ReportMongoDBStore.ReplaceReports")
+	defer span.End()
 
+	store.logging.Infoln("ReportStore.ReplaceReports : reached ReplaceReports in store")
+
+	collection, ok := store.reports[granularity]
+	if !ok {
+		return fmt.Errorf(errors.InvalidReportTypeError)
+	}
+
+	//duplicates left by concurrent inserts go away with the rest of the range
+	inRange := bson.M{"$gte": from, "$lt": to}
+	_, err := collection.DeleteMany(ctx, bson.M{"tweet_id": tweetID, "timestamp": inRange})
 	if err != nil {
-		store.logging.Errorf("Error in report_mongodb filter() Unlike daily: %s", err.Error())
-		return nil, err
+		store.logging.Errorf("ReportStore.ReplaceReports.DeleteMany() : %s", err)
+		return err
+	}
+
+	//upserts instead of inserts, events counted since the delete are overwritten rather than
+	//failing the replay on the unique index
+	models := make([]mongo.WriteModel, 0, len(reports))
+	for _, report := range reports {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"tweet_id": tweetID, "timestamp": report.Timestamp}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"like_count":        report.LikeCount,
+				"unlike_count":      report.UnlikeCount,
+				"view_count":        report.ViewCount,
+				"time_spent":        report.Timespent,
+				"time_spent_events": report.TimespentCount,
+				"impressions":       report.Impressions,
+				"reach":             report.Reach,
+			}}).
+			SetUpsert(true))
+	}
+	if len(models) > 0 {
+		_, err = collection.BulkWrite(ctx, models)
+		if err != nil {
+			store.logging.Errorf("ReportStore.ReplaceReports.BulkWrite() : %s", err)
+			return err
+		}
+	}
+
+	_, err = store.reach[granularity].DeleteMany(ctx, bson.M{"tweet_id": tweetID, "timestamp": inRange})
+	if err != nil {
+		store.logging.Errorf("ReportStore.ReplaceReports.DeleteMany() reach : %s", err)
+		return err
 	}
-	return decode(cursor)
+	for timestamp, usernames := range reach {
+		viewers := make(map[string]int, len(usernames))
+		for _, username := range usernames {
+			viewers[username] = 1
+		}
+		err = store.addReach(ctx, store.reach[granularity], tweetID, timestamp, viewers)
+		if err != nil {
+			store.logging.Errorf("ReportStore.ReplaceReports.addReach() : %s", err)
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (store *ReportMongoDBStore) filterOneDaily(filter interface{}) (user *domain.Report, err error) {
-	result := store.dailyReports.FindOne(context.TODO(), filter)
-	err = result.Decode(&user)
-	return
+// addReach records the viewers in the report, viewers already in it are skipped
+func (store *ReportMongoDBStore) addReach(ctx context.Context, reach *mongo.Collection, tweetID string, timestamp int64, viewers map[string]int) error {
+	documents := make([]interface{}, 0, len(viewers))
+	for username := range viewers {
+		documents = append(documents, bson.M{"tweet_id": tweetID, "timestamp": timestamp, "username": username})
+	}
+	if len(documents) == 0 {
+		return nil
+	}
+
+	_, err := reach.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		return err
+	}
+	return nil
 }
 
-func (store *ReportMongoDBStore) filterOneMonthly(filter interface{}) (user *domain.Report, err error) {
-	result := store.monthlyReports.FindOne(context.TODO(), filter)
-	err = result.Decode(&user)
+func (store *ReportMongoDBStore) filterOne(ctx context.Context, collection *mongo.Collection, filter interface{}) (report *domain.Report, err error) {
+	result := collection.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"counted": 0}))
+	err = result.Decode(&report)
 	return
 }
 
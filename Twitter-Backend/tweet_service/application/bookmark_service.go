@@ -0,0 +1,332 @@
+package application
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultBookmarkPage   = 20
+	maxBookmarkPage       = 100
+	maxCollectionNameSize = 25
+)
+
+// BookmarkService keeps private bookmarks. Everything is read and written for the user in
+// the token, so bookmarks and their counts are never shown to anyone else
+type BookmarkService struct {
+	store      domain.BookmarkStore
+	tweets     domain.TweetStore
+	visibility *VisibilityService
+	engagement *EngagementService
+	polls      *PollService
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewBookmarkService(store domain.BookmarkStore, tweets domain.TweetStore, visibility *VisibilityService, engagement *EngagementService, polls *PollService, tracer trace.Tracer, logging *logrus.Logger) *BookmarkService {
+	return &BookmarkService{
+		store:      store,
+		tweets:     tweets,
+		visibility: visibility,
+		engagement: engagement,
+		polls:      polls,
+		tracer:     tracer,
+		logging:    logging,
+	}
+}
+
+// Add bookmarks the tweet, bookmarking it again moves it to the given collection. Only tweets
+// the user may read can be bookmarked
+func (service *BookmarkService) Add(ctx context.Context, token string, username string, tweetID string, collection string) (*domain.Bookmark, error) {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.Add")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.Add : add bookmark reached")
+
+	tweet, err := service.tweets.GetOne(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	if tweet.Hidden {
+		return nil, fmt.Errorf(errors.TweetNotFound)
+	}
+
+	visible, err := service.visibility.CanViewTweet(ctx, token, username, tweet)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, fmt.Errorf(errors.TweetNotFound)
+	}
+
+	collection = strings.TrimSpace(collection)
+	if collection != domain.DefaultCollection {
+		err = service.collectionExists(ctx, username, collection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bookmark := domain.Bookmark{
+		TweetID:    tweet.ID,
+		Collection: collection,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	existing, err := service.store.GetBookmark(ctx, username, tweet.ID)
+	if err != nil && err.Error() != errors.BookmarkNotFound {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.Collection == collection {
+			return existing, nil
+		}
+
+		//a moved bookmark keeps its place in the list of all bookmarks
+		bookmark.CreatedAt = existing.CreatedAt
+		err = service.store.DeleteBookmark(ctx, username, existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = service.store.SaveBookmark(ctx, username, &bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bookmark, nil
+}
+
+func (service *BookmarkService) Remove(ctx context.Context, username string, tweetID string) error {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.Remove")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.Remove : remove bookmark reached")
+
+	id, err := gocql.ParseUUID(tweetID)
+	if err != nil {
+		return fmt.Errorf(errors.BookmarkNotFound)
+	}
+
+	bookmark, err := service.store.GetBookmark(ctx, username, id)
+	if err != nil {
+		return err
+	}
+
+	return service.store.DeleteBookmark(ctx, username, bookmark)
+}
+
+// List returns one page of bookmarked tweets, newest bookmark first, with their engagement and
+// polls. Bookmarks of tweets that were deleted are removed here, hidden tweets and tweets the
+// user may no longer read are skipped but stay bookmarked
+func (service *BookmarkService) List(ctx context.Context, token string, username string, collection string, limit int, cursor string) (*domain.BookmarkPage, error) {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.List")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.List : list bookmarks reached")
+
+	if limit <= 0 {
+		limit = defaultBookmarkPage
+	}
+	if limit > maxBookmarkPage {
+		limit = maxBookmarkPage
+	}
+
+	pageState, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf(errors.InvalidCursor)
+	}
+
+	collection = strings.TrimSpace(collection)
+	if collection != domain.DefaultCollection {
+		err = service.collectionExists(ctx, username, collection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bookmarks, next, err := service.store.GetBookmarks(ctx, username, collection, limit, pageState)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]gocql.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		ids[i] = bookmark.TweetID
+	}
+
+	tweets, err := service.tweets.GetTweetsByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[gocql.UUID]*domain.Tweet, len(tweets))
+	for _, tweet := range tweets {
+		byID[tweet.ID] = tweet
+	}
+
+	page := domain.BookmarkPage{
+		Tweets:     []*domain.Tweet{},
+		NextCursor: base64.RawURLEncoding.EncodeToString(next),
+	}
+	for _, bookmark := range bookmarks {
+		tweet, ok := byID[bookmark.TweetID]
+		if !ok {
+			service.prune(ctx, bookmark.TweetID)
+			continue
+		}
+
+		visible, err := service.visibility.CanViewTweet(ctx, token, username, tweet)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			page.Tweets = append(page.Tweets, tweet)
+		}
+	}
+
+	err = service.engagement.Attach(ctx, username, page.Tweets)
+	if err != nil {
+		return nil, err
+	}
+	err = service.polls.Attach(ctx, username, page.Tweets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (service *BookmarkService) CreateCollection(ctx context.Context, username string, name string) (*domain.BookmarkCollection, error) {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.CreateCollection")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.CreateCollection : create collection reached")
+
+	name = strings.TrimSpace(name)
+	size := len([]rune(name))
+	if size == 0 || size > maxCollectionNameSize {
+		return nil, fmt.Errorf(errors.InvalidCollection)
+	}
+
+	collection := domain.BookmarkCollection{
+		Name:      name,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	created, err := service.store.SaveBookmarkCollection(ctx, username, &collection)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, fmt.Errorf(errors.CollectionExists)
+	}
+
+	return &collection, nil
+}
+
+func (service *BookmarkService) GetCollections(ctx context.Context, username string) ([]*domain.BookmarkCollection, error) {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.GetCollections")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.GetCollections : get collections reached")
+
+	collections, err := service.store.GetBookmarkCollections(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if collections == nil {
+		collections = []*domain.BookmarkCollection{}
+	}
+
+	return collections, nil
+}
+
+// DeleteCollection removes the collection, its bookmarks are kept without a collection
+func (service *BookmarkService) DeleteCollection(ctx context.Context, username string, name string) error {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.DeleteCollection")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.DeleteCollection : delete collection reached")
+
+	name = strings.TrimSpace(name)
+	err := service.collectionExists(ctx, username, name)
+	if err != nil {
+		return err
+	}
+
+	return service.store.DeleteBookmarkCollection(ctx, username, name)
+}
+
+// DeleteForTweet removes every bookmark of the tweet, it is called when a tweet is deleted
+func (service *BookmarkService) DeleteForTweet(ctx context.Context, tweetID gocql.UUID) error {
+	ctx, span := service.tracer.Start(ctx, "BookmarkService.DeleteForTweet")
+	defer span.End()
+
+	service.logging.Infoln("BookmarkService.DeleteForTweet : delete bookmarks of tweet reached")
+
+	usernames, err := service.store.GetBookmarkers(ctx, tweetID)
+	if err != nil {
+		return err
+	}
+
+	for _, username := range usernames {
+		bookmark, err := service.store.GetBookmark(ctx, username, tweetID)
+		if err != nil {
+			if err.Error() == errors.BookmarkNotFound {
+				continue
+			}
+			return err
+		}
+
+		err = service.store.DeleteBookmark(ctx, username, bookmark)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prune cleans up after a tweet that was deleted without going through DeleteForTweet.
+// Hidden tweets are kept because moderation can still release them
+func (service *BookmarkService) prune(ctx context.Context, tweetID gocql.UUID) {
+	_, err := service.tweets.GetOne(ctx, tweetID.String())
+	if err == nil {
+		return
+	}
+	if err.Error() != errors.TweetNotFound {
+		service.logging.Errorf("BookmarkService.prune.GetOne : %s", err)
+		return
+	}
+
+	err = service.DeleteForTweet(ctx, tweetID)
+	if err != nil {
+		service.logging.Errorf("BookmarkService.prune.DeleteForTweet : %s", err)
+	}
+}
+
+func (service *BookmarkService) collectionExists(ctx context.Context, username string, name string) error {
+	collections, err := service.store.GetBookmarkCollections(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		if collection.Name == name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(errors.CollectionNotFound)
+}
@@ -6,6 +6,7 @@ import (
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
 	"go.opentelemetry.io/otel/trace"
 	"log"
+	"tweet_service/domain"
 )
 
 type CreateEventOrchestrator struct {
@@ -28,13 +29,13 @@ func NewCreateEventOrchestrator(publisher saga.Publisher, subscriber saga.Subscr
 	return o, nil
 }
 
-func (o *CreateEventOrchestrator) Start(ctx context.Context, event events.Event) error {
+func (o *CreateEventOrchestrator) Start(ctx context.Context, event domain.AdEvent) error {
 	ctx, span := o.tracer.Start(ctx, "Orchestrator Starting")
 	defer span.End()
 
 	log.Printf("Starting orchestrator with eventType: %s", event.Type)
 
-	eventPush := events.CreateEventCommand{
+	eventPush := domain.AdEventCommand{
 		Event: event,
 		Type:  events.UpdateCassandra,
 	}
@@ -45,17 +46,19 @@ func (o *CreateEventOrchestrator) Start(ctx context.Context, event events.Event)
 	return o.commandPublisher.Publish(eventPush)
 }
 
-func (o *CreateEventOrchestrator) handle(reply *events.CreateEventReply) {
-	log.Printf("Orkestrator primio reply: %s", reply.Type)
-	command := events.CreateEventCommand{Event: reply.Event}
+// handle returns a failed publish, so the reply is redelivered and the command sent again
+func (o *CreateEventOrchestrator) handle(reply *domain.AdEventReply) error {
+	log.Printf("Orkestrator primio reply: %d", reply.Type)
+	command := domain.AdEventCommand{Event: reply.Event}
 	command.Type = o.nextCommandType(*reply)
 	if command.Type != events.UnknownCommand {
-		log.Printf("Orkestrator salje command: %s", command.Type)
-		_ = o.commandPublisher.Publish(command)
+		log.Printf("Orkestrator salje command: %d", command.Type)
+		return o.commandPublisher.Publish(command)
 	}
+	return nil
 }
 
-func (o *CreateEventOrchestrator) nextCommandType(reply events.CreateEventReply) events.CreateEventCommandType {
+func (o *CreateEventOrchestrator) nextCommandType(reply domain.AdEventReply) events.CreateEventCommandType {
 
 	log.Println(reply.Type)
 
@@ -0,0 +1,73 @@
+package application
+
+import (
+	"context"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EngagementService fills in the favorite and retweet counts of tweets from the counters and
+// whether the viewer favorited or retweeted them, the stored flags are not per user
+type EngagementService struct {
+	store   domain.TweetStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewEngagementService(store domain.TweetStore, tracer trace.Tracer, logging *logrus.Logger) *EngagementService {
+	return &EngagementService{
+		store:   store,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+// Attach sets the counts of the tweets and the flags of viewer, an empty viewer gets no flags
+func (service *EngagementService) Attach(ctx context.Context, viewer string, tweets []*domain.Tweet) error {
+	ctx, span := service.tracer.Start(ctx, "EngagementService.Attach")
+	defer span.End()
+
+	ids := make([]gocql.UUID, 0, len(tweets))
+	seen := make(map[gocql.UUID]bool, len(tweets))
+	for _, tweet := range tweets {
+		if tweet != nil && !seen[tweet.ID] {
+			seen[tweet.ID] = true
+			ids = append(ids, tweet.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	engagement, err := service.store.GetEngagement(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	favorited, err := service.store.GetFavorited(ctx, ids, viewer)
+	if err != nil {
+		return err
+	}
+
+	retweeted, err := service.store.GetRetweeted(ctx, ids, viewer)
+	if err != nil {
+		return err
+	}
+
+	for _, tweet := range tweets {
+		if tweet == nil {
+			continue
+		}
+		if counts, ok := engagement[tweet.ID]; ok {
+			tweet.FavoriteCount = counts.FavoriteCount
+			tweet.RetweetCount = counts.RetweetCount
+		}
+		tweet.Favorited = favorited[tweet.ID]
+		tweet.Retweeted = retweeted[tweet.ID]
+	}
+
+	return nil
+}
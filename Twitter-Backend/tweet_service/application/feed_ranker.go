@@ -0,0 +1,250 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//engagement is counted in hourly buckets, the trending candidates come from the last day
+	engagementBucket    = time.Hour
+	engagementBuckets   = 24
+	trendingCandidates  = 50
+	candidateMaxAge     = 7 * 24 * time.Hour
+	rankedFeedLength    = 200
+	favoriteEngagement  = 1
+	retweetEngagement   = 2
+	engagementKeyPrefix = "engagement:tweets:"
+	//a second degree following with this many followings in between gets half the affinity of a following
+	halfAffinityMutuals = 2
+)
+
+// FeedRanker builds the ranked feed. Candidates come from followings, second degree
+// followings and tweets with the most engagement over the last day. Candidates from
+// outside the followings are only kept if the viewer may read them
+type FeedRanker struct {
+	tweets     domain.TweetStore
+	cache      domain.TweetCache
+	engagement *EngagementService
+	visibility *VisibilityService
+	weights    domain.RankingWeights
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewFeedRanker(tweets domain.TweetStore, cache domain.TweetCache, engagement *EngagementService, visibility *VisibilityService, weights domain.RankingWeights, tracer trace.Tracer, logging *logrus.Logger) *FeedRanker {
+	return &FeedRanker{
+		tweets:     tweets,
+		cache:      cache,
+		engagement: engagement,
+		visibility: visibility,
+		weights:    weights,
+		tracer:     tracer,
+		logging:    logging,
+	}
+}
+
+// RecordEngagement adds to the engagement of a tweet in the current bucket, unlikes pass a negative amount
+func (ranker *FeedRanker) RecordEngagement(ctx context.Context, tweetID string, amount float64) {
+	ctx, span := ranker.tracer.Start(ctx, "FeedRanker.RecordEngagement")
+	defer span.End()
+
+	key := engagementKey(time.Now())
+	err := ranker.cache.IncrementScore(ctx, key, tweetID, amount, engagementBucket*(engagementBuckets+1))
+	if err != nil {
+		ranker.logging.Errorf("FeedRanker.RecordEngagement : %s", err)
+	}
+}
+
+// Rank scores the candidates and returns them best first. followingFeed is the
+// chronological feed of the viewer, the candidates come back with the viewer's engagement
+func (ranker *FeedRanker) Rank(ctx context.Context, token string, viewer string, feedInfo *domain.FeedInfo, followingFeed []*domain.Tweet) ([]*domain.Tweet, error) {
+	ctx, span := ranker.tracer.Start(ctx, "FeedRanker.Rank")
+	defer span.End()
+
+	ranker.logging.Infoln("FeedRanker.Rank : rank reached")
+
+	affinity := make(map[string]float64)
+	for _, username := range feedInfo.Usernames {
+		affinity[username] = 1
+	}
+
+	var secondDegree []string
+	for _, connection := range feedInfo.SecondDegree {
+		affinity[connection.Username] = float64(connection.Mutual) / float64(connection.Mutual+halfAffinityMutuals)
+		secondDegree = append(secondDegree, connection.Username)
+	}
+
+	var discovered []*domain.Tweet
+
+	if len(secondDegree) != 0 {
+		tweets, err := ranker.tweets.GetPostsFeedByUser(ctx, secondDegree)
+		if err != nil {
+			return nil, err
+		}
+		discovered = append(discovered, fresh(tweets)...)
+	}
+
+	trending, err := ranker.trending(ctx)
+	if err != nil {
+		//the feed still works without trending candidates
+		ranker.logging.Errorf("FeedRanker.Rank.trending : %s", err)
+	} else {
+		discovered = append(discovered, fresh(trending)...)
+	}
+
+	discovered, err = ranker.readable(ctx, token, viewer, feedInfo.Usernames, discovered)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := uniqueTweets(append(followingFeed, discovered...))
+
+	err = ranker.engagement.Attach(ctx, viewer, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	maxEngagement := 0.0
+	for _, tweet := range candidates {
+		maxEngagement = math.Max(maxEngagement, engagement(tweet))
+	}
+
+	now := time.Now()
+	scores := make(map[string]float64, len(candidates))
+	for _, tweet := range candidates {
+		scores[tweet.ID.String()] = ranker.score(tweet, affinity[tweet.Username], maxEngagement, now)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].ID.String()] > scores[candidates[j].ID.String()]
+	})
+
+	if len(candidates) > rankedFeedLength {
+		candidates = candidates[:rankedFeedLength]
+	}
+
+	return candidates, nil
+}
+
+func (ranker *FeedRanker) score(tweet *domain.Tweet, affinity float64, maxEngagement float64, now time.Time) float64 {
+	engagementScore := 0.0
+	if maxEngagement > 0 {
+		engagementScore = math.Log1p(engagement(tweet)) / math.Log1p(maxEngagement)
+	}
+
+	recencyScore := 1.0
+	age := now.Sub(time.Unix(tweet.CreatedAt, 0))
+	if age > 0 && ranker.weights.HalfLife > 0 {
+		recencyScore = math.Pow(0.5, float64(age)/float64(ranker.weights.HalfLife))
+	}
+
+	return ranker.weights.Engagement*engagementScore +
+		ranker.weights.Recency*recencyScore +
+		ranker.weights.Affinity*affinity
+}
+
+// readable keeps the candidates from outside the followings the viewer may read, hidden
+// tweets and tweets of private accounts the viewer doesn't follow are left out
+func (ranker *FeedRanker) readable(ctx context.Context, token string, viewer string, followings []string, tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	followed := make(map[string]bool, len(followings))
+	for _, username := range followings {
+		followed[username] = true
+	}
+
+	result := make([]*domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		if tweet.Hidden {
+			continue
+		}
+		if followed[tweet.Username] && (tweet.OwnerUsername == "" || followed[tweet.OwnerUsername]) {
+			result = append(result, tweet)
+			continue
+		}
+
+		visible, err := ranker.visibility.CanViewTweet(ctx, token, viewer, tweet)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			result = append(result, tweet)
+		}
+	}
+	return result, nil
+}
+
+// trending returns the tweets with the most engagement over the last day
+func (ranker *FeedRanker) trending(ctx context.Context) ([]*domain.Tweet, error) {
+	ctx, span := ranker.tracer.Start(ctx, "FeedRanker.trending")
+	defer span.End()
+
+	now := time.Now()
+	keys := make([]string, 0, engagementBuckets)
+	for i := 0; i < engagementBuckets; i++ {
+		keys = append(keys, engagementKey(now.Add(-time.Duration(i)*engagementBucket)))
+	}
+
+	top, err := ranker.cache.TopMembers(ctx, keys, trendingCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []gocql.UUID
+	for _, member := range top {
+		if member.Score <= 0 {
+			continue
+		}
+		id, err := gocql.ParseUUID(member.Member)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	return ranker.tweets.GetTweetsByIds(ctx, ids)
+}
+
+func engagementKey(at time.Time) string {
+	return fmt.Sprintf("%s%d", engagementKeyPrefix, at.Truncate(engagementBucket).Unix())
+}
+
+func engagement(tweet *domain.Tweet) float64 {
+	return float64(tweet.FavoriteCount*favoriteEngagement + tweet.RetweetCount*retweetEngagement)
+}
+
+// fresh keeps the organic tweets younger than candidateMaxAge, it is applied to
+// candidates from outside the viewer's followings
+func fresh(tweets []*domain.Tweet) []*domain.Tweet {
+	oldest := time.Now().Add(-candidateMaxAge).Unix()
+
+	var result []*domain.Tweet
+	for _, tweet := range tweets {
+		if tweet.Advertisement || tweet.CreatedAt < oldest {
+			continue
+		}
+		result = append(result, tweet)
+	}
+	return result
+}
+
+func uniqueTweets(tweets []*domain.Tweet) []*domain.Tweet {
+	seen := make(map[string]bool, len(tweets))
+	unique := make([]*domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		id := tweet.ID.String()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, tweet)
+	}
+	return unique
+}
@@ -0,0 +1,363 @@
+package application
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	maxImagePixels = 50_000_000
+	maxImageSide   = 4096
+	jpegQuality    = 85
+
+	//every frame of a GIF is decoded into memory, so the frames count against the pixels too
+	maxGIFFrames = 500
+	maxGIFPixels = maxImagePixels
+)
+
+// imageSizes are the bounds of the longest side of every thumbnail
+var imageSizes = map[domain.ImageSize]int{
+	domain.ImageThumb:  150,
+	domain.ImageSmall:  680,
+	domain.ImageMedium: 1200,
+	domain.ImageLarge:  2048,
+}
+
+// IsImageSize reports whether size names a stored variant, empty means the original
+func IsImageSize(size domain.ImageSize) bool {
+	_, ok := imageSizes[size]
+	return ok || size == "" || size == domain.ImageOriginal
+}
+
+// ProcessedImage is an upload re-encoded without its metadata, together with its thumbnails
+type ProcessedImage struct {
+	Data     []byte
+	MimeType string
+	Width    int
+	Height   int
+	Variants map[domain.ImageSize][]byte
+}
+
+// ProcessImage decodes the upload and encodes it again, which drops EXIF and any
+// other metadata. Photos are normalized to JPEG, images with transparency to PNG
+// and GIFs stay GIFs so animations survive. Thumbnails are only made for sizes
+// smaller than the image itself
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf(errors.UnsupportedMedia)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf(errors.MediaTooLarge)
+	}
+
+	if format == "gif" {
+		return processGIF(data)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf(errors.UnsupportedMedia)
+	}
+
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	img = fit(img, maxImageSide)
+
+	encoded, err := encodeStill(img)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := &ProcessedImage{
+		Data:     encoded,
+		MimeType: http.DetectContentType(encoded),
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+	}
+
+	processed.Variants, err = thumbnails(img)
+	if err != nil {
+		return nil, err
+	}
+
+	return processed, nil
+}
+
+func processGIF(data []byte) (*ProcessedImage, error) {
+	frames, pixels, ok := gifFrames(data)
+	if !ok {
+		return nil, fmt.Errorf(errors.UnsupportedMedia)
+	}
+	if frames > maxGIFFrames || pixels > maxGIFPixels {
+		return nil, fmt.Errorf(errors.MediaTooLarge)
+	}
+
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(animation.Image) == 0 {
+		return nil, fmt.Errorf(errors.UnsupportedMedia)
+	}
+
+	var buffer bytes.Buffer
+	err = gif.EncodeAll(&buffer, animation)
+	if err != nil {
+		return nil, err
+	}
+
+	//thumbnails are still images of the first frame
+	firstFrame := image.NewNRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+	draw.Draw(firstFrame, animation.Image[0].Bounds(), animation.Image[0], animation.Image[0].Bounds().Min, draw.Over)
+
+	variants, err := thumbnails(firstFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcessedImage{
+		Data:     buffer.Bytes(),
+		MimeType: "image/gif",
+		Width:    animation.Config.Width,
+		Height:   animation.Config.Height,
+		Variants: variants,
+	}, nil
+}
+
+// gifFrames walks the blocks of a GIF without decoding it and returns the number of frames
+// and the sum of their width × height. It stops counting once a limit is passed
+func gifFrames(data []byte) (int, int, bool) {
+	if len(data) < 13 || !bytes.HasPrefix(data, []byte("GIF8")) {
+		return 0, 0, false
+	}
+
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << ((data[10] & 0x07) + 1)
+	}
+
+	frames, pixels := 0, 0
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x3B:
+			return frames, pixels, true
+		case 0x21:
+			if offset+2 > len(data) {
+				return 0, 0, false
+			}
+			offset += 2
+		case 0x2C:
+			if offset+10 > len(data) {
+				return 0, 0, false
+			}
+			width := int(binary.LittleEndian.Uint16(data[offset+5 : offset+7]))
+			height := int(binary.LittleEndian.Uint16(data[offset+7 : offset+9]))
+			frames++
+			pixels += width * height
+			if frames > maxGIFFrames || pixels > maxGIFPixels {
+				return frames, pixels, true
+			}
+
+			packed := data[offset+9]
+			offset += 10
+			if packed&0x80 != 0 {
+				offset += 3 << ((packed & 0x07) + 1)
+			}
+			//the LZW minimum code size comes before the data
+			offset++
+		default:
+			return 0, 0, false
+		}
+
+		//extensions and image data end with an empty sub-block
+		for {
+			if offset >= len(data) {
+				return 0, 0, false
+			}
+			size := int(data[offset])
+			offset += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+
+	//whether a file without a trailer is valid is left to the decoder
+	return frames, pixels, true
+}
+
+func thumbnails(img image.Image) (map[domain.ImageSize][]byte, error) {
+	variants := make(map[domain.ImageSize][]byte)
+	longest := img.Bounds().Dx()
+	if img.Bounds().Dy() > longest {
+		longest = img.Bounds().Dy()
+	}
+
+	for size, bound := range imageSizes {
+		if longest <= bound {
+			continue
+		}
+
+		encoded, err := encodeStill(fit(img, bound))
+		if err != nil {
+			return nil, err
+		}
+		variants[size] = encoded
+	}
+
+	return variants, nil
+}
+
+func encodeStill(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		err = png.Encode(&buffer, img)
+	} else {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// fit scales the image down so its longest side is at most bound
+func fit(img image.Image, bound int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= bound && height <= bound {
+		return img
+	}
+
+	if width >= height {
+		height = height * bound / width
+		width = bound
+	} else {
+		width = width * bound / height
+		height = bound
+	}
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
+
+// orient applies the EXIF orientation to the pixels, the tag itself is lost on re-encoding
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			}
+			srcOffset := src.PixOffset(x, y)
+			dstOffset := dst.PixOffset(dx, dy)
+			copy(dst.Pix[dstOffset:dstOffset+4], src.Pix[srcOffset:srcOffset+4])
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation reads the orientation tag from the EXIF segment, 1 means upright
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	offset := 2
+	for offset+4 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"tweet_service/errors"
+)
+
+func encodeTestGIF(t *testing.T, frames int, width int, height int) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		frame.SetColorIndex(i%width, 0, 1)
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 10)
+	}
+
+	var buffer bytes.Buffer
+	err := gif.EncodeAll(&buffer, animation)
+	if err != nil {
+		t.Fatalf("EncodeAll() error = %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestGIFFramesCountsTheDescriptors(t *testing.T) {
+	frames, pixels, ok := gifFrames(encodeTestGIF(t, 3, 20, 10))
+	if !ok {
+		t.Fatal("gifFrames() could not walk a valid GIF")
+	}
+	if frames != 3 || pixels != 600 {
+		t.Errorf("gifFrames() = %d frames, %d pixels, want 3 frames, 600 pixels", frames, pixels)
+	}
+
+	_, _, ok = gifFrames([]byte("GIF89a\x01"))
+	if ok {
+		t.Error("gifFrames() accepted a truncated GIF")
+	}
+}
+
+func TestProcessImageRejectsLargeAnimations(t *testing.T) {
+	_, err := ProcessImage(encodeTestGIF(t, maxGIFFrames+1, 2, 2))
+	if err == nil || err.Error() != errors.MediaTooLarge {
+		t.Errorf("ProcessImage() with too many frames error = %v, want %q", err, errors.MediaTooLarge)
+	}
+
+	//each frame is within the pixel limit, all of them together are not
+	_, err = ProcessImage(encodeTestGIF(t, 30, 2000, 1000))
+	if err == nil || err.Error() != errors.MediaTooLarge {
+		t.Errorf("ProcessImage() with too many pixels error = %v, want %q", err, errors.MediaTooLarge)
+	}
+
+	processed, err := ProcessImage(encodeTestGIF(t, 5, 40, 30))
+	if err != nil {
+		t.Fatalf("ProcessImage() of a small animation error = %v", err)
+	}
+	if processed.MimeType != "image/gif" {
+		t.Errorf("ProcessImage() MimeType = %q, want image/gif", processed.MimeType)
+	}
+}
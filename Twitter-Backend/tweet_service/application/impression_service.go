@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//impressions sent in one message at most, a full batch is sent right away
+	impressionBatchSize = 200
+	impressionInterval  = 5 * time.Second
+)
+
+// ImpressionService collects the ads served in feeds and publishes them to report_service
+// in batches, a batch goes out when it is full or when the interval passes
+type ImpressionService struct {
+	publisher saga.Publisher
+	mutex     sync.Mutex
+	pending   []domain.Impression
+	tracer    trace.Tracer
+	logging   *logrus.Logger
+}
+
+func NewImpressionService(publisher saga.Publisher, tracer trace.Tracer, logging *logrus.Logger) *ImpressionService {
+	return &ImpressionService{
+		publisher: publisher,
+		tracer:    tracer,
+		logging:   logging,
+	}
+}
+
+// Record queues an impression of every ad served to username
+func (service *ImpressionService) Record(username string, ads []*domain.Tweet) {
+	if len(ads) == 0 {
+		return
+	}
+
+	now := time.Now().Unix()
+	service.mutex.Lock()
+	for _, ad := range ads {
+		id, _ := gocql.RandomUUID()
+		service.pending = append(service.pending, domain.Impression{
+			ID:         id.String(),
+			TweetID:    ad.ID.String(),
+			Advertiser: ad.Username,
+			Username:   username,
+			Timestamp:  now,
+		})
+	}
+	var batch []domain.Impression
+	if len(service.pending) >= impressionBatchSize {
+		batch = service.pending
+		service.pending = nil
+	}
+	service.mutex.Unlock()
+
+	if batch != nil {
+		go service.publish(batch)
+	}
+}
+
+// Run publishes the queued impressions every interval until ctx is done, then sends what is left
+func (service *ImpressionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(impressionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			service.flush()
+			return
+		case <-ticker.C:
+			service.flush()
+		}
+	}
+}
+
+func (service *ImpressionService) flush() {
+	service.mutex.Lock()
+	batch := service.pending
+	service.pending = nil
+	service.mutex.Unlock()
+
+	if len(batch) > 0 {
+		service.publish(batch)
+	}
+}
+
+// publish only logs failures, the impressions of the batch are lost for the reports
+func (service *ImpressionService) publish(batch []domain.Impression) {
+	_, span := service.tracer.Start(context.Background(), "ImpressionService.publish")
+	defer span.End()
+
+	err := service.publisher.Publish(domain.ImpressionBatch{Impressions: batch})
+	if err != nil {
+		service.logging.Errorf("ImpressionService.publish : %d impressions lost : %s", len(batch), err)
+	}
+}
@@ -0,0 +1,392 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/html"
+)
+
+const (
+	codeAlphabet     = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	codeLength       = 7
+	codeAttempts     = 5
+	maxPreviews      = 2
+	previewTimeout   = 10 * time.Second
+	previewBodyLimit = 512 * 1024
+	previewRedirects = 3
+)
+
+type LinkService struct {
+	store   domain.LinkStore
+	baseURL string
+	client  *http.Client
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+// NewLinkService fetches previews with the given client, NewPreviewClient is the one that
+// keeps links away from internal services
+func NewLinkService(store domain.LinkStore, baseURL string, client *http.Client, tracer trace.Tracer, logging *logrus.Logger) *LinkService {
+	return &LinkService{
+		store:   store,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+// Shorten replaces every link in the tweet text with a short link and returns
+// the original links so previews can be fetched for them
+func (service *LinkService) Shorten(ctx context.Context, tweet *domain.Tweet) ([]string, error) {
+	return service.ShortenWith(ctx, tweet, nil)
+}
+
+// ShortenWith is Shorten reusing the codes of links that were shortened for the tweet before.
+// Codes of new links are added to codes, so a tweet published again keeps its short links
+func (service *LinkService) ShortenWith(ctx context.Context, tweet *domain.Tweet, codes map[string]string) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx, "LinkService.Shorten")
+	defer span.End()
+
+	service.logging.Infoln("LinkService.Shorten : shorten service reached")
+
+	var links []string
+	var shortenErr error
+	tweet.Text = urlRegex.ReplaceAllStringFunc(tweet.Text, func(match string) string {
+		if shortenErr != nil {
+			return match
+		}
+
+		link, trailing := trimLink(match)
+		if strings.HasPrefix(link, service.baseURL+"/") {
+			return match
+		}
+		link = normalizeLink(link)
+
+		code, ok := codes[link]
+		if !ok {
+			var err error
+			code, err = service.saveShortLink(ctx, tweet, link)
+			if err != nil {
+				shortenErr = err
+				return match
+			}
+			if codes != nil {
+				codes[link] = code
+			}
+		}
+
+		links = append(links, link)
+		return service.baseURL + "/l/" + code + trailing
+	})
+
+	if shortenErr != nil {
+		service.logging.Errorf("LinkService.Shorten : %s", shortenErr)
+		return nil, shortenErr
+	}
+
+	return links, nil
+}
+
+func (service *LinkService) saveShortLink(ctx context.Context, tweet *domain.Tweet, link string) (string, error) {
+	for attempt := 0; attempt < codeAttempts; attempt++ {
+		code, err := randomCode()
+		if err != nil {
+			return "", err
+		}
+
+		shortLink := domain.ShortLink{
+			Code:      code,
+			URL:       link,
+			TweetID:   tweet.ID,
+			Username:  tweet.Username,
+			CreatedAt: time.Now().Unix(),
+		}
+
+		applied, err := service.store.SaveShortLink(ctx, &shortLink)
+		if err != nil {
+			return "", err
+		}
+		if applied {
+			return code, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free short link code after %d attempts", codeAttempts)
+}
+
+// Resolve returns the original link and counts the click, a failed count does not stop the redirect
+func (service *LinkService) Resolve(ctx context.Context, code string) (string, error) {
+	ctx, span := service.tracer.Start(ctx, "LinkService.Resolve")
+	defer span.End()
+
+	service.logging.Infoln("LinkService.Resolve : resolve service reached")
+
+	link, err := service.store.GetShortLink(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.store.CountClick(ctx, code)
+	if err != nil {
+		service.logging.Errorf("LinkService.Resolve.CountClick : %s", err)
+	}
+
+	return link.URL, nil
+}
+
+func (service *LinkService) GetStats(ctx context.Context, code string, username string) (*domain.ShortLink, error) {
+	ctx, span := service.tracer.Start(ctx, "LinkService.GetStats")
+	defer span.End()
+
+	service.logging.Infoln("LinkService.GetStats : link stats service reached")
+
+	link, err := service.store.GetShortLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Username != username {
+		return nil, fmt.Errorf(errors.NotLinkOwner)
+	}
+
+	return link, nil
+}
+
+// FetchPreviews builds Open Graph cards for the first links of a tweet. It is
+// meant to run in the background after the tweet is saved, so it uses its own context
+func (service *LinkService) FetchPreviews(tweet *domain.Tweet, links []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), previewTimeout*maxPreviews)
+	defer cancel()
+
+	ctx, span := service.tracer.Start(ctx, "LinkService.FetchPreviews")
+	defer span.End()
+
+	var previews []domain.LinkPreview
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if len(previews) == maxPreviews {
+			break
+		}
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+
+		preview, err := service.fetchPreview(ctx, link)
+		if err != nil {
+			service.logging.Warnf("LinkService.FetchPreviews : %s: %s", link, err)
+			continue
+		}
+		if preview != nil {
+			previews = append(previews, *preview)
+		}
+	}
+
+	if len(previews) == 0 {
+		return
+	}
+
+	err := service.store.SaveLinkPreviews(ctx, tweet, previews)
+	if err != nil {
+		service.logging.Errorf("LinkService.FetchPreviews.SaveLinkPreviews : %s", err)
+	}
+}
+
+func (service *LinkService) fetchPreview(ctx context.Context, link string) (*domain.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := service.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, nil
+	}
+
+	preview := parsePreview(io.LimitReader(resp.Body, previewBodyLimit))
+	if preview.Title == "" {
+		return nil, nil
+	}
+	preview.URL = link
+
+	if preview.Image != "" {
+		preview.Image = resolveReference(resp.Request.URL, preview.Image)
+	}
+
+	return preview, nil
+}
+
+// parsePreview reads the og: meta tags from the document head, falling back
+// to the title and description tags
+func parsePreview(body io.Reader) *domain.LinkPreview {
+	preview := &domain.LinkPreview{}
+	var title, description string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return fillPreview(preview, title, description)
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "head":
+				return fillPreview(preview, title, description)
+			case "title":
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return fillPreview(preview, title, description)
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "meta":
+				key, content := metaAttributes(token)
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image":
+					preview.Image = content
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+}
+
+func fillPreview(preview *domain.LinkPreview, title string, description string) *domain.LinkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	return preview
+}
+
+func metaAttributes(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(attr.Val)
+			}
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	return key, content
+}
+
+func resolveReference(base *url.URL, reference string) string {
+	parsed, err := url.Parse(reference)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// NewPreviewClient refuses to connect to loopback and private addresses so
+// links in tweets can't be used to reach internal services
+func NewPreviewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to fetch preview from %s", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: previewTimeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= previewRedirects {
+				return fmt.Errorf("stopped after %d redirects", previewRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect scheme %s", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsMulticast() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast()
+}
+
+func randomCode() (string, error) {
+	code := make([]byte, codeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// trimLink splits punctuation that ends a sentence off the matched link
+func trimLink(match string) (string, string) {
+	link := strings.TrimRight(match, ".,;:!?)]}'")
+	return link, match[len(link):]
+}
+
+func normalizeLink(link string) string {
+	if !strings.Contains(link, "://") {
+		return "http://" + link
+	}
+	return link
+}
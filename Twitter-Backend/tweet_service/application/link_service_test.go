@@ -0,0 +1,414 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testBaseURL = "https://twitter.test/api/tweets"
+
+type fakeLinkStore struct {
+	mutex    sync.Mutex
+	links    map[string]*domain.ShortLink
+	taken    int
+	previews []domain.LinkPreview
+}
+
+func newFakeLinkStore() *fakeLinkStore {
+	return &fakeLinkStore{links: make(map[string]*domain.ShortLink)}
+}
+
+// SaveShortLink reports the first taken codes as already in use
+func (store *fakeLinkStore) SaveShortLink(ctx context.Context, link *domain.ShortLink) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.taken > 0 {
+		store.taken--
+		return false, nil
+	}
+	if _, ok := store.links[link.Code]; ok {
+		return false, nil
+	}
+	store.links[link.Code] = link
+	return true, nil
+}
+
+func (store *fakeLinkStore) GetShortLink(ctx context.Context, code string) (*domain.ShortLink, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	link, ok := store.links[code]
+	if !ok {
+		return nil, fmt.Errorf("link %s not found", code)
+	}
+	return link, nil
+}
+
+func (store *fakeLinkStore) CountClick(ctx context.Context, code string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.links[code].Clicks++
+	return nil
+}
+
+func (store *fakeLinkStore) SaveLinkPreviews(ctx context.Context, tweet *domain.Tweet, previews []domain.LinkPreview) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.previews = append(store.previews, previews...)
+	return nil
+}
+
+func newTestLinkService(store domain.LinkStore, client *http.Client) *LinkService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewLinkService(store, testBaseURL+"/", client, trace.NewNoopTracerProvider().Tracer("test"), logger)
+}
+
+// htmlServer serves the page as text/html on every path
+func htmlServer(t *testing.T, page string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(writer, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestShortenReplacesLinksWithShortLinks(t *testing.T) {
+	store := newFakeLinkStore()
+	service := newTestLinkService(store, http.DefaultClient)
+	tweet := &domain.Tweet{
+		ID:       gocql.TimeUUID(),
+		Username: "ana",
+		Text:     "read https://example.com/a?b=c, www.example.org. and " + testBaseURL + "/l/abcdefg",
+	}
+
+	links, err := service.Shorten(context.Background(), tweet)
+	if err != nil {
+		t.Fatalf("Shorten() error = %v", err)
+	}
+
+	want := []string{"https://example.com/a?b=c", "http://www.example.org"}
+	if strings.Join(links, " ") != strings.Join(want, " ") {
+		t.Errorf("links = %v, want %v", links, want)
+	}
+	if strings.Contains(tweet.Text, "example.") {
+		t.Errorf("text still holds an original link: %q", tweet.Text)
+	}
+	if !strings.HasSuffix(tweet.Text, " and "+testBaseURL+"/l/abcdefg") {
+		t.Errorf("short link in the text was shortened again: %q", tweet.Text)
+	}
+	if len(store.links) != 2 {
+		t.Fatalf("saved %d short links, want 2", len(store.links))
+	}
+
+	for code, link := range store.links {
+		if len(code) != codeLength {
+			t.Errorf("code %q has length %d, want %d", code, len(code), codeLength)
+		}
+		if link.Username != "ana" || link.TweetID != tweet.ID {
+			t.Errorf("short link %s saved for %s/%s", code, link.Username, link.TweetID)
+		}
+		//punctuation ending the sentence stays after the short link
+		short := testBaseURL + "/l/" + code
+		if !strings.Contains(tweet.Text, short+",") && !strings.Contains(tweet.Text, short+".") {
+			t.Errorf("text %q lost the punctuation after %s", tweet.Text, short)
+		}
+
+		resolved, err := service.Resolve(context.Background(), code)
+		if err != nil || resolved != link.URL {
+			t.Errorf("Resolve(%s) = %q, %v, want %q", code, resolved, err, link.URL)
+		}
+	}
+}
+
+func TestShortenRetriesTakenCodes(t *testing.T) {
+	store := newFakeLinkStore()
+	store.taken = codeAttempts - 1
+	service := newTestLinkService(store, http.DefaultClient)
+
+	_, err := service.Shorten(context.Background(), &domain.Tweet{Text: "https://example.com"})
+	if err != nil {
+		t.Fatalf("Shorten() error = %v", err)
+	}
+	if len(store.links) != 1 {
+		t.Errorf("saved %d short links, want 1", len(store.links))
+	}
+
+	store.taken = codeAttempts
+	tweet := &domain.Tweet{Text: "https://example.com/other"}
+	_, err = service.Shorten(context.Background(), tweet)
+	if err == nil {
+		t.Fatal("Shorten() succeeded without a free code")
+	}
+}
+
+func TestShortenWithReusesCodes(t *testing.T) {
+	store := newFakeLinkStore()
+	service := newTestLinkService(store, http.DefaultClient)
+	codes := make(map[string]string)
+	text := "read https://example.com/a and https://example.com/b"
+
+	first := &domain.Tweet{ID: gocql.TimeUUID(), Username: "ana", Text: text}
+	_, err := service.ShortenWith(context.Background(), first, codes)
+	if err != nil {
+		t.Fatalf("ShortenWith() error = %v", err)
+	}
+	if len(codes) != 2 {
+		t.Fatalf("ShortenWith() kept %d codes, want 2", len(codes))
+	}
+
+	//publishing again shortens the same text without saving new short links
+	again := &domain.Tweet{ID: first.ID, Username: "ana", Text: text}
+	links, err := service.ShortenWith(context.Background(), again, codes)
+	if err != nil {
+		t.Fatalf("second ShortenWith() error = %v", err)
+	}
+	if again.Text != first.Text {
+		t.Errorf("second text = %q, want %q", again.Text, first.Text)
+	}
+	if len(links) != 2 {
+		t.Errorf("second run returned %d links for previews, want 2", len(links))
+	}
+	if len(store.links) != 2 {
+		t.Errorf("saved %d short links, want 2", len(store.links))
+	}
+}
+
+func TestParsePreviewReadsOpenGraphTags(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want domain.LinkPreview
+	}{
+		{
+			name: "open graph",
+			page: `<html><head><title>Page title</title>
+				<meta property="og:title" content=" OG title ">
+				<meta property="OG:DESCRIPTION" content="OG description">
+				<meta property="og:image" content="/cover.png">
+				<meta name="description" content="Plain description"></head><body></body></html>`,
+			want: domain.LinkPreview{Title: "OG title", Description: "OG description", Image: "/cover.png"},
+		},
+		{
+			name: "falls back to title and description",
+			page: `<html><head><title> Page title </title>
+				<meta name="description" content="Plain description"></head></html>`,
+			want: domain.LinkPreview{Title: "Page title", Description: "Plain description"},
+		},
+		{
+			name: "stops at the body",
+			page: `<html><head><title>Page title</title></head>
+				<body><meta property="og:title" content="Body title"></body></html>`,
+			want: domain.LinkPreview{Title: "Page title"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parsePreview(strings.NewReader(test.page))
+			if *got != test.want {
+				t.Errorf("parsePreview() = %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchPreviewResolvesTheImage(t *testing.T) {
+	server := htmlServer(t, `<head><meta property="og:title" content="Title">
+		<meta property="og:image" content="/images/cover.png"></head>`)
+	service := newTestLinkService(newFakeLinkStore(), server.Client())
+
+	preview, err := service.fetchPreview(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("fetchPreview() error = %v", err)
+	}
+	if preview == nil {
+		t.Fatal("fetchPreview() returned no preview")
+	}
+	if preview.URL != server.URL+"/article" {
+		t.Errorf("URL = %q, want the link", preview.URL)
+	}
+	if preview.Image != server.URL+"/images/cover.png" {
+		t.Errorf("Image = %q, want it resolved against the page", preview.Image)
+	}
+}
+
+func TestFetchPreviewSkipsPagesWithoutCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr bool
+	}{
+		{
+			name: "not html",
+			handler: func(writer http.ResponseWriter, req *http.Request) {
+				writer.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(writer, `{"og:title": "Title"}`)
+			},
+		},
+		{
+			name: "no title",
+			handler: func(writer http.ResponseWriter, req *http.Request) {
+				writer.Header().Set("Content-Type", "text/html")
+				_, _ = io.WriteString(writer, `<head><meta property="og:description" content="Description"></head>`)
+			},
+		},
+		{
+			name: "error status",
+			handler: func(writer http.ResponseWriter, req *http.Request) {
+				http.Error(writer, "gone", http.StatusNotFound)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+			service := newTestLinkService(newFakeLinkStore(), server.Client())
+
+			preview, err := service.fetchPreview(context.Background(), server.URL)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("fetchPreview() error = %v, want error %t", err, test.wantErr)
+			}
+			if preview != nil {
+				t.Errorf("fetchPreview() = %+v, want no preview", preview)
+			}
+		})
+	}
+}
+
+func TestFetchPreviewReadsAtMostTheBodyLimit(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", previewBodyLimit) + "-->"
+	server := htmlServer(t, "<head>"+padding+`<meta property="og:title" content="Too late"></head>`)
+	service := newTestLinkService(newFakeLinkStore(), server.Client())
+
+	preview, err := service.fetchPreview(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("fetchPreview() error = %v", err)
+	}
+	if preview != nil {
+		t.Errorf("fetchPreview() read past the body limit: %+v", preview)
+	}
+}
+
+func TestFetchPreviewGivesUpOnSlowServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	service := newTestLinkService(newFakeLinkStore(), server.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := service.fetchPreview(ctx, server.URL)
+	if err == nil {
+		t.Fatal("fetchPreview() succeeded against a server that never answers")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("fetchPreview() took %s, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestFetchPreviewsSavesTheFirstCards(t *testing.T) {
+	server := htmlServer(t, `<head><title>Title</title></head>`)
+	store := newFakeLinkStore()
+	service := newTestLinkService(store, server.Client())
+
+	service.FetchPreviews(&domain.Tweet{}, []string{
+		server.URL + "/a", server.URL + "/a", server.URL + "/b", server.URL + "/c",
+	})
+
+	if len(store.previews) != maxPreviews {
+		t.Fatalf("saved %d previews, want %d", len(store.previews), maxPreviews)
+	}
+	if store.previews[0].URL != server.URL+"/a" || store.previews[1].URL != server.URL+"/b" {
+		t.Errorf("saved previews of %s and %s, want /a and /b", store.previews[0].URL, store.previews[1].URL)
+	}
+}
+
+func TestPreviewClientRefusesInternalAddresses(t *testing.T) {
+	server := htmlServer(t, `<head><title>Internal</title></head>`)
+	service := newTestLinkService(newFakeLinkStore(), NewPreviewClient())
+
+	_, err := service.fetchPreview(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "refusing to fetch preview") {
+		t.Fatalf("fetchPreview() of a loopback address error = %v, want it refused", err)
+	}
+
+	internal := []string{"127.0.0.1", "::1", "10.0.0.8", "172.16.4.2", "192.168.1.1", "169.254.169.254",
+		"fe80::1", "fc00::1", "0.0.0.0", "224.0.0.1"}
+	for _, address := range internal {
+		if isPublicIP(net.ParseIP(address)) {
+			t.Errorf("isPublicIP(%s) = true", address)
+		}
+	}
+	for _, address := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		if !isPublicIP(net.ParseIP(address)) {
+			t.Errorf("isPublicIP(%s) = false", address)
+		}
+	}
+}
+
+func TestPreviewClientLimitsRedirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasPrefix(req.URL.Path, "/loop"):
+			http.Redirect(writer, req, req.URL.Path+"/again", http.StatusFound)
+		case req.URL.Path == "/scheme":
+			http.Redirect(writer, req, "file:///etc/passwd", http.StatusFound)
+		case req.URL.Path == "/once":
+			http.Redirect(writer, req, server.URL+"/page", http.StatusFound)
+		default:
+			writer.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(writer, `<head><title>Page</title></head>`)
+		}
+	}))
+	defer server.Close()
+
+	//the redirect rules of the preview client, dialing the test server on loopback
+	client := NewPreviewClient()
+	client.Transport = server.Client().Transport
+	service := newTestLinkService(newFakeLinkStore(), client)
+
+	preview, err := service.fetchPreview(context.Background(), server.URL+"/once")
+	if err != nil || preview == nil {
+		t.Fatalf("fetchPreview() after one redirect = %v, %v", preview, err)
+	}
+
+	_, err = service.fetchPreview(context.Background(), server.URL+"/loop")
+	if err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Errorf("fetchPreview() of a redirect loop error = %v, want it stopped", err)
+	}
+
+	_, err = service.fetchPreview(context.Background(), server.URL+"/scheme")
+	if err == nil || !strings.Contains(err.Error(), "unsupported redirect scheme") {
+		t.Errorf("fetchPreview() redirected to a file error = %v, want it refused", err)
+	}
+}
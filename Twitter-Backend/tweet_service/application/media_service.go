@@ -0,0 +1,338 @@
+package application
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strings"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+	"unicode/utf8"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	maxImages      = 4
+	maxImageSize   = 5 << 20
+	maxGIFSize     = 15 << 20
+	maxVideoSize   = 32 << 20
+	maxVideoLength = 140 * time.Second
+	maxAltText     = 1000
+)
+
+// mediaTypes are the sniffed content types a tweet can carry
+var mediaTypes = map[string]domain.MediaType{
+	"image/jpeg": domain.MediaImage,
+	"image/png":  domain.MediaImage,
+	"image/webp": domain.MediaImage,
+	"image/gif":  domain.MediaGIF,
+	"video/mp4":  domain.MediaVideo,
+}
+
+type MediaService struct {
+	store   domain.MediaMetadataStore
+	blobs   domain.MediaStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewMediaService(store domain.MediaMetadataStore, blobs domain.MediaStore, tracer trace.Tracer, logging *logrus.Logger) *MediaService {
+	return &MediaService{
+		store:   store,
+		blobs:   blobs,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+// Inspect validates the uploads by their content and reads their metadata. A tweet
+// carries either up to four images or a single video or GIF
+func (service *MediaService) Inspect(ctx context.Context, uploads []*domain.MediaUpload) ([]*domain.Media, error) {
+	ctx, span := service.tracer.Start(ctx, "MediaService.Inspect")
+	defer span.End()
+
+	service.logging.Infoln("MediaService.Inspect : inspect service reached")
+
+	if len(uploads) > maxImages {
+		return nil, fmt.Errorf(errors.TooManyMedia)
+	}
+
+	var media []*domain.Media
+	for _, upload := range uploads {
+		item, err := inspectUpload(upload)
+		if err != nil {
+			service.logging.Errorf("MediaService.Inspect : %s", err)
+			return nil, err
+		}
+
+		if item.Type != domain.MediaImage && len(uploads) > 1 {
+			return nil, fmt.Errorf(errors.TooManyMedia)
+		}
+
+		media = append(media, item)
+	}
+
+	return media, nil
+}
+
+func (service *MediaService) Save(ctx context.Context, tweet *domain.Tweet, media []*domain.Media, uploads []*domain.MediaUpload) error {
+	ctx, span := service.tracer.Start(ctx, "MediaService.Save")
+	defer span.End()
+
+	service.logging.Infoln("MediaService.Save : save service reached")
+
+	for i, item := range media {
+		key, err := service.Put(ctx, uploads[i].Data)
+		if err != nil {
+			service.logging.Errorf("MediaService.Save.Put : %s", err)
+			return err
+		}
+
+		err = service.store.SaveMedia(ctx, tweet, item, key)
+		if err != nil {
+			service.logging.Errorf("MediaService.Save : %s", err)
+			return err
+		}
+
+		for size, data := range uploads[i].Variants {
+			key, err = service.Put(ctx, data)
+			if err != nil {
+				service.logging.Errorf("MediaService.Save.Put : %s", err)
+				return err
+			}
+
+			err = service.store.SaveMediaVariant(ctx, item.ID, size, key)
+			if err != nil {
+				service.logging.Errorf("MediaService.Save.SaveMediaVariant : %s", err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Get returns the media in the requested size, images smaller than the size and
+// videos are always returned whole. The returned MIME type is the one of the variant
+func (service *MediaService) Get(ctx context.Context, id string, size domain.ImageSize) (*domain.Media, []byte, error) {
+	ctx, span := service.tracer.Start(ctx, "MediaService.Get")
+	defer span.End()
+
+	service.logging.Infoln("MediaService.Get : get media service reached")
+
+	if !IsImageSize(size) {
+		return nil, nil, fmt.Errorf(errors.InvalidImageSize)
+	}
+	if size == "" {
+		size = domain.ImageOriginal
+	}
+
+	media, ref, err := service.store.GetMedia(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := service.Load(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if size == domain.ImageOriginal || media.Type == domain.MediaVideo {
+		return media, data, nil
+	}
+
+	variantRef, err := service.store.GetMediaVariant(ctx, id, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if variantRef == nil {
+		return media, data, nil
+	}
+
+	variant, err := service.Load(ctx, variantRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	media.MimeType = http.DetectContentType(variant)
+	return media, variant, nil
+}
+
+// Put stores the bytes under their content key, bytes that are already stored are not uploaded again
+func (service *MediaService) Put(ctx context.Context, data []byte) (string, error) {
+	ctx, span := service.tracer.Start(ctx, "MediaService.Put")
+	defer span.End()
+
+	key := domain.ContentKey(data)
+
+	exists, err := service.blobs.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return key, nil
+	}
+
+	err = service.blobs.Put(ctx, key, data, http.DetectContentType(data))
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Load reads the bytes a row points at, rows that were not migrated yet still hold them inline
+func (service *MediaService) Load(ctx context.Context, ref *domain.BlobRef) ([]byte, error) {
+	ctx, span := service.tracer.Start(ctx, "MediaService.Load")
+	defer span.End()
+
+	if ref.Key == "" {
+		return ref.Data, nil
+	}
+
+	return service.blobs.Get(ctx, ref.Key)
+}
+
+func inspectUpload(upload *domain.MediaUpload) (*domain.Media, error) {
+	mimeType := http.DetectContentType(upload.Data)
+	mediaType, ok := mediaTypes[mimeType]
+	if !ok {
+		return nil, fmt.Errorf(errors.UnsupportedMedia)
+	}
+
+	size := len(upload.Data)
+	switch {
+	case mediaType == domain.MediaImage && size > maxImageSize,
+		mediaType == domain.MediaGIF && size > maxGIFSize,
+		mediaType == domain.MediaVideo && size > maxVideoSize:
+		return nil, fmt.Errorf(errors.MediaTooLarge)
+	}
+
+	id, err := gocql.RandomUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	altText := strings.TrimSpace(upload.AltText)
+	if utf8.RuneCountInString(altText) > maxAltText {
+		altText = string([]rune(altText)[:maxAltText])
+	}
+
+	media := &domain.Media{
+		ID:       id,
+		Type:     mediaType,
+		MimeType: mimeType,
+		AltText:  altText,
+		Size:     int64(size),
+	}
+
+	if mediaType == domain.MediaVideo {
+		width, height, duration, err := mp4Info(upload.Data)
+		if err != nil {
+			return nil, fmt.Errorf(errors.UnsupportedMedia)
+		}
+		if duration > maxVideoLength {
+			return nil, fmt.Errorf(errors.VideoTooLong)
+		}
+		media.Width, media.Height, media.DurationMs = width, height, duration.Milliseconds()
+		return media, nil
+	}
+
+	processed, err := ProcessImage(upload.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.Data = processed.Data
+	upload.Variants = processed.Variants
+	media.MimeType = processed.MimeType
+	media.Width, media.Height = processed.Width, processed.Height
+	media.Size = int64(len(processed.Data))
+
+	return media, nil
+}
+
+// mp4Info reads the dimensions from the first visual track header and the
+// duration from the movie header
+func mp4Info(data []byte) (int, int, time.Duration, error) {
+	moov := mp4Boxes(data, "moov")
+	if len(moov) == 0 {
+		return 0, 0, 0, fmt.Errorf("mp4 without a moov box")
+	}
+
+	var duration time.Duration
+	if mvhd := mp4Boxes(moov[0], "mvhd"); len(mvhd) != 0 {
+		duration = mvhdDuration(mvhd[0])
+	}
+
+	for _, trak := range mp4Boxes(moov[0], "trak") {
+		tkhd := mp4Boxes(trak, "tkhd")
+		if len(tkhd) == 0 || len(tkhd[0]) < 80 {
+			continue
+		}
+
+		header := tkhd[0]
+		width := int(binary.BigEndian.Uint32(header[len(header)-8:]) >> 16)
+		height := int(binary.BigEndian.Uint32(header[len(header)-4:]) >> 16)
+		if width > 0 && height > 0 {
+			return width, height, duration, nil
+		}
+	}
+
+	return 0, 0, 0, fmt.Errorf("mp4 without a video track")
+}
+
+// mp4Boxes returns the payloads of the boxes of the given type found directly inside data
+func mp4Boxes(data []byte, boxType string) [][]byte {
+	var boxes [][]byte
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(data)) {
+			return boxes
+		}
+
+		if string(data[4:8]) == boxType {
+			boxes = append(boxes, data[header:size])
+		}
+		data = data[size:]
+	}
+	return boxes
+}
+
+func mvhdDuration(mvhd []byte) time.Duration {
+	var timescale, duration uint64
+	switch {
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	case len(mvhd) >= 20:
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
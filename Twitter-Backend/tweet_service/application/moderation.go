@@ -0,0 +1,285 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"tweet_service/domain"
+)
+
+var urlRegex = regexp.MustCompile(`(?i)\b((?:https?://|www\.)[^\s<>"]+)`)
+
+// ModerationPipeline runs every stage and keeps the strictest result
+type ModerationPipeline struct {
+	stages []domain.TextModerator
+}
+
+// acceptRecorder is a stage that keeps track of the tweets that were saved after moderation
+type acceptRecorder interface {
+	Accepted(ctx context.Context, tweet *domain.Tweet) error
+}
+
+func NewModerationPipeline(stages ...domain.TextModerator) *ModerationPipeline {
+	return &ModerationPipeline{
+		stages: stages,
+	}
+}
+
+func (pipeline *ModerationPipeline) Run(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	result := &domain.ModerationResult{Verdict: domain.VerdictAllow}
+
+	for _, stage := range pipeline.stages {
+		stageResult, err := stage.Moderate(ctx, tweet)
+		if err != nil {
+			return nil, err
+		}
+
+		//a stage answering with a verdict nobody knows fails closed
+		if !stageResult.Verdict.Valid() {
+			stageResult = &domain.ModerationResult{
+				Verdict: domain.VerdictReject,
+				Reason:  fmt.Sprintf("unknown verdict %q", stageResult.Verdict),
+			}
+		}
+
+		if stageResult.Verdict.Severity() > result.Verdict.Severity() {
+			result = stageResult
+			result.Stage = stage.Name()
+		}
+	}
+
+	return result, nil
+}
+
+// Accepted tells the stages that the tweet was saved, it is called for allowed and held tweets
+func (pipeline *ModerationPipeline) Accepted(ctx context.Context, tweet *domain.Tweet) error {
+	for _, stage := range pipeline.stages {
+		recorder, ok := stage.(acceptRecorder)
+		if !ok {
+			continue
+		}
+
+		err := recorder.Accepted(ctx, tweet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func LoadModerationRules(path string) (*domain.ModerationRules, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules domain.ModerationRules
+	err = json.Unmarshal(file, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range rules.Patterns {
+		if !pattern.Verdict.Valid() {
+			return nil, fmt.Errorf("invalid verdict %q for moderation pattern %q", pattern.Verdict, pattern.Pattern)
+		}
+	}
+	if rules.Spam.Verdict != "" && !rules.Spam.Verdict.Valid() {
+		return nil, fmt.Errorf("invalid spam verdict %q", rules.Spam.Verdict)
+	}
+
+	return &rules, nil
+}
+
+type wordRule struct {
+	regex   *regexp.Regexp
+	verdict domain.ModerationVerdict
+	reason  string
+}
+
+// WordFilter matches blocked and held words and the configured regular expressions
+type WordFilter struct {
+	rules []wordRule
+}
+
+func NewWordFilter(rules *domain.ModerationRules) (*WordFilter, error) {
+	filter := &WordFilter{}
+
+	for _, word := range rules.BlockedWords {
+		filter.rules = append(filter.rules, wordRule{
+			regex:   regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`),
+			verdict: domain.VerdictReject,
+			reason:  "contains a blocked word",
+		})
+	}
+
+	for _, word := range rules.HeldWords {
+		filter.rules = append(filter.rules, wordRule{
+			regex:   regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`),
+			verdict: domain.VerdictHold,
+			reason:  "contains a word that needs review",
+		})
+	}
+
+	for _, pattern := range rules.Patterns {
+		regex, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid moderation pattern %q: %s", pattern.Pattern, err)
+		}
+		filter.rules = append(filter.rules, wordRule{
+			regex:   regex,
+			verdict: pattern.Verdict,
+			reason:  pattern.Reason,
+		})
+	}
+
+	return filter, nil
+}
+
+func (filter *WordFilter) Name() string {
+	return "words"
+}
+
+func (filter *WordFilter) Moderate(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	result := &domain.ModerationResult{Verdict: domain.VerdictAllow}
+
+	for _, rule := range filter.rules {
+		if rule.verdict.Severity() > result.Verdict.Severity() && rule.regex.MatchString(tweet.Text) {
+			result = &domain.ModerationResult{Verdict: rule.verdict, Reason: rule.reason}
+		}
+	}
+
+	return result, nil
+}
+
+// LinkFilter rejects tweets linking to a denied domain or any of its subdomains
+type LinkFilter struct {
+	deniedDomains []string
+}
+
+func NewLinkFilter(rules *domain.ModerationRules) *LinkFilter {
+	filter := &LinkFilter{}
+	for _, deniedDomain := range rules.DeniedDomains {
+		filter.deniedDomains = append(filter.deniedDomains, strings.ToLower(strings.TrimPrefix(deniedDomain, ".")))
+	}
+	return filter
+}
+
+func (filter *LinkFilter) Name() string {
+	return "links"
+}
+
+func (filter *LinkFilter) Moderate(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	for _, link := range urlRegex.FindAllString(tweet.Text, -1) {
+		host := linkHost(link)
+		for _, deniedDomain := range filter.deniedDomains {
+			if host == deniedDomain || strings.HasSuffix(host, "."+deniedDomain) {
+				return &domain.ModerationResult{
+					Verdict: domain.VerdictReject,
+					Reason:  fmt.Sprintf("links to a denied domain: %s", deniedDomain),
+				}, nil
+			}
+		}
+	}
+
+	return &domain.ModerationResult{Verdict: domain.VerdictAllow}, nil
+}
+
+func linkHost(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(parsed.Hostname())
+}
+
+// SpamDetector counts how many times a user posted the same text inside the window. Only
+// tweets that were saved count, a rejected attempt doesn't make the next one spam
+type SpamDetector struct {
+	cache      domain.TweetCache
+	window     time.Duration
+	maxRepeats int
+	verdict    domain.ModerationVerdict
+}
+
+func NewSpamDetector(cache domain.TweetCache, rules *domain.ModerationRules) *SpamDetector {
+	detector := &SpamDetector{
+		cache:      cache,
+		window:     time.Duration(rules.Spam.WindowSeconds) * time.Second,
+		maxRepeats: rules.Spam.MaxRepeats,
+		verdict:    rules.Spam.Verdict,
+	}
+
+	if detector.window == 0 {
+		detector.window = 10 * time.Minute
+	}
+	if detector.maxRepeats == 0 {
+		detector.maxRepeats = 3
+	}
+	if detector.verdict == "" {
+		detector.verdict = domain.VerdictHold
+	}
+
+	return detector
+}
+
+func (detector *SpamDetector) Name() string {
+	return "spam"
+}
+
+func (detector *SpamDetector) Moderate(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	key, ok := spamKey(tweet)
+	if !ok {
+		return &domain.ModerationResult{Verdict: domain.VerdictAllow}, nil
+	}
+
+	saved, err := detector.cache.GetCount(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	//this tweet would be one more
+	count := saved + 1
+	if count > int64(detector.maxRepeats) {
+		return &domain.ModerationResult{
+			Verdict: detector.verdict,
+			Reason:  fmt.Sprintf("same text posted %d times in %s", count, detector.window),
+		}, nil
+	}
+
+	return &domain.ModerationResult{Verdict: domain.VerdictAllow}, nil
+}
+
+// Accepted counts the text of a saved tweet
+func (detector *SpamDetector) Accepted(ctx context.Context, tweet *domain.Tweet) error {
+	key, ok := spamKey(tweet)
+	if !ok {
+		return nil
+	}
+
+	_, err := detector.cache.Increment(ctx, key, detector.window)
+	return err
+}
+
+func spamKey(tweet *domain.Tweet) (string, bool) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(tweet.Text)), " ")
+	if normalized == "" {
+		return "", false
+	}
+
+	hash := sha256.Sum256([]byte(normalized))
+	return fmt.Sprintf("spam:%s:%s", tweet.Username, hex.EncodeToString(hash[:])), true
+}
@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"tweet_service/domain"
+)
+
+// countingCache keeps the counters of the spam detector in memory
+type countingCache struct {
+	counts map[string]int64
+}
+
+func newCountingCache() *countingCache {
+	return &countingCache{counts: make(map[string]int64)}
+}
+
+func (cache *countingCache) PostCacheData(ctx context.Context, key string, value *[]byte) error {
+	return nil
+}
+
+func (cache *countingCache) GetCachedValue(ctx context.Context, key string) (*[]byte, error) {
+	return nil, nil
+}
+
+func (cache *countingCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	cache.counts[key]++
+	return cache.counts[key], nil
+}
+
+func (cache *countingCache) GetCount(ctx context.Context, key string) (int64, error) {
+	return cache.counts[key], nil
+}
+
+func (cache *countingCache) IncrementScore(ctx context.Context, key string, member string, by float64, expiration time.Duration) error {
+	return nil
+}
+
+func (cache *countingCache) TopMembers(ctx context.Context, keys []string, limit int) ([]domain.ScoredMember, error) {
+	return nil, nil
+}
+
+func (cache *countingCache) MemberScores(ctx context.Context, keys []string, members []string) (map[string]float64, error) {
+	return nil, nil
+}
+
+// fixedModerator answers every tweet with the same verdict
+type fixedModerator struct {
+	verdict domain.ModerationVerdict
+}
+
+func (moderator fixedModerator) Name() string {
+	return "fixed"
+}
+
+func (moderator fixedModerator) Moderate(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	return &domain.ModerationResult{Verdict: moderator.verdict, Reason: "fixed"}, nil
+}
+
+func newTestModerationRules() *domain.ModerationRules {
+	return &domain.ModerationRules{
+		BlockedWords: []string{"blocked"},
+		HeldWords:    []string{"review"},
+		Patterns: []domain.ModerationPattern{
+			{Pattern: `(?i)\bfree\s+followers\b`, Verdict: domain.VerdictHold, Reason: "follower selling"},
+		},
+		DeniedDomains: []string{".evil.com"},
+		Spam:          domain.SpamRules{WindowSeconds: 60, MaxRepeats: 2, Verdict: domain.VerdictHold},
+	}
+}
+
+func TestModerationPipelineKeepsTheStrictestVerdict(t *testing.T) {
+	rules := newTestModerationRules()
+	words, err := NewWordFilter(rules)
+	if err != nil {
+		t.Fatalf("NewWordFilter() error = %v", err)
+	}
+	pipeline := NewModerationPipeline(words, NewLinkFilter(rules))
+
+	tests := []struct {
+		text    string
+		verdict domain.ModerationVerdict
+		stage   string
+	}{
+		{"hello world", domain.VerdictAllow, ""},
+		{"please review this", domain.VerdictHold, "words"},
+		{"get FREE followers now", domain.VerdictHold, "words"},
+		{"review this blocked word", domain.VerdictReject, "words"},
+		{"see https://cdn.evil.com/x", domain.VerdictReject, "links"},
+		{"see https://notevil.com/x", domain.VerdictAllow, ""},
+		{"please review www.evil.com", domain.VerdictReject, "links"},
+	}
+
+	for _, test := range tests {
+		result, err := pipeline.Run(context.Background(), &domain.Tweet{Username: "ana", Text: test.text})
+		if err != nil {
+			t.Fatalf("Run(%q) error = %v", test.text, err)
+		}
+		if result.Verdict != test.verdict || result.Stage != test.stage {
+			t.Errorf("Run(%q) = %s by %q, want %s by %q", test.text, result.Verdict, result.Stage, test.verdict, test.stage)
+		}
+	}
+}
+
+func TestModerationPipelineRejectsUnknownVerdicts(t *testing.T) {
+	pipeline := NewModerationPipeline(fixedModerator{verdict: domain.VerdictHold}, fixedModerator{verdict: "quarantine"})
+
+	result, err := pipeline.Run(context.Background(), &domain.Tweet{Username: "ana", Text: "hello"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Verdict != domain.VerdictReject {
+		t.Errorf("Run() with an unknown verdict = %s, want %s", result.Verdict, domain.VerdictReject)
+	}
+}
+
+func TestLoadModerationRulesRejectsUnknownVerdicts(t *testing.T) {
+	for _, rules := range []string{
+		`{"patterns": [{"pattern": "x", "verdict": "quarantine"}]}`,
+		`{"spam": {"verdict": "block"}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "rules.json")
+		err := os.WriteFile(path, []byte(rules), 0o600)
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+
+		_, err = LoadModerationRules(path)
+		if err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("LoadModerationRules(%s) error = %v, want an invalid verdict", rules, err)
+		}
+	}
+}
+
+func TestSpamDetectorOnlyCountsAcceptedTweets(t *testing.T) {
+	ctx := context.Background()
+	detector := NewSpamDetector(newCountingCache(), newTestModerationRules())
+	tweet := &domain.Tweet{Username: "ana", Text: "Buy  now"}
+
+	//attempts that were never saved don't count
+	for i := 0; i < 5; i++ {
+		result, err := detector.Moderate(ctx, tweet)
+		if err != nil {
+			t.Fatalf("Moderate() error = %v", err)
+		}
+		if result.Verdict != domain.VerdictAllow {
+			t.Fatalf("attempt %d = %s before anything was saved", i+1, result.Verdict)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		err := detector.Accepted(ctx, tweet)
+		if err != nil {
+			t.Fatalf("Accepted() error = %v", err)
+		}
+	}
+
+	//the same text in other case and spacing is the same tweet
+	result, err := detector.Moderate(ctx, &domain.Tweet{Username: "ana", Text: "buy now"})
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if result.Verdict != domain.VerdictHold {
+		t.Errorf("third post = %s, want %s", result.Verdict, domain.VerdictHold)
+	}
+
+	result, err = detector.Moderate(ctx, &domain.Tweet{Username: "marko", Text: "buy now"})
+	if err != nil {
+		t.Fatalf("Moderate() error = %v", err)
+	}
+	if result.Verdict != domain.VerdictAllow {
+		t.Errorf("post of another user = %s, want %s", result.Verdict, domain.VerdictAllow)
+	}
+}
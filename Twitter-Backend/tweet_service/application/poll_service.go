@@ -0,0 +1,209 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// PollService keeps the polls attached to tweets. A user votes once per poll and only sees
+// the tallies after voting or once the poll is closed
+type PollService struct {
+	store   domain.PollStore
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewPollService(store domain.PollStore, tracer trace.Tracer, logging *logrus.Logger) *PollService {
+	return &PollService{
+		store:   store,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+// Validate trims the options and checks them and the closing time, it is called before the
+// tweet is stored so an invalid poll doesn't leave a tweet behind
+func (service *PollService) Validate(poll *domain.Poll) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf(errors.InvalidPoll)
+	}
+
+	options := make([]domain.PollOption, len(poll.Options))
+	for i, option := range poll.Options {
+		text := strings.TrimSpace(option.Text)
+		length := len([]rune(text))
+		if length == 0 || length > maxPollOptionLength {
+			return fmt.Errorf(errors.InvalidPoll)
+		}
+		options[i] = domain.PollOption{Text: text}
+	}
+
+	closesAt := time.Unix(poll.ClosesAt, 0)
+	now := time.Now()
+	if closesAt.Before(now.Add(minPollDuration)) || closesAt.After(now.Add(maxPollDuration)) {
+		return fmt.Errorf(errors.InvalidPoll)
+	}
+
+	poll.Options = options
+	poll.Closed = false
+	poll.Vote = nil
+	poll.TotalVotes = nil
+	return nil
+}
+
+func (service *PollService) Create(ctx context.Context, tweetID gocql.UUID, poll *domain.Poll) error {
+	ctx, span := service.tracer.Start(ctx, "PollService.Create")
+	defer span.End()
+
+	service.logging.Infoln("PollService.Create : create poll reached")
+
+	return service.store.SavePoll(ctx, tweetID, poll)
+}
+
+// Vote records the vote of username and returns the poll with its results
+func (service *PollService) Vote(ctx context.Context, username string, tweetID string, option int) (*domain.Poll, error) {
+	ctx, span := service.tracer.Start(ctx, "PollService.Vote")
+	defer span.End()
+
+	service.logging.Infoln("PollService.Vote : vote reached")
+
+	id, err := gocql.ParseUUID(tweetID)
+	if err != nil {
+		return nil, fmt.Errorf(errors.PollNotFound)
+	}
+
+	polls, err := service.store.GetPolls(ctx, []gocql.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	poll, ok := polls[id]
+	if !ok {
+		return nil, fmt.Errorf(errors.PollNotFound)
+	}
+
+	if !time.Now().Before(time.Unix(poll.ClosesAt, 0)) {
+		return nil, fmt.Errorf(errors.PollClosed)
+	}
+	if option < 0 || option >= len(poll.Options) {
+		return nil, fmt.Errorf(errors.InvalidPollOption)
+	}
+
+	applied, err := service.store.SaveVote(ctx, id, username, option)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, fmt.Errorf(errors.AlreadyVoted)
+	}
+
+	tweet := domain.Tweet{ID: id}
+	err = service.Attach(ctx, username, []*domain.Tweet{&tweet})
+	if err != nil {
+		return nil, err
+	}
+
+	return tweet.Poll, nil
+}
+
+// Attach sets the polls of the tweets that have one as seen by viewer, who may be empty for
+// requests without a user. Retweets show the poll of the original tweet
+func (service *PollService) Attach(ctx context.Context, viewer string, tweets []*domain.Tweet) error {
+	ctx, span := service.tracer.Start(ctx, "PollService.Attach")
+	defer span.End()
+
+	ids := make([]gocql.UUID, 0, len(tweets))
+	seen := make(map[gocql.UUID]bool, len(tweets))
+	for _, tweet := range tweets {
+		if tweet == nil {
+			continue
+		}
+		id := pollID(tweet)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	polls, err := service.store.GetPolls(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(polls) == 0 {
+		return nil
+	}
+
+	pollIDs := make([]gocql.UUID, 0, len(polls))
+	for id := range polls {
+		pollIDs = append(pollIDs, id)
+	}
+
+	votes, err := service.store.GetVotes(ctx, pollIDs, viewer)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	visible := []gocql.UUID{}
+	for id, poll := range polls {
+		poll.Closed = !now.Before(time.Unix(poll.ClosesAt, 0))
+		if vote, ok := votes[id]; ok {
+			poll.Vote = &vote
+		}
+		if poll.Closed || poll.Vote != nil {
+			visible = append(visible, id)
+		}
+	}
+
+	tallies, err := service.store.GetTallies(ctx, visible)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range visible {
+		poll := polls[id]
+		total := 0
+		for i := range poll.Options {
+			votes := tallies[id][i]
+			poll.Options[i].Votes = &votes
+			total += votes
+		}
+		poll.TotalVotes = &total
+	}
+
+	for _, tweet := range tweets {
+		if tweet == nil {
+			continue
+		}
+		if poll, ok := polls[pollID(tweet)]; ok {
+			//tweets repeated in a feed share the poll, it is only read
+			tweet.Poll = poll
+		}
+	}
+
+	return nil
+}
+
+// pollID returns the ID the poll of the tweet is kept under, a retweet shares the poll of the
+// original tweet
+func pollID(tweet *domain.Tweet) gocql.UUID {
+	if tweet.RetweetOf != nil {
+		return *tweet.RetweetOf
+	}
+	return tweet.ID
+}
@@ -0,0 +1,432 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"tweet_service/authorization"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//number of due tweets published by one replica in one run
+	dueBatch        = 100
+	maxScheduleTime = 365 * 24 * time.Hour
+
+	//a tweet that could not be published is tried again after the claim timeout, doubled
+	//with every attempt up to maxPublishBackoff, and fails after maxPublishAttempts
+	maxPublishAttempts = 5
+	maxPublishBackoff  = time.Hour
+)
+
+// ScheduleService keeps drafts and scheduled tweets and publishes the scheduled ones when
+// they are due. Every replica runs the scheduler, a tweet is published by the replica that
+// claims it with a lightweight transaction
+type ScheduleService struct {
+	store        domain.ScheduleStore
+	tweets       *TweetService
+	replica      string
+	interval     time.Duration
+	claimTimeout time.Duration
+	tracer       trace.Tracer
+	logging      *logrus.Logger
+}
+
+func NewScheduleService(store domain.ScheduleStore, tweets *TweetService, interval time.Duration, claimTimeout time.Duration, tracer trace.Tracer, logging *logrus.Logger) *ScheduleService {
+	hostname, _ := os.Hostname()
+	id, _ := gocql.RandomUUID()
+
+	return &ScheduleService{
+		store:        store,
+		tweets:       tweets,
+		replica:      fmt.Sprintf("%s-%s", hostname, id.String()),
+		interval:     interval,
+		claimTimeout: claimTimeout,
+		tracer:       tracer,
+		logging:      logging,
+	}
+}
+
+// Create saves a draft, or a scheduled tweet when PublishAt is set
+func (service *ScheduleService) Create(ctx context.Context, username string, userType string, request *domain.ScheduledTweet) (*domain.ScheduledTweet, error) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.Create")
+	defer span.End()
+
+	service.logging.Infoln("ScheduleService.Create : create scheduled reached")
+
+	err := validateScheduled(request)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	item := domain.ScheduledTweet{
+		Username:      username,
+		Text:          strings.TrimSpace(request.Text),
+		Advertisement: request.Advertisement,
+		PublishAt:     request.PublishAt,
+		Status:        scheduleStatus(request.PublishAt),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		UserType:      userType,
+	}
+	item.ID, _ = gocql.RandomUUID()
+
+	//the due entry goes first, an entry without its tweet is dropped by the scheduler
+	if item.Status == domain.ScheduleScheduled {
+		err = service.store.AddDue(ctx, dueEntry(&item))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = service.store.SaveScheduled(ctx, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (service *ScheduleService) Get(ctx context.Context, username string, id string) (*domain.ScheduledTweet, error) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.Get")
+	defer span.End()
+
+	uuid, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, fmt.Errorf(errors.ScheduledNotFound)
+	}
+
+	return service.store.GetScheduled(ctx, username, uuid)
+}
+
+// List returns the drafts and scheduled tweets of the user, or only those with the given
+// status. Scheduled tweets come first, ordered by publish time
+func (service *ScheduleService) List(ctx context.Context, username string, status domain.ScheduleStatus) ([]*domain.ScheduledTweet, error) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.List")
+	defer span.End()
+
+	service.logging.Infoln("ScheduleService.List : list scheduled reached")
+
+	items, err := service.store.GetScheduledByUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []*domain.ScheduledTweet{}
+	for _, item := range items {
+		if status == "" || item.Status == status {
+			list = append(list, item)
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if (list[i].PublishAt == 0) != (list[j].PublishAt == 0) {
+			return list[i].PublishAt != 0
+		}
+		if list[i].PublishAt != list[j].PublishAt {
+			return list[i].PublishAt < list[j].PublishAt
+		}
+		return list[i].UpdatedAt > list[j].UpdatedAt
+	})
+
+	return list, nil
+}
+
+// Edit changes the text and publish time. Setting PublishAt schedules a draft, clearing it
+// turns a scheduled tweet back into a draft
+func (service *ScheduleService) Edit(ctx context.Context, username string, id string, update *domain.ScheduledTweet) (*domain.ScheduledTweet, error) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.Edit")
+	defer span.End()
+
+	service.logging.Infoln("ScheduleService.Edit : edit scheduled reached")
+
+	err := validateScheduled(update)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := service.Get(ctx, username, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Status == domain.SchedulePublishing || item.Status == domain.SchedulePublished {
+		return nil, fmt.Errorf(errors.ScheduleConflict)
+	}
+
+	previous := *item
+	item.Text = strings.TrimSpace(update.Text)
+	item.Advertisement = update.Advertisement
+	item.PublishAt = update.PublishAt
+	item.Status = scheduleStatus(update.PublishAt)
+	item.Error = ""
+	item.UpdatedAt = time.Now().Unix()
+	//an edited tweet is a new one to the scheduler
+	item.Attempts = 0
+	item.Links = nil
+
+	return item, service.update(ctx, item, &previous)
+}
+
+// Cancel turns a scheduled tweet back into a draft
+func (service *ScheduleService) Cancel(ctx context.Context, username string, id string) (*domain.ScheduledTweet, error) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.Cancel")
+	defer span.End()
+
+	service.logging.Infoln("ScheduleService.Cancel : cancel scheduled reached")
+
+	item, err := service.Get(ctx, username, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Status != domain.ScheduleScheduled {
+		return nil, fmt.Errorf(errors.ScheduleConflict)
+	}
+
+	previous := *item
+	item.PublishAt = 0
+	item.Status = domain.ScheduleDraft
+	item.UpdatedAt = time.Now().Unix()
+
+	return item, service.update(ctx, item, &previous)
+}
+
+func (service *ScheduleService) Delete(ctx context.Context, username string, id string) error {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.Delete")
+	defer span.End()
+
+	service.logging.Infoln("ScheduleService.Delete : delete scheduled reached")
+
+	item, err := service.Get(ctx, username, id)
+	if err != nil {
+		return err
+	}
+
+	if item.Status == domain.SchedulePublishing {
+		return fmt.Errorf(errors.ScheduleConflict)
+	}
+
+	deleted, err := service.store.DeleteScheduled(ctx, item)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf(errors.ScheduleConflict)
+	}
+
+	if item.Status == domain.ScheduleScheduled {
+		return service.store.RemoveDue(ctx, dueEntry(item))
+	}
+	return nil
+}
+
+// Run publishes due tweets every interval until ctx is done
+func (service *ScheduleService) Run(ctx context.Context) {
+	ticker := time.NewTicker(service.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.publishDue(ctx)
+		}
+	}
+}
+
+func (service *ScheduleService) publishDue(ctx context.Context) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.publishDue")
+	defer span.End()
+
+	now := time.Now()
+	entries, err := service.store.GetDue(ctx, now.Unix(), dueBatch)
+	if err != nil {
+		service.logging.Errorf("ScheduleService.publishDue.GetDue : %s", err)
+		return
+	}
+
+	for _, entry := range entries {
+		item, err := service.store.GetScheduled(ctx, entry.Username, entry.ID)
+		if err != nil {
+			if err.Error() == errors.ScheduledNotFound {
+				service.removeDue(ctx, entry)
+				continue
+			}
+			service.logging.Errorf("ScheduleService.publishDue.GetScheduled : %s", err)
+			continue
+		}
+
+		//edits and cancels leave entries behind that no longer match the tweet
+		if item.PublishAt != entry.PublishAt {
+			service.removeDue(ctx, entry)
+			continue
+		}
+
+		switch item.Status {
+		case domain.ScheduleScheduled:
+		case domain.SchedulePublishing:
+			//a failed publish or a replica that stopped while publishing leaves its claim behind
+			if now.Sub(time.Unix(item.ClaimedAt, 0)) < service.backoff(item.Attempts) {
+				continue
+			}
+		default:
+			service.removeDue(ctx, entry)
+			continue
+		}
+
+		claimed, err := service.store.ClaimScheduled(ctx, item, service.replica, now.Unix())
+		if err != nil {
+			service.logging.Errorf("ScheduleService.publishDue.ClaimScheduled : %s", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if item.Attempts >= maxPublishAttempts {
+			service.complete(ctx, item, entry, domain.ScheduleFailed, errors.PublishFailed)
+			continue
+		}
+
+		service.publishClaimed(ctx, item, entry)
+	}
+}
+
+// publishClaimed publishes a tweet this replica claimed. Tweets rejected by moderation fail,
+// other errors leave the claim in place so the tweet is tried again after the backoff. The
+// short link codes are saved with the claim, so the next attempt doesn't shorten the links again
+func (service *ScheduleService) publishClaimed(ctx context.Context, item *domain.ScheduledTweet, entry *domain.DueEntry) {
+	ctx, span := service.tracer.Start(ctx, "ScheduleService.publishClaimed")
+	defer span.End()
+
+	service.logging.Infof("ScheduleService.publishClaimed : publishing %s of %s", item.ID, item.Username)
+
+	token, err := authorization.NewServiceToken(item.Username, item.UserType)
+	if err != nil {
+		service.logging.Errorf("ScheduleService.publishClaimed.NewServiceToken : %s", err)
+		return
+	}
+
+	saved := len(item.Links)
+	shortened := func(ctx context.Context) error {
+		if len(item.Links) == saved {
+			return nil
+		}
+		applied, err := service.store.SaveScheduledLinks(ctx, item, service.replica)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			return fmt.Errorf(errors.ScheduleConflict)
+		}
+		return nil
+	}
+
+	status := domain.SchedulePublished
+	reason := ""
+	_, err = service.tweets.PublishScheduled(ctx, item, token, shortened)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), errors.TweetRejected) {
+			service.logging.Errorf("ScheduleService.publishClaimed.PublishScheduled : attempt %d: %s", item.Attempts+1, err)
+			return
+		}
+		status = domain.ScheduleFailed
+		reason = err.Error()
+	}
+
+	service.complete(ctx, item, entry, status, reason)
+}
+
+// complete records the outcome of the claim and drops the due entry
+func (service *ScheduleService) complete(ctx context.Context, item *domain.ScheduledTweet, entry *domain.DueEntry, status domain.ScheduleStatus, reason string) {
+	err := service.store.CompleteScheduled(ctx, item, service.replica, status, reason)
+	if err != nil {
+		service.logging.Errorf("ScheduleService.complete.CompleteScheduled : %s", err)
+		return
+	}
+
+	service.removeDue(ctx, entry)
+}
+
+// backoff is how long a claim made on the given attempt is left alone
+func (service *ScheduleService) backoff(attempts int) time.Duration {
+	delay := service.claimTimeout
+	for i := 1; i < attempts && delay < maxPublishBackoff; i++ {
+		delay *= 2
+		if delay > maxPublishBackoff {
+			delay = maxPublishBackoff
+		}
+	}
+	return delay
+}
+
+// update writes item over previous and moves its due entry along with the publish time
+func (service *ScheduleService) update(ctx context.Context, item *domain.ScheduledTweet, previous *domain.ScheduledTweet) error {
+	if item.Status == domain.ScheduleScheduled && item.PublishAt != previous.PublishAt {
+		err := service.store.AddDue(ctx, dueEntry(item))
+		if err != nil {
+			return err
+		}
+	}
+
+	applied, err := service.store.UpdateScheduled(ctx, item, previous.Status, previous.PublishAt)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf(errors.ScheduleConflict)
+	}
+
+	if previous.Status == domain.ScheduleScheduled && item.PublishAt != previous.PublishAt {
+		return service.store.RemoveDue(ctx, dueEntry(previous))
+	}
+	return nil
+}
+
+func (service *ScheduleService) removeDue(ctx context.Context, entry *domain.DueEntry) {
+	err := service.store.RemoveDue(ctx, entry)
+	if err != nil {
+		service.logging.Errorf("ScheduleService.removeDue : %s", err)
+	}
+}
+
+func validateScheduled(item *domain.ScheduledTweet) error {
+	if strings.TrimSpace(item.Text) == "" {
+		return fmt.Errorf(errors.EmptyTweet)
+	}
+
+	if item.PublishAt != 0 {
+		publishAt := time.Unix(item.PublishAt, 0)
+		if !publishAt.After(time.Now()) || publishAt.After(time.Now().Add(maxScheduleTime)) {
+			return fmt.Errorf(errors.InvalidPublishTime)
+		}
+	}
+
+	return nil
+}
+
+func scheduleStatus(publishAt int64) domain.ScheduleStatus {
+	if publishAt == 0 {
+		return domain.ScheduleDraft
+	}
+	return domain.ScheduleScheduled
+}
+
+func dueEntry(item *domain.ScheduledTweet) *domain.DueEntry {
+	return &domain.DueEntry{
+		Username:  item.Username,
+		ID:        item.ID,
+		PublishAt: item.PublishAt,
+	}
+}
@@ -0,0 +1,295 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//number of timeline entries read for one feed
+	timelineLength = 200
+	//number of recent tweets copied into a timeline when a new follow is created
+	backfillLength = 50
+	fanOutTimeout  = 30 * time.Second
+	//the follower count of a celebrity is compared with the threshold again after this long
+	celebrityRecheck = 24 * time.Hour
+)
+
+// TimelineService fans tweets out to the timelines of followers when they are posted.
+// Authors with more followers than the threshold are left out of the fan-out and
+// their tweets are merged into the feed when it is read. Their follower count is checked
+// again on a later tweet, so authors who lose followers are fanned out again
+type TimelineService struct {
+	store     domain.TimelineStore
+	tweets    domain.TweetStore
+	cb        *gobreaker.CircuitBreaker
+	threshold int
+	tracer    trace.Tracer
+	logging   *logrus.Logger
+}
+
+func NewTimelineService(store domain.TimelineStore, tweets domain.TweetStore, threshold int, tracer trace.Tracer, logging *logrus.Logger) *TimelineService {
+	return &TimelineService{
+		store:     store,
+		tweets:    tweets,
+		cb:        CircuitBreaker(),
+		threshold: threshold,
+		tracer:    tracer,
+		logging:   logging,
+	}
+}
+
+// FanOut pushes the tweet into the timelines of the author's followers. It runs in the
+// background after the tweet is saved, so it uses its own context
+func (service *TimelineService) FanOut(token string, tweet domain.Tweet) {
+	ctx, cancel := context.WithTimeout(context.Background(), fanOutTimeout)
+	defer cancel()
+
+	ctx, span := service.tracer.Start(ctx, "TimelineService.FanOut")
+	defer span.End()
+
+	service.logging.Infoln("TimelineService.FanOut : fan out reached")
+
+	//the author always sees their own tweets
+	recipients := []string{tweet.Username}
+
+	celebrity, err := service.store.GetCelebrity(ctx, tweet.Username)
+	if err != nil {
+		service.logging.Errorf("TimelineService.FanOut.GetCelebrity : %s", err)
+		return
+	}
+
+	if celebrity == nil || time.Since(time.Unix(celebrity.CheckedAt, 0)) > celebrityRecheck {
+		followers, err := service.getFollowers(ctx, token, tweet.Username)
+		if err != nil {
+			service.logging.Errorf("TimelineService.FanOut.getFollowers : %s", err)
+			return
+		}
+
+		if len(followers) > service.threshold {
+			err = service.store.SetCelebrity(ctx, tweet.Username, len(followers))
+			if err != nil {
+				service.logging.Errorf("TimelineService.FanOut.SetCelebrity : %s", err)
+				return
+			}
+		} else {
+			if celebrity != nil {
+				err = service.demote(ctx, tweet.Username, followers)
+				if err != nil {
+					service.logging.Errorf("TimelineService.FanOut.demote : %s", err)
+					return
+				}
+			}
+			recipients = append(recipients, followers...)
+		}
+	}
+
+	err = service.store.AddToTimelines(ctx, recipients, []*domain.Tweet{&tweet})
+	if err != nil {
+		service.logging.Errorf("TimelineService.FanOut.AddToTimelines : %s", err)
+	}
+}
+
+// demote fans the recent tweets of a celebrity who dropped below the threshold out to the
+// followers, their tweets were read at feed time until now. The flag is cleared last so the
+// tweets stay in the feeds if the backfill fails
+func (service *TimelineService) demote(ctx context.Context, username string, followers []string) error {
+	ctx, span := service.tracer.Start(ctx, "TimelineService.demote")
+	defer span.End()
+
+	service.logging.Infof("TimelineService.demote : %s is no longer a celebrity", username)
+
+	tweets, err := service.tweets.GetTweetsByUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if len(tweets) > backfillLength {
+		tweets = tweets[:backfillLength]
+	}
+
+	err = service.store.AddToTimelines(ctx, followers, tweets)
+	if err != nil {
+		return err
+	}
+
+	return service.store.ClearCelebrity(ctx, username)
+}
+
+// HandleFollowEvent backfills the follower's timeline with recent tweets of a new
+// followee and removes the tweets of an unfollowed one
+func (service *TimelineService) HandleFollowEvent(ctx context.Context, event *domain.FollowEvent) error {
+	ctx, span := service.tracer.Start(ctx, "TimelineService.HandleFollowEvent")
+	defer span.End()
+
+	service.logging.Infof("TimelineService.HandleFollowEvent : %s %s -> %s", event.Type, event.Follower, event.Followee)
+
+	switch event.Type {
+	case domain.Followed:
+		//timelines that were never built are filled from all followings on the first read
+		built, err := service.store.IsTimelineBuilt(ctx, event.Follower)
+		if err != nil || !built {
+			return err
+		}
+
+		celebrity, err := service.store.IsCelebrity(ctx, event.Followee)
+		if err != nil || celebrity {
+			return err
+		}
+
+		tweets, err := service.tweets.GetTweetsByUser(ctx, event.Followee)
+		if err != nil {
+			return err
+		}
+		if len(tweets) > backfillLength {
+			tweets = tweets[:backfillLength]
+		}
+
+		return service.store.AddToTimelines(ctx, []string{event.Follower}, tweets)
+
+	case domain.Unfollowed:
+		return service.store.RemoveFromTimeline(ctx, event.Follower, event.Followee)
+	}
+
+	return nil
+}
+
+// Feed merges the user's timeline with the tweets of followed celebrities. usernames are
+// the user's followings together with the user
+func (service *TimelineService) Feed(ctx context.Context, username string, usernames []string) ([]*domain.Tweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TimelineService.Feed")
+	defer span.End()
+
+	service.logging.Infoln("TimelineService.Feed : feed reached")
+
+	celebrities, err := service.store.GetCelebrities(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	built, err := service.store.IsTimelineBuilt(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !built {
+		return service.build(ctx, username, usernames, celebrities)
+	}
+
+	feed, err := service.store.GetTimeline(ctx, username, timelineLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(celebrities) != 0 {
+		celebrityTweets, err := service.tweets.GetPostsFeedByUser(ctx, celebrities)
+		if err != nil {
+			return nil, err
+		}
+		feed = append(feed, celebrityTweets...)
+	}
+
+	return newestFirst(feed), nil
+}
+
+// build reads the feed from all followings and stores it as the user's timeline
+func (service *TimelineService) build(ctx context.Context, username string, usernames []string, celebrities []string) ([]*domain.Tweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TimelineService.build")
+	defer span.End()
+
+	feed, err := service.tweets.GetPostsFeedByUser(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	isCelebrity := make(map[string]bool, len(celebrities))
+	for _, celebrity := range celebrities {
+		isCelebrity[celebrity] = true
+	}
+
+	var entries []*domain.Tweet
+	for _, tweet := range feed {
+		if len(entries) == timelineLength {
+			break
+		}
+		if !isCelebrity[tweet.Username] {
+			entries = append(entries, tweet)
+		}
+	}
+
+	err = service.store.AddToTimelines(ctx, []string{username}, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.store.MarkTimelineBuilt(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (service *TimelineService) getFollowers(ctx context.Context, token string, username string) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx, "TimelineService.getFollowers")
+	defer span.End()
+
+	endpoint := fmt.Sprintf("http://%s:%s/followers/%s", followServiceHost, followServicePort, url.PathEscape(username))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Authorization", token)
+
+	followers, err := service.cb.Execute(func() (interface{}, error) {
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("FollowServiceError")
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("FollowServiceError")
+		}
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var followers []string
+		err = json.Unmarshal(body, &followers)
+		if err != nil {
+			return nil, err
+		}
+
+		return followers, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return followers.([]string), nil
+}
+
+// newestFirst sorts the tweets by creation time and drops duplicates, a tweet can be
+// both in the timeline and in the tweets of a user who became a celebrity later
+func newestFirst(tweets []*domain.Tweet) []*domain.Tweet {
+	unique := uniqueTweets(tweets)
+
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].CreatedAt > unique[j].CreatedAt
+	})
+
+	return unique
+}
@@ -0,0 +1,367 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sony/gobreaker"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	userServiceHost = os.Getenv("USER_SERVICE_HOST")
+	userServicePort = os.Getenv("USER_SERVICE_PORT")
+
+	hashtagRegex = regexp.MustCompile(`#[\pL\pN_]+`)
+	termRegex    = regexp.MustCompile(`[\pL][\pL\pN']+`)
+	mentionRegex = regexp.MustCompile(`@[\pL\pN_]+`)
+)
+
+const (
+	//uses are counted in five minute buckets for the window and hourly buckets for the baseline
+	trendBucket          = 5 * time.Minute
+	trendWindow          = time.Hour
+	baselineBucket       = time.Hour
+	baselineBuckets      = 24
+	trendCandidates      = 50
+	minTrendCount        = 3
+	defaultTrendLimit    = 10
+	minTermLength        = 4
+	globalTrendScope     = "global"
+	trendKeyPrefix       = "trends:"
+	countedKeyPrefix     = "trends:counted:"
+	residenceCachePrefix = "residence:"
+)
+
+var stopWords = map[string]bool{
+	"about": true, "after": true, "again": true, "also": true, "been": true, "before": true,
+	"being": true, "could": true, "does": true, "doing": true, "down": true, "each": true,
+	"from": true, "have": true, "having": true, "here": true, "into": true, "just": true,
+	"like": true, "more": true, "most": true, "only": true, "other": true, "over": true,
+	"same": true, "should": true, "some": true, "such": true, "than": true, "that": true,
+	"their": true, "them": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "those": true, "through": true, "under": true, "until": true, "very": true,
+	"were": true, "what": true, "when": true, "where": true, "which": true, "while": true,
+	"will": true, "with": true, "would": true, "your": true, "you're": true, "it's": true,
+	"don't": true, "can't": true, "because": true, "today": true,
+}
+
+// TrendService counts hashtags and terms of tweets that are posted, favorited and retweeted.
+// A term trends when its uses in the last hour are well above its usual hourly rate
+type TrendService struct {
+	cache      domain.TweetCache
+	tweets     domain.TweetStore
+	visibility *VisibilityService
+	publisher  saga.Publisher
+	cb         *gobreaker.CircuitBreaker
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewTrendService(cache domain.TweetCache, tweets domain.TweetStore, visibility *VisibilityService, publisher saga.Publisher, tracer trace.Tracer, logging *logrus.Logger) *TrendService {
+	return &TrendService{
+		cache:      cache,
+		tweets:     tweets,
+		visibility: visibility,
+		publisher:  publisher,
+		cb:         CircuitBreaker(),
+		tracer:     tracer,
+		logging:    logging,
+	}
+}
+
+// Publish sends a tweet event to the trend consumers, failures only cost a count. The owner
+// is the author of the tweet, for a retweet the author of the original
+func (service *TrendService) Publish(eventType domain.TweetEventType, tweetID string, username string, owner string, text string) {
+	event := domain.TweetEvent{
+		Type:      eventType,
+		TweetID:   tweetID,
+		Username:  username,
+		Owner:     owner,
+		Text:      text,
+		Hashtags:  ExtractHashtags(text),
+		Timestamp: time.Now().Unix(),
+	}
+
+	err := service.publisher.Publish(event)
+	if err != nil {
+		service.logging.Errorf("TrendService.Publish : %s", err)
+	}
+}
+
+// HandleEvent counts every hashtag and term of the tweet once, globally and in the
+// residence of the user who acted. A user counts once per tweet however often they post,
+// favorite or retweet it, and tweets of private accounts are not counted at all
+func (service *TrendService) HandleEvent(ctx context.Context, event *domain.TweetEvent) error {
+	ctx, span := service.tracer.Start(ctx, "TrendService.HandleEvent")
+	defer span.End()
+
+	service.logging.Infof("TrendService.HandleEvent : %s %s", event.Type, event.TweetID)
+
+	text, owner := event.Text, event.Owner
+	if text == "" || owner == "" {
+		tweet, err := service.tweets.GetOne(ctx, event.TweetID)
+		if err != nil {
+			return err
+		}
+		if tweet.Hidden || tweet.Advertisement {
+			return nil
+		}
+		text, owner = tweet.Text, author(tweet)
+	}
+
+	terms := ExtractTerms(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	private, err := service.visibility.isPrivate(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if private {
+		return nil
+	}
+
+	//unfavoriting and favoriting again must not count the tweet twice
+	counted, err := service.cache.Increment(ctx, countedKeyPrefix+event.TweetID+":"+event.Username, baselineBucket*(baselineBuckets+1))
+	if err != nil {
+		return err
+	}
+	if counted > 1 {
+		return nil
+	}
+
+	scopes := []string{globalTrendScope}
+	residence, err := service.residence(ctx, event.Username)
+	if err != nil {
+		//the global count is still worth keeping
+		service.logging.Errorf("TrendService.HandleEvent.residence : %s", err)
+	} else if residence != "" {
+		scopes = append(scopes, residenceScope(residence))
+	}
+
+	at := time.Unix(event.Timestamp, 0)
+	for _, scope := range scopes {
+		windowKey := trendKey(scope, trendBucket, at)
+		baselineKey := trendKey(scope, baselineBucket, at)
+		for _, term := range terms {
+			err := service.cache.IncrementScore(ctx, windowKey, term, 1, trendWindow+trendBucket)
+			if err != nil {
+				return err
+			}
+			err = service.cache.IncrementScore(ctx, baselineKey, term, 1, baselineBucket*(baselineBuckets+1))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetTrends returns the terms with the highest velocity, globally or for a residence
+func (service *TrendService) GetTrends(ctx context.Context, residence string, limit int) ([]*domain.Trend, error) {
+	ctx, span := service.tracer.Start(ctx, "TrendService.GetTrends")
+	defer span.End()
+
+	service.logging.Infoln("TrendService.GetTrends : trends service reached")
+
+	if limit <= 0 {
+		limit = defaultTrendLimit
+	}
+
+	scope := globalTrendScope
+	if residence != "" {
+		scope = residenceScope(residence)
+	}
+
+	now := time.Now()
+	var windowKeys []string
+	for at := now; at.After(now.Add(-trendWindow)); at = at.Add(-trendBucket) {
+		windowKeys = append(windowKeys, trendKey(scope, trendBucket, at))
+	}
+
+	//the baseline leaves out the current hour so that a burst doesn't raise its own baseline
+	var baselineKeys []string
+	for i := 1; i <= baselineBuckets; i++ {
+		baselineKeys = append(baselineKeys, trendKey(scope, baselineBucket, now.Add(-time.Duration(i)*baselineBucket)))
+	}
+
+	candidates, err := service.cache.TopMembers(ctx, windowKeys, trendCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []string
+	for _, candidate := range candidates {
+		if candidate.Score >= minTrendCount {
+			terms = append(terms, candidate.Member)
+		}
+	}
+
+	baselines, err := service.cache.MemberScores(ctx, baselineKeys, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := float64(baselineBuckets*baselineBucket) / float64(trendWindow)
+	var trends []*domain.Trend
+	for _, candidate := range candidates {
+		if candidate.Score < minTrendCount {
+			continue
+		}
+
+		baseline := baselines[candidate.Member] / windows
+		trends = append(trends, &domain.Trend{
+			Term:     candidate.Member,
+			Hashtag:  strings.HasPrefix(candidate.Member, "#"),
+			Count:    int(candidate.Score),
+			Baseline: baseline,
+			//smoothed so that new terms don't get an infinite velocity
+			Velocity: (candidate.Score + 1) / (baseline + 1),
+		})
+	}
+
+	sort.SliceStable(trends, func(i, j int) bool {
+		if trends[i].Velocity == trends[j].Velocity {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Velocity > trends[j].Velocity
+	})
+
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+
+	return trends, nil
+}
+
+// residence looks up where the user lives, answers are cached because every event needs one
+func (service *TrendService) residence(ctx context.Context, username string) (string, error) {
+	ctx, span := service.tracer.Start(ctx, "TrendService.residence")
+	defer span.End()
+
+	cacheKey := residenceCachePrefix + username
+	cached, _ := service.cache.GetCachedValue(ctx, cacheKey)
+	if cached != nil {
+		return string(*cached), nil
+	}
+
+	endpoint := fmt.Sprintf("http://%s:%s/getOne/%s", userServiceHost, userServicePort, url.PathEscape(username))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	residence, err := service.cb.Execute(func() (interface{}, error) {
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("UserServiceError")
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("UserServiceError")
+		}
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var user struct {
+			Residence string `json:"residence"`
+		}
+		err = json.Unmarshal(body, &user)
+		if err != nil {
+			return nil, err
+		}
+
+		return user.Residence, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	value := []byte(residence.(string))
+	_ = service.cache.PostCacheData(ctx, cacheKey, &value)
+
+	return residence.(string), nil
+}
+
+// ExtractTerms returns the distinct hashtags and terms of a tweet, hashtags keep their #.
+// Links, mentions, short words and stop words are left out
+func ExtractTerms(text string) []string {
+	text = urlRegex.ReplaceAllString(text, " ")
+	text = mentionRegex.ReplaceAllString(text, " ")
+
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, hashtag := range hashtagRegex.FindAllString(text, -1) {
+		add(strings.ToLower(hashtag))
+	}
+	text = hashtagRegex.ReplaceAllString(text, " ")
+
+	for _, word := range termRegex.FindAllString(text, -1) {
+		word = strings.ToLower(strings.Trim(word, "'"))
+		if len([]rune(word)) < minTermLength || stopWords[word] {
+			continue
+		}
+		add(word)
+	}
+
+	return terms
+}
+
+// ExtractHashtags returns the distinct hashtags of a tweet in lower case, with their #
+func ExtractHashtags(text string) []string {
+	text = urlRegex.ReplaceAllString(text, " ")
+
+	seen := make(map[string]bool)
+	var hashtags []string
+	for _, hashtag := range hashtagRegex.FindAllString(text, -1) {
+		hashtag = strings.ToLower(hashtag)
+		if !seen[hashtag] {
+			seen[hashtag] = true
+			hashtags = append(hashtags, hashtag)
+		}
+	}
+
+	return hashtags
+}
+
+// author returns who wrote the tweet, for a retweet the author of the original
+func author(tweet *domain.Tweet) string {
+	if tweet.OwnerUsername != "" {
+		return tweet.OwnerUsername
+	}
+	return tweet.Username
+}
+
+func residenceScope(residence string) string {
+	return "residence:" + strings.ToLower(strings.TrimSpace(residence))
+}
+
+func trendKey(scope string, bucket time.Duration, at time.Time) string {
+	return fmt.Sprintf("%s%s:%d:%d", trendKeyPrefix, scope, int64(bucket.Minutes()), at.Truncate(bucket).Unix())
+}
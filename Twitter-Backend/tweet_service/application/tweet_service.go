@@ -7,9 +7,11 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 	"tweet_service/domain"
+	"tweet_service/errors"
 	"github.com/gocql/gocql"
 	"github.com/sirupsen/logrus"
 	"github.com/sony/gobreaker"
@@ -28,52 +30,262 @@ type TweetService struct {
 	cache        domain.TweetCache
 	cb           *gobreaker.CircuitBreaker
 	orchestrator *CreateEventOrchestrator
+	moderation   *ModerationPipeline
+	links        *LinkService
+	media        *MediaService
+	timelines    *TimelineService
+	ranker       *FeedRanker
+	trends       *TrendService
+	polls        *PollService
+	engagement   *EngagementService
+	visibility   *VisibilityService
+	impressions  *ImpressionService
 	logging      *logrus.Logger
 }
 
-func NewTweetService(store domain.TweetStore, cache domain.TweetCache, tracer trace.Tracer, orchestrator *CreateEventOrchestrator, logging *logrus.Logger) *TweetService {
+func NewTweetService(store domain.TweetStore, cache domain.TweetCache, tracer trace.Tracer, orchestrator *CreateEventOrchestrator, moderation *ModerationPipeline, links *LinkService, media *MediaService, timelines *TimelineService, ranker *FeedRanker, trends *TrendService, polls *PollService, engagement *EngagementService, visibility *VisibilityService, impressions *ImpressionService, logging *logrus.Logger) *TweetService {
 	return &TweetService{
 		store:        store,
 		cache:        cache,
 		cb:           CircuitBreaker(),
 		orchestrator: orchestrator,
+		moderation:   moderation,
+		links:        links,
+		media:        media,
+		timelines:    timelines,
+		ranker:       ranker,
+		trends:       trends,
+		polls:        polls,
+		engagement:   engagement,
+		visibility:   visibility,
+		impressions:  impressions,
 		tracer:       tracer,
-		logging: logging,
+		logging:      logging,
 	}
 }
 
-func (service *TweetService) GetAll(ctx context.Context) ([]domain.Tweet, error) {
+// GetAll returns every tweet viewer may read with its engagement and poll as seen by viewer,
+// hidden tweets and tweets of private accounts the viewer doesn't follow are left out
+func (service *TweetService) GetAll(ctx context.Context, token string, viewer string) ([]*domain.Tweet, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetAll")
 	defer span.End()
 
 	service.logging.Infoln("TweetService.GetAll : getAll service reached")
 
-	return service.store.GetAll(ctx)
+	tweets, err := service.store.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make([]*domain.Tweet, 0, len(tweets))
+	for i := range tweets {
+		tweet := &tweets[i]
+		if tweet.Hidden {
+			continue
+		}
+		visible, err := service.visibility.CanViewTweet(ctx, token, viewer, tweet)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			readable = append(readable, tweet)
+		}
+	}
+
+	return readable, service.attach(ctx, viewer, readable)
 }
 
-func (service *TweetService) GetOne(ctx context.Context, tweetID string) (*domain.Tweet, error) {
+// GetOne returns the tweet with its engagement and poll as seen by viewer
+func (service *TweetService) GetOne(ctx context.Context, token string, viewer string, tweetID string) (*domain.Tweet, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetOne")
 	defer span.End()
 
 	service.logging.Infoln("TweetService.GetOne : getOne service reached")
 
-	return service.store.GetOne(ctx, tweetID)
+	tweet, err := service.visibleTweet(ctx, token, viewer, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.attach(ctx, viewer, []*domain.Tweet{tweet})
+	if err != nil {
+		return nil, err
+	}
+
+	return tweet, nil
+}
+
+// Vote records the vote of viewer on the poll of a tweet, polls of tweets the viewer may not
+// read look the same as missing ones
+func (service *TweetService) Vote(ctx context.Context, token string, viewer string, tweetID string, option int) (*domain.Poll, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.Vote")
+	defer span.End()
+
+	service.logging.Infoln("TweetService.Vote : vote service reached")
+
+	tweet, err := service.visibleTweet(ctx, token, viewer, tweetID)
+	if err != nil {
+		if err.Error() == errors.TweetNotFound {
+			return nil, fmt.Errorf(errors.PollNotFound)
+		}
+		return nil, err
+	}
+
+	//a vote on a retweet is a vote on the original poll
+	return service.polls.Vote(ctx, viewer, pollID(tweet).String(), option)
+}
+
+// visibleTweet returns the tweet if viewer may read it. Hidden tweets and tweets of private
+// accounts the viewer doesn't follow look the same as missing ones
+func (service *TweetService) visibleTweet(ctx context.Context, token string, viewer string, tweetID string) (*domain.Tweet, error) {
+	tweet, err := service.store.GetOne(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tweet.Hidden {
+		return nil, fmt.Errorf(errors.TweetNotFound)
+	}
+
+	visible, err := service.visibility.CanViewTweet(ctx, token, viewer, tweet)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, fmt.Errorf(errors.TweetNotFound)
+	}
+
+	return tweet, nil
 }
 
-func (service *TweetService) GetTweetsByUser(ctx context.Context, username string) ([]*domain.Tweet, error) {
+// readable keeps the tweets viewer may read, for retweets the author of the original is
+// checked as well
+func (service *TweetService) readable(ctx context.Context, token string, viewer string, tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	readable := make([]*domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		if tweet.Hidden {
+			continue
+		}
+		visible, err := service.visibility.CanViewTweet(ctx, token, viewer, tweet)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			readable = append(readable, tweet)
+		}
+	}
+
+	return readable, nil
+}
+
+// attach fills in what depends on the viewer, the engagement flags and the poll results
+func (service *TweetService) attach(ctx context.Context, viewer string, tweets []*domain.Tweet) error {
+	err := service.engagement.Attach(ctx, viewer, tweets)
+	if err != nil {
+		return err
+	}
+
+	return service.polls.Attach(ctx, viewer, tweets)
+}
+
+func (service *TweetService) Hide(ctx context.Context, tweetID string) error {
+	ctx, span := service.tracer.Start(ctx, "TweetService.Hide")
+	defer span.End()
+
+	service.logging.Infoln("TweetService.Hide : hide service reached")
+
+	return service.store.SetHidden(ctx, tweetID, true)
+}
+
+func (service *TweetService) Release(ctx context.Context, tweetID string) error {
+	ctx, span := service.tracer.Start(ctx, "TweetService.Release")
+	defer span.End()
+
+	service.logging.Infoln("TweetService.Release : release service reached")
+
+	return service.store.SetHidden(ctx, tweetID, false)
+}
+
+func (service *TweetService) GetModerationRecords(ctx context.Context, verdict domain.ModerationVerdict) ([]*domain.ModerationRecord, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.GetModerationRecords")
+	defer span.End()
+
+	service.logging.Infoln("TweetService.GetModerationRecords : moderation records service reached")
+
+	return service.store.GetModerationRecords(ctx, verdict)
+}
+
+// moderate runs the moderation pipeline and keeps a record of every held or rejected tweet
+func (service *TweetService) moderate(ctx context.Context, tweet *domain.Tweet) (*domain.ModerationResult, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.moderate")
+	defer span.End()
+
+	result, err := service.moderation.Run(ctx, tweet)
+	if err != nil {
+		service.logging.Errorf("TweetService.moderate : %s", err)
+		return nil, err
+	}
+
+	if result.Verdict == domain.VerdictAllow {
+		return result, nil
+	}
+
+	record := domain.ModerationRecord{
+		TweetID:   tweet.ID,
+		Username:  tweet.Username,
+		Text:      tweet.Text,
+		Verdict:   result.Verdict,
+		Reason:    result.Reason,
+		Stage:     result.Stage,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	err = service.store.SaveModerationRecord(ctx, &record)
+	if err != nil {
+		service.logging.Errorf("TweetService.moderate.SaveModerationRecord : %s", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTweetsByUser returns the tweets of username with their engagement and polls as seen by viewer.
+// A private account is only readable by its followers, retweets of private tweets are left out
+func (service *TweetService) GetTweetsByUser(ctx context.Context, token string, viewer string, username string) ([]*domain.Tweet, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetTweetsByUser")
 	defer span.End()
 	service.logging.Infoln("TweetService.getTweetsbyUser : tweetsByUser service reached")
 
-	return service.store.GetTweetsByUser(ctx, username)
+	visible, err := service.visibility.CanView(ctx, token, viewer, username)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, fmt.Errorf(errors.ProtectedAccount)
+	}
+
+	tweets, err := service.store.GetTweetsByUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	readable, err := service.readable(ctx, token, viewer, tweets)
+	if err != nil {
+		return nil, err
+	}
+
+	return readable, service.attach(ctx, viewer, readable)
 }
 
-func (service *TweetService) GetFeedByUser(ctx context.Context, token string) (*domain.FeedData, error) {
+func (service *TweetService) GetFeedByUser(ctx context.Context, token string, username string, mode domain.FeedMode) (*domain.FeedData, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetFeedByUser")
 	defer span.End()
 
 	service.logging.Infoln("Feed : feed service reached")
 	followServiceEndpoint := fmt.Sprintf("http://%s:%s/feedInfo", followServiceHost, followServicePort)
+	if mode == domain.FeedRanked {
+		followServiceEndpoint += "?ranked=true"
+	}
 	followServiceRequest, _ := http.NewRequest("GET", followServiceEndpoint, nil)
 	followServiceRequest.Header.Add("Authorization", token)
 	bodyBytes, err := service.cb.Execute(func() (interface{}, error) {
@@ -106,12 +318,37 @@ func (service *TweetService) GetFeedByUser(ctx context.Context, token string) (*
 		return nil, err
 	}
 	feedInfo := bodyBytes.(domain.FeedInfo)
-	feed, err := service.store.GetPostsFeedByUser(ctx, feedInfo.Usernames)
+	feed, err := service.timelines.Feed(ctx, username, feedInfo.Usernames)
 	if err != nil {
 		service.logging.Errorln("error getting feed by user")
 		return nil, err
 	}
 
+	//retweets of private accounts the user doesn't follow are in the timeline too
+	feed, err = service.readable(ctx, token, username, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	//the ranker attaches the engagement of its candidates itself
+	if mode == domain.FeedRanked {
+		feed, err = service.ranker.Rank(ctx, token, username, &feedInfo, feed)
+		if err != nil {
+			service.logging.Errorln("error ranking feed")
+			return nil, err
+		}
+	} else {
+		err = service.engagement.Attach(ctx, username, feed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = service.polls.Attach(ctx, username, feed)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(feedInfo.AdIds) == 0 {
 		return &domain.FeedData{
 			Feed: feed,
@@ -119,87 +356,324 @@ func (service *TweetService) GetFeedByUser(ctx context.Context, token string) (*
 		}, nil
 	}
 
-	ads, err := service.store.GetRecommendAdsForUser(ctx, feedInfo.AdIds)
+	candidates, err := service.store.GetRecommendAdsForUser(ctx, feedInfo.AdIds)
 	if err != nil {
 		log.Printf("Error in getting recommend ads for user: %s", err.Error())
 		return nil, err
 	}
 
+	//only advertisements the user may read are served, whatever follow_service picked
+	var ads []*domain.Tweet
+	for _, ad := range candidates {
+		if !ad.Advertisement {
+			continue
+		}
+		visible, err := service.visibility.CanViewTweet(ctx, token, username, ad)
+		if err != nil || !visible {
+			continue
+		}
+		ads = append(ads, ad)
+	}
+
+	err = service.attach(ctx, username, ads)
+	if err != nil {
+		return nil, err
+	}
+
+	service.impressions.Record(username, ads)
+
 	return &domain.FeedData{
 		Feed: feed,
 		Ads:  ads,
 	}, nil
 }
 
-func (service *TweetService) saveImage(ctx context.Context, tweetID gocql.UUID, imageBytes []byte) error {
+// GetListFeed returns the tweets of the list members the user may read, newest first
+func (service *TweetService) GetListFeed(ctx context.Context, token string, viewer string, listID string) ([]*domain.Tweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.GetListFeed")
+	defer span.End()
+
+	service.logging.Infoln("TweetService.GetListFeed : list feed service reached")
+
+	followServiceEndpoint := fmt.Sprintf("http://%s:%s/lists/%s/feedInfo", followServiceHost, followServicePort, url.PathEscape(listID))
+	followServiceRequest, err := http.NewRequestWithContext(ctx, "GET", followServiceEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	followServiceRequest.Header.Add("Authorization", token)
+
+	result, err := service.cb.Execute(func() (interface{}, error) {
+		response, err := http.DefaultClient.Do(followServiceRequest)
+		if err != nil {
+			service.logging.Errorln("follow service error")
+			return nil, fmt.Errorf("FollowServiceError")
+		}
+		defer response.Body.Close()
+
+		//a missing list or someone else's private list is not a failure of follow_service
+		if response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("FollowServiceError")
+		}
+
+		var feedInfo domain.FeedInfo
+		err = json.NewDecoder(response.Body).Decode(&feedInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		return &feedInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf(errors.ListNotFound)
+	}
+
+	feedInfo := result.(*domain.FeedInfo)
+	if len(feedInfo.Usernames) == 0 {
+		return []*domain.Tweet{}, nil
+	}
+
+	feed, err := service.store.GetPostsFeedByUser(ctx, feedInfo.Usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err = service.readable(ctx, token, viewer, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, service.attach(ctx, viewer, feed)
+}
+
+func (service *TweetService) saveImage(ctx context.Context, tweetID gocql.UUID, key string) error {
 	ctx, span := service.tracer.Start(ctx, "TweetService.saveImage")
 	defer span.End()
 
 	service.logging.Infoln("saveimage : saveimage service reached")
 
-	return service.store.SaveImage(ctx, tweetID, imageBytes)
+	return service.store.SaveImage(ctx, tweetID, key)
+}
+
+// GetMedia returns the media if viewer may read the tweet it belongs to, media of hidden
+// tweets and of private accounts the viewer doesn't follow look the same as missing media
+func (service *TweetService) GetMedia(ctx context.Context, token string, viewer string, id string, size domain.ImageSize) (*domain.Media, []byte, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.GetMedia")
+	defer span.End()
+
+	service.logging.Infoln("TweetService : getMedia service reached")
+
+	media, data, err := service.media.Get(ctx, id, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = service.visibleTweet(ctx, token, viewer, media.TweetID.String())
+	if err != nil {
+		if err.Error() == errors.TweetNotFound {
+			return nil, nil, fmt.Errorf(errors.MediaNotFound)
+		}
+		return nil, nil, err
+	}
+
+	return media, data, nil
 }
 
-func (service *TweetService) GetLikesByTweet(ctx context.Context, tweetID string) ([]*domain.Favorite, error) {
+func (service *TweetService) GetLikesByTweet(ctx context.Context, token string, viewer string, tweetID string) ([]*domain.Favorite, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetLikesByTweet")
 	defer span.End()
 
 	service.logging.Infoln("TweetService : likesbytweet service reached")
 
+	_, err := service.visibleTweet(ctx, token, viewer, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
 	return service.store.GetLikesByTweet(ctx, tweetID)
 }
 
-func (service *TweetService) Post(ctx context.Context, tweet *domain.Tweet, username string, image *[]byte) (*domain.Tweet, error) {
+func (service *TweetService) GetRetweetsByTweet(ctx context.Context, token string, viewer string, tweetID string) ([]*domain.Retweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.GetRetweetsByTweet")
+	defer span.End()
+
+	service.logging.Infoln("TweetService : retweetsbytweet service reached")
+
+	_, err := service.visibleTweet(ctx, token, viewer, tweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.store.GetRetweetsByTweet(ctx, tweetID)
+}
+
+func (service *TweetService) Post(ctx context.Context, tweet *domain.Tweet, username string, token string, uploads []*domain.MediaUpload) (*domain.Tweet, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.Post")
 	defer span.End()
 
-	service.logging.Infoln("TweetService : likesbytweet service reached")
+	service.logging.Infoln("TweetService : post service reached")
 
 	tweet.ID, _ = gocql.RandomUUID()
+	tweet.Username = username
+	tweet.CreatedAt = time.Now().Unix()
 
-	tweet.Image = false
-	if len(*image) != 0 {
-		log.Printf("USLO U SLIKU")
-		err := service.saveImage(ctx, tweet.ID, *image)
+	return service.publish(ctx, tweet, token, uploads, nil, nil)
+}
+
+// PublishScheduled posts a scheduled tweet under the id it got when it was scheduled and with
+// the scheduled time as its creation time, so publishing it again writes the same rows. The
+// links are shortened with the codes in item.Links, new codes are added there and handed to
+// shortened before anything else is written
+func (service *TweetService) PublishScheduled(ctx context.Context, item *domain.ScheduledTweet, token string, shortened func(ctx context.Context) error) (*domain.Tweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.PublishScheduled")
+	defer span.End()
+
+	service.logging.Infoln("TweetService : publish scheduled reached")
+
+	tweet := domain.Tweet{
+		ID:            item.ID,
+		Username:      item.Username,
+		Text:          item.Text,
+		Advertisement: item.Advertisement,
+		CreatedAt:     item.PublishAt,
+	}
+
+	if item.Links == nil {
+		item.Links = make(map[string]string)
+	}
+
+	return service.publish(ctx, &tweet, token, nil, item.Links, shortened)
+}
+
+// publish moderates, stores and fans out a tweet that already has its id, author and creation time.
+// Links are shortened with codes and shortened is called after, both may be nil
+func (service *TweetService) publish(ctx context.Context, tweet *domain.Tweet, token string, uploads []*domain.MediaUpload, codes map[string]string, shortened func(ctx context.Context) error) (*domain.Tweet, error) {
+	ctx, span := service.tracer.Start(ctx, "TweetService.publish")
+	defer span.End()
+
+	if tweet.Poll != nil {
+		err := service.polls.Validate(tweet.Poll)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		err = service.cache.PostCacheData(ctx, tweet.ID.String(), image)
+	media, err := service.media.Inspect(ctx, uploads)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := service.moderate(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Verdict == domain.VerdictReject {
+		return nil, fmt.Errorf("%s: %s", errors.TweetRejected, result.Reason)
+	}
+	tweet.Hidden = result.Verdict == domain.VerdictHold
+	//moderation saw the text before its links were shortened
+	moderated := domain.Tweet{ID: tweet.ID, Username: tweet.Username, Text: tweet.Text}
+
+	links, err := service.links.ShortenWith(ctx, tweet, codes)
+	if err != nil {
+		return nil, err
+	}
+	if shortened != nil {
+		err = shortened(ctx)
 		if err != nil {
 			return nil, err
 		}
-		tweet.Image = true
 	}
-	tweet.CreatedAt = time.Now().Unix()
+
+	tweet.Image = false
+	tweet.Media = nil
+	if len(media) != 0 {
+		err := service.media.Save(ctx, tweet, media, uploads)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range media {
+			tweet.Media = append(tweet.Media, *item)
+		}
+		//the image flag keeps /image/{id} working for clients that don't read the media list
+		tweet.Image = media[0].Type == domain.MediaImage
+	}
 	tweet.Favorited = false
 	tweet.FavoriteCount = 0
 	tweet.Retweeted = false
 	tweet.RetweetCount = 0
-	tweet.Username = username
 
-	return service.store.Post(ctx, tweet)
+	saved, err := service.store.Post(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.moderation.Accepted(ctx, &moderated)
+	if err != nil {
+		service.logging.Errorf("TweetService.publish.Accepted : %s", err)
+	}
+
+	if saved.Poll != nil {
+		err = service.polls.Create(ctx, saved.ID, saved.Poll)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(links) != 0 {
+		previewTweet := *saved
+		go service.links.FetchPreviews(&previewTweet, links)
+	}
+
+	//held tweets are fanned out as well, timelines skip them until they are released
+	go service.timelines.FanOut(token, *saved)
+
+	if !saved.Hidden && !saved.Advertisement {
+		service.trends.Publish(domain.TweetPosted, saved.ID.String(), saved.Username, saved.Username, saved.Text)
+	}
+
+	return saved, nil
 }
 
-func (service *TweetService) Favorite(ctx context.Context, id string, username string, isAd bool) (int, error) {
+// Favorite toggles the favorite of username on a tweet the user may read
+func (service *TweetService) Favorite(ctx context.Context, token string, id string, username string, isAd bool) (int, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.Favorite")
 	defer span.End()
 
 	service.logging.Infoln("TweetService : favorite service reached")
 
+	tweet, err := service.visibleTweet(ctx, token, username, id)
+	if err != nil {
+		return 0, err
+	}
+
 	status, err := service.store.Favorite(ctx, id, username)
 	if err != nil {
 		service.logging.Errorln("TweetService : favorite error")
 		return status, err
 	}
 
-	if isAd {
-		event := events.Event{
-			TweetID:   id,
-			Type:      "",
-			Timestamp: time.Now().Unix(),
-			Timespent: 0,
+	if status == 201 {
+		service.ranker.RecordEngagement(ctx, id, favoriteEngagement)
+		//the text goes along so consumers outside this service get the hashtags too
+		text := ""
+		if !tweet.Advertisement {
+			text = tweet.Text
 		}
+		service.trends.Publish(domain.TweetFavorited, id, username, author(tweet), text)
+	} else if status == 200 {
+		service.ranker.RecordEngagement(ctx, id, -favoriteEngagement)
+	}
+
+	if isAd {
+		event := newAdEvent(id, "", 0)
 		if status == 200 {
 			event.Type = "Unliked"
 		} else {
@@ -221,12 +695,7 @@ func (service *TweetService) TimeSpentOnAd(ctx context.Context, timespent *domai
 
 	service.logging.Infoln("TimeSpentOnAd : timespent service reached")
 
-	event := events.Event{
-		TweetID:   timespent.TweetID,
-		Type:      "Timespent",
-		Timestamp: time.Now().Unix(),
-		Timespent: timespent.Timespent,
-	}
+	event := newAdEvent(timespent.TweetID, "Timespent", timespent.Timespent)
 
 	err := service.orchestrator.Start(ctx, event)
 	if err != nil {
@@ -237,25 +706,73 @@ func (service *TweetService) TimeSpentOnAd(ctx context.Context, timespent *domai
 	return nil
 }
 
-func (service *TweetService) GetTweetImage(ctx context.Context, id string) (*[]byte, error) {
+// newAdEvent creates an ad event with a new ID, report_service counts each ID once however
+// often the event is delivered
+func newAdEvent(tweetID string, eventType string, timespent int64) domain.AdEvent {
+	id, _ := gocql.RandomUUID()
+	return domain.AdEvent{
+		ID: id.String(),
+		Event: events.Event{
+			TweetID:   tweetID,
+			Type:      eventType,
+			Timestamp: time.Now().Unix(),
+			Timespent: timespent,
+		},
+	}
+}
+
+// GetTweetImage returns the first image of a tweet in the requested size, legacy images only exist in one size.
+// Visibility is checked before the cache since cached images are shared by all viewers
+func (service *TweetService) GetTweetImage(ctx context.Context, token string, viewer string, id string, size domain.ImageSize) (*[]byte, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.GetTweetImage")
 	defer span.End()
 
 	service.logging.Infoln("TweetService : getTweetImage service reached")
 
-	cachedImage, _ := service.cache.GetCachedValue(ctx, id)
+	if !IsImageSize(size) {
+		return nil, fmt.Errorf(errors.InvalidImageSize)
+	}
+
+	tweet, err := service.visibleTweet(ctx, token, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := id
+	if size != "" && size != domain.ImageOriginal {
+		cacheKey = id + ":" + string(size)
+	}
+
+	cachedImage, _ := service.cache.GetCachedValue(ctx, cacheKey)
 
 	if cachedImage != nil {
 		return cachedImage, nil
 	}
 
-	image, err := service.store.GetTweetImage(ctx, id)
+	ref, err := service.store.GetTweetImage(ctx, id)
 	if err != nil {
 		service.logging.Errorln("TweetService : gettweetImage error")
 		return nil, err
 	}
 
-	err = service.cache.PostCacheData(ctx, id, &image)
+	image, err := service.media.Load(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	//tweets with a media list don't have a row in tweet_image, their first image is served instead
+	if len(image) == 0 {
+		if len(tweet.Media) == 0 || tweet.Media[0].Type != domain.MediaImage {
+			return nil, fmt.Errorf(errors.MediaNotFound)
+		}
+
+		_, image, err = service.media.Get(ctx, tweet.Media[0].ID.String(), size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = service.cache.PostCacheData(ctx, cacheKey, &image)
 	if err != nil {
 		log.Printf("POST REDIS ERR: %s", err.Error())
 		return nil, err
@@ -269,12 +786,7 @@ func (service *TweetService) ViewProfileFromAd(ctx context.Context, tweetID doma
 
 	service.logging.Infoln("TweetService : viewProfileFromAd service reached")
 
-	event := events.Event{
-		TweetID:   tweetID.ID,
-		Type:      "ViewCount",
-		Timestamp: time.Now().Unix(),
-		Timespent: 0,
-	}
+	event := newAdEvent(tweetID.ID, "ViewCount", 0)
 
 	err := service.orchestrator.Start(ctx, event)
 	if err != nil {
@@ -285,15 +797,23 @@ func (service *TweetService) ViewProfileFromAd(ctx context.Context, tweetID doma
 
 }
 
-func (service *TweetService) Retweet(ctx context.Context, id string, username string) (int, error) {
+// Retweet copies a tweet the user may read into the user's tweets, hidden tweets and tweets of
+// private accounts the user doesn't follow can't be retweeted
+func (service *TweetService) Retweet(ctx context.Context, id string, username string, token string) (int, error) {
 	ctx, span := service.tracer.Start(ctx, "TweetService.Retweet")
 	defer span.End()
 
 	service.logging.Infoln("TweetService : retweet service reached")
 
-	tweet, err := service.store.GetOne(ctx, id)
+	tweet, err := service.visibleTweet(ctx, token, username, id)
 	if err != nil {
 		service.logging.Errorln("TweetService : getOne failed")
+		switch err.Error() {
+		case errors.TweetNotFound:
+			return 404, err
+		case errors.VisibilityUnknown:
+			return 503, err
+		}
 		return 500, err
 	}
 
@@ -301,21 +821,42 @@ func (service *TweetService) Retweet(ctx context.Context, id string, username st
 	if err != nil {
 		return status, err
 	}
+	service.ranker.RecordEngagement(ctx, tweet.ID.String(), retweetEngagement)
+	if !tweet.Advertisement {
+		service.trends.Publish(domain.TweetRetweeted, tweet.ID.String(), username, author(tweet), tweet.Text)
+	}
 
-	if tweet.Image {
-		image, err := service.store.GetTweetImage(ctx, tweet.ID.String())
+	//media rows are shared through the copied media list, a legacy image is shared through its content key
+	if tweet.Image && len(tweet.Media) == 0 {
+		ref, err := service.store.GetTweetImage(ctx, tweet.ID.String())
 		if err != nil {
 			service.logging.Errorln("TweetService : getTweetImage error")
 			return 500, err
 		}
 
-		err = service.saveImage(ctx, *newUUID, image)
+		key := ref.Key
+		if key == "" {
+			key, err = service.media.Put(ctx, ref.Data)
+			if err != nil {
+				service.logging.Errorln("TweetService : media put error")
+				return 500, err
+			}
+		}
+
+		err = service.saveImage(ctx, *newUUID, key)
 		if err != nil {
 			log.Printf("Error in saving image of root tweet in retweet in TweetService.Retweet: %s", err.Error())
 			return 500, err
 		}
 	}
 
+	retweet, err := service.store.GetOne(ctx, newUUID.String())
+	if err != nil {
+		service.logging.Errorln("TweetService : getOne of retweet failed")
+		return 500, err
+	}
+	go service.timelines.FanOut(token, *retweet)
+
 	return status, nil
 }
 
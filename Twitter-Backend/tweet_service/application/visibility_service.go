@@ -0,0 +1,221 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//privacy changes rarely, follows are trusted for a shorter time so an unfollow takes effect soon
+	privacyTTL = time.Minute
+	followTTL  = 30 * time.Second
+
+	//answers kept per cache, one entry per account or per viewer and account pair
+	maxCachedAnswers = 10000
+)
+
+type cachedAnswer struct {
+	value   bool
+	expires time.Time
+}
+
+// VisibilityService decides whether a viewer may read the tweets of an account. Tweets of a
+// private account are only shown to the account itself and its followers. Privacy comes from
+// user_service and follows from follow_service, both answers are cached for a short while
+type VisibilityService struct {
+	cb      *gobreaker.CircuitBreaker
+	client  *http.Client
+	mutex   sync.Mutex
+	private map[string]cachedAnswer
+	follows map[string]cachedAnswer
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewVisibilityService(tracer trace.Tracer, logging *logrus.Logger) *VisibilityService {
+	return &VisibilityService{
+		cb:      CircuitBreaker(),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		private: make(map[string]cachedAnswer),
+		follows: make(map[string]cachedAnswer),
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+// CanView reports whether viewer may read the tweets of owner. viewer is empty for requests
+// without a user, token is the bearer token of the request and is needed for private owners
+func (service *VisibilityService) CanView(ctx context.Context, token string, viewer string, owner string) (bool, error) {
+	ctx, span := service.tracer.Start(ctx, "VisibilityService.CanView")
+	defer span.End()
+
+	if owner == "" || owner == viewer {
+		return true, nil
+	}
+
+	private, err := service.isPrivate(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+	if !private {
+		return true, nil
+	}
+	if viewer == "" {
+		return false, nil
+	}
+
+	return service.isFollowing(ctx, token, viewer, owner)
+}
+
+// CanViewTweet checks the author of the tweet and, for a retweet, the author of the original
+func (service *VisibilityService) CanViewTweet(ctx context.Context, token string, viewer string, tweet *domain.Tweet) (bool, error) {
+	visible, err := service.CanView(ctx, token, viewer, tweet.Username)
+	if err != nil || !visible {
+		return false, err
+	}
+
+	if tweet.OwnerUsername == "" || tweet.OwnerUsername == tweet.Username {
+		return true, nil
+	}
+	return service.CanView(ctx, token, viewer, tweet.OwnerUsername)
+}
+
+func (service *VisibilityService) isPrivate(ctx context.Context, username string) (bool, error) {
+	if answer, ok := service.cached(service.private, username); ok {
+		return answer, nil
+	}
+
+	endpoint := fmt.Sprintf("http://%s:%s/getOne/%s", userServiceHost, userServicePort, url.PathEscape(username))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := service.cb.Execute(func() (interface{}, error) {
+		response, err := service.client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		//tweets of accounts that no longer exist are not protected by anyone
+		if response.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("user service responded with %d", response.StatusCode)
+		}
+
+		var user struct {
+			Privacy bool `json:"privacy"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&user)
+		if err != nil {
+			return nil, err
+		}
+		return user.Privacy, nil
+	})
+	if err != nil {
+		service.logging.Errorf("VisibilityService.isPrivate : %s", err)
+		return false, fmt.Errorf(errors.VisibilityUnknown)
+	}
+
+	private := result.(bool)
+	service.store(service.private, username, private, privacyTTL)
+	return private, nil
+}
+
+func (service *VisibilityService) isFollowing(ctx context.Context, token string, viewer string, owner string) (bool, error) {
+	key := viewer + "|" + owner
+	if answer, ok := service.cached(service.follows, key); ok {
+		return answer, nil
+	}
+
+	endpoint := fmt.Sprintf("http://%s:%s/followExist/%s", followServiceHost, followServicePort, url.PathEscape(owner))
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Add("Authorization", token)
+
+	result, err := service.cb.Execute(func() (interface{}, error) {
+		response, err := service.client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("follow service responded with %d", response.StatusCode)
+		}
+
+		var follows bool
+		err = json.NewDecoder(response.Body).Decode(&follows)
+		if err != nil {
+			return nil, err
+		}
+		return follows, nil
+	})
+	if err != nil {
+		service.logging.Errorf("VisibilityService.isFollowing : %s", err)
+		return false, fmt.Errorf(errors.VisibilityUnknown)
+	}
+
+	follows := result.(bool)
+	service.store(service.follows, key, follows, followTTL)
+	return follows, nil
+}
+
+func (service *VisibilityService) cached(answers map[string]cachedAnswer, key string) (bool, bool) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	answer, ok := answers[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(answer.expires) {
+		delete(answers, key)
+		return false, false
+	}
+	return answer.value, true
+}
+
+func (service *VisibilityService) store(answers map[string]cachedAnswer, key string, value bool, ttl time.Duration) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	now := time.Now()
+	if _, ok := answers[key]; !ok && len(answers) >= maxCachedAnswers {
+		evict(answers, now)
+	}
+	answers[key] = cachedAnswer{value: value, expires: now.Add(ttl)}
+}
+
+// evict sweeps out the expired answers of a full cache, when all of them are still fresh
+// a tenth of the cache is dropped at random so the sweep does not run on every store
+func evict(answers map[string]cachedAnswer, now time.Time) {
+	for key, answer := range answers {
+		if now.After(answer.expires) {
+			delete(answers, key)
+		}
+	}
+
+	for key := range answers {
+		if len(answers) < maxCachedAnswers-maxCachedAnswers/10 {
+			return
+		}
+		delete(answers, key)
+	}
+}
@@ -0,0 +1,50 @@
+package application
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestVisibilityService() *VisibilityService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewVisibilityService(trace.NewNoopTracerProvider().Tracer("test"), logger)
+}
+
+func TestVisibilityCacheStaysBounded(t *testing.T) {
+	service := newTestVisibilityService()
+
+	for i := 0; i < 3*maxCachedAnswers; i++ {
+		service.store(service.follows, fmt.Sprintf("viewer%d|owner", i), true, followTTL)
+		if len(service.follows) > maxCachedAnswers {
+			t.Fatalf("cache holds %d answers after %d stores, want at most %d", len(service.follows), i+1, maxCachedAnswers)
+		}
+	}
+
+	last := fmt.Sprintf("viewer%d|owner", 3*maxCachedAnswers-1)
+	if answer, ok := service.cached(service.follows, last); !ok || !answer {
+		t.Errorf("the answer stored last was evicted")
+	}
+}
+
+func TestVisibilityCacheSweepsExpiredAnswersFirst(t *testing.T) {
+	service := newTestVisibilityService()
+
+	for i := 0; i < maxCachedAnswers-1; i++ {
+		service.store(service.private, fmt.Sprintf("expired%d", i), true, -time.Second)
+	}
+	service.store(service.private, "fresh", true, privacyTTL)
+	service.store(service.private, "new", false, privacyTTL)
+
+	if len(service.private) != 2 {
+		t.Errorf("cache holds %d answers, want only the fresh ones", len(service.private))
+	}
+	if answer, ok := service.cached(service.private, "fresh"); !ok || !answer {
+		t.Errorf("a fresh answer was evicted while expired ones were there")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 var jwtKey = []byte(os.Getenv("SECRET_KEY"))
@@ -85,3 +86,25 @@ func GetMapClaims(tokenBytes []byte) map[string]string {
 
 	return claims
 }
+
+// NewServiceToken signs a short lived token for calls made on behalf of a user outside
+// of one of their requests, like publishing their scheduled tweets
+func NewServiceToken(username string, userType string) (string, error) {
+	signer, err := jwt.NewSignerHS(jwt.HS256, jwtKey)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]string{
+		"username":   username,
+		"userType":   userType,
+		"expires_at": time.Now().Add(5 * time.Minute).Format(time.RFC3339),
+	}
+
+	token, err := jwt.NewBuilder(signer).Build(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return "Bearer " + token.String(), nil
+}
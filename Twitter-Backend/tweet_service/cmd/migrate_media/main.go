@@ -0,0 +1,45 @@
+// Command migrate_media moves media bytes that are still kept in Cassandra into
+// the media store configured for tweet_service. It reads the same environment as
+// the service and can be run again safely, rows that were moved are skipped.
+package main
+
+import (
+	"context"
+	"log"
+	"tweet_service/application"
+	"tweet_service/startup"
+	cfg "tweet_service/startup/config"
+	"tweet_service/store"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	config := cfg.NewConfig()
+	tracer := otel.Tracer("migrate_media")
+	logging := logrus.New()
+
+	tweetStore, err := store.New(log.Default(), tracer, logging)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tweetStore.CloseSession()
+
+	//makes sure the content_key columns exist
+	tweetStore.CreateTables()
+
+	mediaStore, err := startup.NewMediaStore(config, tracer)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mediaService := application.NewMediaService(tweetStore, mediaStore, tracer, logging)
+
+	migrated, err := tweetStore.MigrateMediaBlobs(context.Background(), mediaService.Put)
+	if err != nil {
+		log.Fatalf("media migration stopped after %d blobs: %s", migrated, err)
+	}
+
+	log.Printf("media migration finished, %d blobs moved", migrated)
+}
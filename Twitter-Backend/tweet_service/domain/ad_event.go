@@ -0,0 +1,23 @@
+package domain
+
+import (
+	events "github.com/zjalicf/twitter-clone-common/common/saga/create_event"
+)
+
+// AdEvent is an engagement with an ad sent to report_service, the shared saga event with
+// an ID report_service counts it once by. The embedded fields are encoded next to ID, so
+// the messages still decode as the shared types
+type AdEvent struct {
+	ID string
+	events.Event
+}
+
+type AdEventCommand struct {
+	Event AdEvent
+	Type  events.CreateEventCommandType
+}
+
+type AdEventReply struct {
+	Event AdEvent
+	Type  events.CreateEventReplyType
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
+
+// DefaultCollection holds every bookmark, named collections are optional subsets of it
+const DefaultCollection = ""
+
+type Bookmark struct {
+	TweetID    gocql.UUID `json:"tweet_id"`
+	Collection string     `json:"collection"`
+	CreatedAt  int64      `json:"created_at"`
+}
+
+type BookmarkCollection struct {
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// BookmarkPage is one page of bookmarked tweets, NextCursor is empty on the last page
+type BookmarkPage struct {
+	Tweets     []*Tweet `json:"tweets"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// BookmarkStore keeps bookmarks per user, they are never readable by anyone else
+type BookmarkStore interface {
+	SaveBookmark(ctx context.Context, username string, bookmark *Bookmark) error
+	GetBookmark(ctx context.Context, username string, tweetID gocql.UUID) (*Bookmark, error)
+	DeleteBookmark(ctx context.Context, username string, bookmark *Bookmark) error
+	GetBookmarkers(ctx context.Context, tweetID gocql.UUID) ([]string, error)
+	GetBookmarks(ctx context.Context, username string, collection string, limit int, cursor []byte) ([]*Bookmark, []byte, error)
+	SaveBookmarkCollection(ctx context.Context, username string, collection *BookmarkCollection) (bool, error)
+	GetBookmarkCollections(ctx context.Context, username string) ([]*BookmarkCollection, error)
+	DeleteBookmarkCollection(ctx context.Context, username string, name string) error
+}
@@ -0,0 +1,7 @@
+package domain
+
+// Engagement holds the favorite and retweet counters of a tweet
+type Engagement struct {
+	FavoriteCount int
+	RetweetCount  int
+}
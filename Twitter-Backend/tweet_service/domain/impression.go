@@ -0,0 +1,16 @@
+package domain
+
+// Impression is one ad of Advertiser served to Username in a feed, ID tells impressions
+// apart when the same ad is served to the same user more than once
+type Impression struct {
+	ID         string `json:"id"`
+	TweetID    string `json:"tweet_id"`
+	Advertiser string `json:"advertiser"`
+	Username   string `json:"username"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// ImpressionBatch carries the impressions collected since the previous batch
+type ImpressionBatch struct {
+	Impressions []Impression `json:"impressions"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"github.com/gocql/gocql"
+)
+
+type ShortLink struct {
+	Code      string     `json:"code"`
+	URL       string     `json:"url"`
+	TweetID   gocql.UUID `json:"tweet_id"`
+	Username  string     `json:"username"`
+	CreatedAt int64      `json:"created_at"`
+	Clicks    int64      `json:"clicks"`
+}
+
+// LinkPreview is the Open Graph card shown under a tweet, it is stored in the tweet row
+type LinkPreview struct {
+	URL         string `cql:"url" json:"url"`
+	Title       string `cql:"title" json:"title"`
+	Description string `cql:"description" json:"description"`
+	Image       string `cql:"image" json:"image"`
+}
+
+type LinkStore interface {
+	SaveShortLink(ctx context.Context, link *ShortLink) (bool, error)
+	GetShortLink(ctx context.Context, code string) (*ShortLink, error)
+	CountClick(ctx context.Context, code string) error
+	SaveLinkPreviews(ctx context.Context, tweet *Tweet, previews []LinkPreview) error
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"github.com/gocql/gocql"
+)
+
+type MediaType string
+
+const (
+	MediaImage MediaType = "image"
+	MediaGIF   MediaType = "gif"
+	MediaVideo MediaType = "video"
+)
+
+// ImageSize names a stored variant of an image, the original is the re-encoded upload
+type ImageSize string
+
+const (
+	ImageOriginal ImageSize = "orig"
+	ImageThumb    ImageSize = "thumb"
+	ImageSmall    ImageSize = "small"
+	ImageMedium   ImageSize = "medium"
+	ImageLarge    ImageSize = "large"
+)
+
+// Media is the metadata of a single attachment, a copy of it is stored in the tweet row
+type Media struct {
+	ID         gocql.UUID `cql:"id" json:"id"`
+	Type       MediaType  `cql:"type" json:"type"`
+	MimeType   string     `cql:"mime_type" json:"mime_type"`
+	Width      int        `cql:"width" json:"width"`
+	Height     int        `cql:"height" json:"height"`
+	DurationMs int64      `cql:"duration_ms" json:"duration_ms,omitempty"`
+	AltText    string     `cql:"alt_text" json:"alt_text"`
+	Size       int64      `cql:"size" json:"size"`
+	TweetID    gocql.UUID `cql:"tweet_id" json:"-"`
+}
+
+// MediaUpload is a file received with a new tweet, before it is validated
+type MediaUpload struct {
+	Data     []byte
+	AltText  string
+	Variants map[ImageSize][]byte
+}
+
+// MediaMetadataStore keeps the metadata of media and the keys of their bytes in the MediaStore
+type MediaMetadataStore interface {
+	SaveMedia(ctx context.Context, tweet *Tweet, media *Media, key string) error
+	GetMedia(ctx context.Context, id string) (*Media, *BlobRef, error)
+	SaveMediaVariant(ctx context.Context, mediaID gocql.UUID, size ImageSize, key string) error
+	GetMediaVariant(ctx context.Context, mediaID string, size ImageSize) (*BlobRef, error)
+}
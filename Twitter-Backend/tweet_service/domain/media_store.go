@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// MediaStore keeps media bytes outside of Cassandra. Keys are derived from the
+// content, so the same bytes are stored once no matter how many tweets use them
+type MediaStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// BlobRef points at the bytes of a media item. Rows written before media moved
+// to a MediaStore still carry their bytes in Data until they are migrated
+type BlobRef struct {
+	Key  string
+	Data []byte
+}
+
+func ContentKey(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
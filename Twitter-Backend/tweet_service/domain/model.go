@@ -5,25 +5,35 @@ import (
 )
 
 type Tweet struct {
-	ID            gocql.UUID `json:"id"`
-	Text          string     `json:"text"`
-	CreatedAt     int64      `json:"created_on"`
-	Favorited     bool       `json:"favorited"`
-	FavoriteCount int        `json:"favorite_count"`
-	Retweeted     bool       `json:"retweeted"`
-	RetweetCount  int        `json:"retweet_count"`
-	Username      string     `json:"username"`
-	OwnerUsername string     `json:"owner_username"`
-	Image         bool       `json:"image"`
-	Advertisement bool       `json:"advertisement"`
+	ID            gocql.UUID    `json:"id"`
+	Text          string        `json:"text"`
+	CreatedAt     int64         `json:"created_on"`
+	Favorited     bool          `json:"favorited"`
+	FavoriteCount int           `json:"favorite_count"`
+	Retweeted     bool          `json:"retweeted"`
+	RetweetCount  int           `json:"retweet_count"`
+	Username      string        `json:"username"`
+	OwnerUsername string        `json:"owner_username"`
+	RetweetOf     *gocql.UUID   `json:"retweet_of,omitempty"`
+	Image         bool          `json:"image"`
+	Advertisement bool          `json:"advertisement"`
+	Hidden        bool          `json:"-"`
+	Previews      []LinkPreview `json:"previews"`
+	Media         []Media       `json:"media"`
+	Poll          *Poll         `json:"poll,omitempty"`
 }
 
 type AdConfig struct {
-	TweetID   string `json:"tweet_id"`
-	Residence string `json:"residence"`
-	Gender    string `json:"gender"`
-	AgeFrom   int    `json:"age_from"`
-	AgeTo     int    `json:"age_to"`
+	TweetID          string   `json:"tweet_id"`
+	CampaignID       string   `json:"campaign_id"`
+	Residence        string   `json:"residence"`
+	Gender           string   `json:"gender"`
+	AgeFrom          int      `json:"age_from"`
+	AgeTo            int      `json:"age_to"`
+	FollowersOf      []string `json:"followers_of"`
+	Hashtags         []string `json:"hashtags"`
+	ExcludeFollowers bool     `json:"exclude_followers"`
+	FrequencyCap     int      `json:"frequency_cap"`
 }
 
 type AdTweet struct {
@@ -59,8 +69,15 @@ type Event struct {
 }
 
 type FeedInfo struct {
-	Usernames []string `json:"usernames"`
-	AdIds     []string `json:"ad_ids"`
+	Usernames    []string     `json:"usernames"`
+	AdIds        []string     `json:"ad_ids"`
+	SecondDegree []Connection `json:"second_degree,omitempty"`
+}
+
+// Connection is a user followed by the viewer's followings, Mutual counts the followings in between
+type Connection struct {
+	Username string `json:"username"`
+	Mutual   int    `json:"mutual"`
 }
 
 type FeedData struct {
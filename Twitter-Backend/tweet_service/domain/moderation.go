@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"github.com/gocql/gocql"
+)
+
+type ModerationVerdict string
+
+const (
+	VerdictAllow  ModerationVerdict = "allow"
+	VerdictHold   ModerationVerdict = "hold"
+	VerdictReject ModerationVerdict = "reject"
+)
+
+// Severity orders verdicts so the strictest stage wins, an unknown verdict counts as a reject
+func (verdict ModerationVerdict) Severity() int {
+	switch verdict {
+	case VerdictAllow:
+		return 0
+	case VerdictHold:
+		return 1
+	}
+	return 2
+}
+
+func (verdict ModerationVerdict) Valid() bool {
+	return verdict == VerdictAllow || verdict == VerdictHold || verdict == VerdictReject
+}
+
+type ModerationResult struct {
+	Verdict ModerationVerdict `json:"verdict"`
+	Reason  string            `json:"reason"`
+	Stage   string            `json:"stage"`
+}
+
+// ModerationRecord is kept for every tweet that was held or rejected, so
+// moderators can audit false positives
+type ModerationRecord struct {
+	TweetID   gocql.UUID        `json:"tweet_id"`
+	Username  string            `json:"username"`
+	Text      string            `json:"text"`
+	Verdict   ModerationVerdict `json:"verdict"`
+	Reason    string            `json:"reason"`
+	Stage     string            `json:"stage"`
+	CreatedAt int64             `json:"created_at"`
+}
+
+// TextModerator is a single stage of the moderation pipeline run before a tweet is saved
+type TextModerator interface {
+	Name() string
+	Moderate(ctx context.Context, tweet *Tweet) (*ModerationResult, error)
+}
+
+type ModerationPattern struct {
+	Pattern string            `json:"pattern"`
+	Verdict ModerationVerdict `json:"verdict"`
+	Reason  string            `json:"reason"`
+}
+
+type ModerationRules struct {
+	BlockedWords  []string            `json:"blocked_words"`
+	HeldWords     []string            `json:"held_words"`
+	Patterns      []ModerationPattern `json:"patterns"`
+	DeniedDomains []string            `json:"denied_domains"`
+	Spam          SpamRules           `json:"spam"`
+}
+
+type SpamRules struct {
+	WindowSeconds int               `json:"window_seconds"`
+	MaxRepeats    int               `json:"max_repeats"`
+	Verdict       ModerationVerdict `json:"verdict"`
+}
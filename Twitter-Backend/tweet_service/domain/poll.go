@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+// Poll is attached to a tweet when it is posted. Votes are only filled in for a viewer
+// who voted and for everyone once the poll is closed
+type Poll struct {
+	Options    []PollOption `json:"options"`
+	ClosesAt   int64        `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	Vote       *int         `json:"vote,omitempty"`
+	TotalVotes *int         `json:"total_votes,omitempty"`
+}
+
+type PollVote struct {
+	Option int `json:"option"`
+}
+
+// PollStore keeps one vote per user and poll with a lightweight transaction, tallies are
+// counters that are only incremented by votes that were applied
+type PollStore interface {
+	SavePoll(ctx context.Context, tweetID gocql.UUID, poll *Poll) error
+	GetPolls(ctx context.Context, tweetIDs []gocql.UUID) (map[gocql.UUID]*Poll, error)
+	SaveVote(ctx context.Context, tweetID gocql.UUID, username string, option int) (bool, error)
+	GetVotes(ctx context.Context, tweetIDs []gocql.UUID, username string) (map[gocql.UUID]int, error)
+	GetTallies(ctx context.Context, tweetIDs []gocql.UUID) (map[gocql.UUID]map[int]int, error)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type FeedMode string
+
+const (
+	FeedChronological FeedMode = "chronological"
+	FeedRanked        FeedMode = "ranked"
+)
+
+// RankingWeights decide how much each signal contributes to the score of a tweet in
+// the ranked feed. Every signal is scaled to [0, 1] before it is weighted
+type RankingWeights struct {
+	Engagement float64
+	Recency    float64
+	Affinity   float64
+	//age at which the recency signal drops to one half
+	HalfLife time.Duration
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleDraft      ScheduleStatus = "draft"
+	ScheduleScheduled  ScheduleStatus = "scheduled"
+	SchedulePublishing ScheduleStatus = "publishing"
+	SchedulePublished  ScheduleStatus = "published"
+	ScheduleFailed     ScheduleStatus = "failed"
+)
+
+// ScheduledTweet is a draft or a tweet waiting to be published. ID is the id the tweet
+// gets once it is published, so ad configs can be saved for it in advance. Attempts counts
+// the claims and Links keeps the short link codes of a publish that has to be tried again
+type ScheduledTweet struct {
+	ID            gocql.UUID        `json:"id"`
+	Username      string            `json:"username"`
+	Text          string            `json:"text"`
+	Advertisement bool              `json:"advertisement"`
+	PublishAt     int64             `json:"publish_at,omitempty"`
+	Status        ScheduleStatus    `json:"status"`
+	Error         string            `json:"error,omitempty"`
+	CreatedAt     int64             `json:"created_at"`
+	UpdatedAt     int64             `json:"updated_at"`
+	UserType      string            `json:"-"`
+	ClaimedBy     string            `json:"-"`
+	ClaimedAt     int64             `json:"-"`
+	Attempts      int               `json:"-"`
+	Links         map[string]string `json:"-"`
+}
+
+// DueEntry points the scheduler at a scheduled tweet, entries that no longer match
+// their tweet are dropped when they are read
+type DueEntry struct {
+	Username  string
+	ID        gocql.UUID
+	PublishAt int64
+}
+
+// ScheduleStore keeps drafts and scheduled tweets. Changes of scheduled tweets are
+// lightweight transactions on status and publish_at, so only one replica can claim
+// a tweet and an edit can't overwrite a claim
+type ScheduleStore interface {
+	SaveScheduled(ctx context.Context, item *ScheduledTweet) error
+	GetScheduled(ctx context.Context, username string, id gocql.UUID) (*ScheduledTweet, error)
+	GetScheduledByUser(ctx context.Context, username string) ([]*ScheduledTweet, error)
+	UpdateScheduled(ctx context.Context, item *ScheduledTweet, status ScheduleStatus, publishAt int64) (bool, error)
+	DeleteScheduled(ctx context.Context, item *ScheduledTweet) (bool, error)
+	ClaimScheduled(ctx context.Context, item *ScheduledTweet, replica string, now int64) (bool, error)
+	CompleteScheduled(ctx context.Context, item *ScheduledTweet, replica string, status ScheduleStatus, reason string) error
+	SaveScheduledLinks(ctx context.Context, item *ScheduledTweet, replica string) (bool, error)
+	AddDue(ctx context.Context, entry *DueEntry) error
+	RemoveDue(ctx context.Context, entry *DueEntry) error
+	GetDue(ctx context.Context, before int64, limit int) ([]*DueEntry, error)
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "context"
+
+type FollowEventType string
+
+const (
+	Followed   FollowEventType = "Followed"
+	Unfollowed FollowEventType = "Unfollowed"
+)
+
+// FollowEvent is published by follow_service when a follow is created or removed
+type FollowEvent struct {
+	Follower  string          `json:"follower"`
+	Followee  string          `json:"followee"`
+	Type      FollowEventType `json:"type"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// Celebrity is an author whose tweets are read at feed time, CheckedAt is the unix time the
+// follower count was last compared with the threshold
+type Celebrity struct {
+	Username  string
+	Followers int
+	CheckedAt int64
+}
+
+// TimelineStore keeps a partition of tweet ids per user, filled when tweets are posted.
+// Authors with too many followers are marked as celebrities and read at feed time instead
+type TimelineStore interface {
+	AddToTimelines(ctx context.Context, usernames []string, tweets []*Tweet) error
+	RemoveFromTimeline(ctx context.Context, username string, author string) error
+	GetTimeline(ctx context.Context, username string, limit int) ([]*Tweet, error)
+	IsTimelineBuilt(ctx context.Context, username string) (bool, error)
+	MarkTimelineBuilt(ctx context.Context, username string) error
+	IsCelebrity(ctx context.Context, username string) (bool, error)
+	GetCelebrity(ctx context.Context, username string) (*Celebrity, error)
+	SetCelebrity(ctx context.Context, username string, followers int) error
+	ClearCelebrity(ctx context.Context, username string) error
+	GetCelebrities(ctx context.Context, usernames []string) ([]string, error)
+}
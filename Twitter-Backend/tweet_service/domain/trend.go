@@ -0,0 +1,32 @@
+package domain
+
+type TweetEventType string
+
+const (
+	TweetPosted    TweetEventType = "Posted"
+	TweetFavorited TweetEventType = "Favorited"
+	TweetRetweeted TweetEventType = "Retweeted"
+)
+
+// TweetEvent is published for every tweet, favorite and retweet. Username is the user who
+// acted, Text is left empty when the consumer has to read the tweet itself. Hashtags are
+// those of Text, follow_service keeps them as interests of the user for ad targeting
+type TweetEvent struct {
+	Type      TweetEventType `json:"type"`
+	TweetID   string         `json:"tweet_id"`
+	Username  string         `json:"username"`
+	Owner     string         `json:"owner,omitempty"`
+	Text      string         `json:"text,omitempty"`
+	Hashtags  []string       `json:"hashtags,omitempty"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// Trend is a hashtag or a term that is used more than usual. Count is the number of uses
+// in the current window and Baseline the usual number of uses in a window of the same length
+type Trend struct {
+	Term     string  `json:"term"`
+	Hashtag  bool    `json:"hashtag"`
+	Count    int     `json:"count"`
+	Baseline float64 `json:"baseline"`
+	Velocity float64 `json:"velocity"`
+}
@@ -1,8 +1,22 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type TweetCache interface {
 	PostCacheData(ctx context.Context, key string, value *[]byte) error
 	GetCachedValue(ctx context.Context, key string) (*[]byte, error)
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	GetCount(ctx context.Context, key string) (int64, error)
+	IncrementScore(ctx context.Context, key string, member string, by float64, expiration time.Duration) error
+	TopMembers(ctx context.Context, keys []string, limit int) ([]ScoredMember, error)
+	MemberScores(ctx context.Context, keys []string, members []string) (map[string]float64, error)
+}
+
+// ScoredMember is an entry of a sorted set
+type ScoredMember struct {
+	Member string
+	Score  float64
 }
@@ -14,8 +14,16 @@ type TweetStore interface {
 	Post(ctx context.Context, tweet *Tweet) (*Tweet, error)
 	Favorite(ctx context.Context, id string, username string) (int, error)
 	GetLikesByTweet(ctx context.Context, tweetID string) ([]*Favorite, error)
+	GetRetweetsByTweet(ctx context.Context, tweetID string) ([]*Retweet, error)
 	Retweet(ctx context.Context, tweetID string, username string) (*gocql.UUID, int, error)
-	SaveImage(ctx context.Context, tweetID gocql.UUID, imageBytes []byte) error
-	GetTweetImage(ctx context.Context, id string) ([]byte, error)
+	GetEngagement(ctx context.Context, ids []gocql.UUID) (map[gocql.UUID]*Engagement, error)
+	GetFavorited(ctx context.Context, ids []gocql.UUID, username string) (map[gocql.UUID]bool, error)
+	GetRetweeted(ctx context.Context, ids []gocql.UUID, username string) (map[gocql.UUID]bool, error)
+	SaveImage(ctx context.Context, tweetID gocql.UUID, key string) error
+	GetTweetImage(ctx context.Context, id string) (*BlobRef, error)
 	GetOne(ctx context.Context, tweetID string) (*Tweet, error)
+	GetTweetsByIds(ctx context.Context, ids []gocql.UUID) ([]*Tweet, error)
+	SetHidden(ctx context.Context, tweetID string, hidden bool) error
+	SaveModerationRecord(ctx context.Context, record *ModerationRecord) error
+	GetModerationRecords(ctx context.Context, verdict ModerationVerdict) ([]*ModerationRecord, error)
 }
@@ -2,4 +2,33 @@ package errors
 
 const (
 	RetweetAlreadyExist = "you have already retweeted"
+	TweetNotFound       = "tweet not found"
+	TweetRejected       = "tweet rejected by moderation"
+	LinkNotFound        = "link not found"
+	MediaNotFound       = "media not found"
+	UnsupportedMedia    = "unsupported media type"
+	TooManyMedia        = "a tweet can have up to four images or one video or GIF"
+	MediaTooLarge       = "media file is too large"
+	VideoTooLong        = "video is too long"
+	InvalidImageSize    = "size must be one of orig, thumb, small, medium, large"
+	NotLinkOwner        = "only the author of the tweet can see link stats"
+	InvalidFeedMode     = "mode must be chronological or ranked"
+	BookmarkNotFound    = "bookmark not found"
+	CollectionNotFound  = "bookmark collection not found"
+	CollectionExists    = "bookmark collection already exists"
+	InvalidCollection   = "collection name must have between 1 and 25 characters"
+	InvalidCursor       = "invalid cursor"
+	ListNotFound        = "list not found"
+	ScheduledNotFound   = "scheduled tweet not found"
+	ScheduleConflict    = "scheduled tweet is being published or was changed, read it again"
+	PublishFailed       = "scheduled tweet could not be published"
+	InvalidPublishTime  = "publish_at must be in the future and at most a year away"
+	EmptyTweet          = "tweet text is empty"
+	InvalidPoll         = "a poll needs two to four options of at most 25 characters and must close between five minutes and seven days from now"
+	PollNotFound        = "poll not found"
+	PollClosed          = "poll is closed"
+	InvalidPollOption   = "poll has no such option"
+	AlreadyVoted        = "you have already voted in this poll"
+	ProtectedAccount    = "this account is private, follow it to see its tweets"
+	VisibilityUnknown   = "could not check who can see this account, try again later"
 )
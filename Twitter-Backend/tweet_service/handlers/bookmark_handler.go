@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tweet_service/application"
+	"tweet_service/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type BookmarkHandler struct {
+	service *application.BookmarkService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewBookmarkHandler(service *application.BookmarkService, tracer trace.Tracer, logging *logrus.Logger) *BookmarkHandler {
+	return &BookmarkHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *BookmarkHandler) Init(router *mux.Router) {
+	router.HandleFunc("/bookmarks", handler.List).Methods("GET")
+	router.HandleFunc("/bookmarks", handler.Add).Methods("POST")
+	router.HandleFunc("/bookmarks/collections", handler.GetCollections).Methods("GET")
+	router.HandleFunc("/bookmarks/collections", handler.CreateCollection).Methods("POST")
+	router.HandleFunc("/bookmarks/collections/{name}", handler.DeleteCollection).Methods("DELETE")
+	router.HandleFunc("/bookmarks/{tweetId}", handler.Remove).Methods("DELETE")
+}
+
+func (handler *BookmarkHandler) Add(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.Add")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.Add : add bookmark endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		TweetID    string `json:"tweet_id"`
+		Collection string `json:"collection"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bookmark, err := handler.service.Add(ctx, req.Header.Get("Authorization"), username, request.TweetID, request.Collection)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(bookmark, writer)
+}
+
+func (handler *BookmarkHandler) Remove(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.Remove")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.Remove : remove bookmark endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := handler.service.Remove(ctx, username, mux.Vars(req)["tweetId"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// List serves the bookmarks of the user in the token, ?collection= narrows them to one collection
+// and ?cursor= takes the next_cursor of the previous page
+func (handler *BookmarkHandler) List(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.List")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.List : list bookmarks endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	query := req.URL.Query()
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > 100 {
+			http.Error(writer, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := handler.service.List(ctx, req.Header.Get("Authorization"), username, query.Get("collection"), limit, query.Get("cursor"))
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(page, writer)
+}
+
+func (handler *BookmarkHandler) GetCollections(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.GetCollections")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.GetCollections : get collections endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	collections, err := handler.service.GetCollections(ctx, username)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(collections, writer)
+}
+
+func (handler *BookmarkHandler) CreateCollection(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.CreateCollection")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.CreateCollection : create collection endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection, err := handler.service.CreateCollection(ctx, username, request.Name)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(collection, writer)
+}
+
+func (handler *BookmarkHandler) DeleteCollection(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "BookmarkHandler.DeleteCollection")
+	defer span.End()
+
+	handler.logging.Infoln("bookmarkHandler.DeleteCollection : delete collection endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := handler.service.DeleteCollection(ctx, username, mux.Vars(req)["name"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *BookmarkHandler) writeError(writer http.ResponseWriter, err error) {
+	switch err.Error() {
+	case errors.TweetNotFound, errors.BookmarkNotFound, errors.CollectionNotFound:
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.CollectionExists:
+		http.Error(writer, err.Error(), http.StatusConflict)
+	case errors.InvalidCollection, errors.InvalidCursor:
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	case errors.VisibilityUnknown:
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	default:
+		handler.logging.Errorf("bookmarkHandler : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"tweet_service/application"
+	"tweet_service/domain"
+
+	"github.com/sirupsen/logrus"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FollowEventHandler keeps timelines in sync with the follow graph
+type FollowEventHandler struct {
+	timelines  *application.TimelineService
+	subscriber saga.Subscriber
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewFollowEventHandler(timelines *application.TimelineService, subscriber saga.Subscriber, tracer trace.Tracer, logging *logrus.Logger) (*FollowEventHandler, error) {
+	handler := &FollowEventHandler{
+		timelines:  timelines,
+		subscriber: subscriber,
+		tracer:     tracer,
+		logging:    logging,
+	}
+
+	err := handler.subscriber.Subscribe(handler.handle)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func (handler *FollowEventHandler) handle(event *domain.FollowEvent) {
+	ctx, span := handler.tracer.Start(context.Background(), "FollowEventHandler.handle")
+	defer span.End()
+
+	err := handler.timelines.HandleFollowEvent(ctx, event)
+	if err != nil {
+		handler.logging.Errorf("FollowEventHandler.handle : %s", err)
+	}
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+	"tweet_service/authorization"
 )
 
 func jsonResponse(object interface{}, w http.ResponseWriter) {
@@ -19,3 +21,19 @@ func jsonResponse(object interface{}, w http.ResponseWriter) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// usernameFromToken returns the username in the bearer token of the request
+func usernameFromToken(req *http.Request) (string, bool) {
+	bearerToken := strings.Split(req.Header.Get("Authorization"), "Bearer ")
+	if len(bearerToken) < 2 {
+		return "", false
+	}
+	token := authorization.GetToken(bearerToken[1])
+	if token == nil {
+		return "", false
+	}
+	claims := authorization.GetMapClaims(token.Bytes())
+
+	username := claims["username"]
+	return username, username != ""
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"tweet_service/application"
+	"tweet_service/authorization"
+	"tweet_service/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type LinkHandler struct {
+	service *application.LinkService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewLinkHandler(service *application.LinkService, tracer trace.Tracer, logging *logrus.Logger) *LinkHandler {
+	return &LinkHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *LinkHandler) Init(router *mux.Router) {
+	router.HandleFunc("/l/{code}", handler.Redirect).Methods("GET")
+	router.HandleFunc("/links/{code}", handler.GetStats).Methods("GET")
+}
+
+func (handler *LinkHandler) Redirect(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "LinkHandler.Redirect")
+	defer span.End()
+
+	handler.logging.Infoln("linkHandler.Redirect : redirect endpoint reached")
+
+	vars := mux.Vars(req)
+	link, err := handler.service.Resolve(ctx, vars["code"])
+	if err != nil {
+		if err.Error() == errors.LinkNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(writer, req, link, http.StatusFound)
+}
+
+func (handler *LinkHandler) GetStats(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "LinkHandler.GetStats")
+	defer span.End()
+
+	handler.logging.Infoln("linkHandler.GetStats : link stats endpoint reached")
+
+	bearerToken := strings.Split(req.Header.Get("Authorization"), "Bearer ")
+	if len(bearerToken) < 2 {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	token := authorization.GetToken(bearerToken[1])
+	if token == nil {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	claims := authorization.GetMapClaims(token.Bytes())
+
+	vars := mux.Vars(req)
+	link, err := handler.service.GetStats(ctx, vars["code"], claims["username"])
+	if err != nil {
+		switch err.Error() {
+		case errors.LinkNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.NotLinkOwner:
+			http.Error(writer, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(link, writer)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"tweet_service/application"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PollHandler takes the votes on polls, polls are created with their tweet and their results
+// are served with it. Votes go through the tweet service, which checks the tweet can be read
+type PollHandler struct {
+	service *application.TweetService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewPollHandler(service *application.TweetService, tracer trace.Tracer, logging *logrus.Logger) *PollHandler {
+	return &PollHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *PollHandler) Init(router *mux.Router) {
+	router.HandleFunc("/poll/{id}/vote", handler.Vote).Methods("POST")
+}
+
+// Vote records the vote of the user for the option with the given index and returns the results
+func (handler *PollHandler) Vote(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "PollHandler.Vote")
+	defer span.End()
+
+	handler.logging.Infoln("pollHandler.Vote : vote endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var vote domain.PollVote
+	err := json.NewDecoder(req.Body).Decode(&vote)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	poll, err := handler.service.Vote(ctx, req.Header.Get("Authorization"), username, mux.Vars(req)["id"], vote.Option)
+	if err != nil {
+		switch err.Error() {
+		case errors.PollNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.PollClosed, errors.AlreadyVoted:
+			http.Error(writer, err.Error(), http.StatusConflict)
+		case errors.InvalidPollOption:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.VisibilityUnknown:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			handler.logging.Errorf("pollHandler.Vote : %s", err)
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(poll, writer)
+}
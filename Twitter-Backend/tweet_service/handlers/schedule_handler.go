@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"tweet_service/application"
+	"tweet_service/authorization"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScheduleHandler serves drafts and scheduled tweets. A scheduled ad gets its tweet id when it
+// is scheduled, its config is posted to follow_service with that id like for a posted ad
+type ScheduleHandler struct {
+	service *application.ScheduleService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewScheduleHandler(service *application.ScheduleService, tracer trace.Tracer, logging *logrus.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *ScheduleHandler) Init(router *mux.Router) {
+	router.HandleFunc("/scheduled", handler.List).Methods("GET")
+	router.HandleFunc("/scheduled", handler.Create).Methods("POST")
+	router.HandleFunc("/scheduled/{id}", handler.Get).Methods("GET")
+	router.HandleFunc("/scheduled/{id}", handler.Edit).Methods("PUT")
+	router.HandleFunc("/scheduled/{id}", handler.Delete).Methods("DELETE")
+	router.HandleFunc("/scheduled/{id}/cancel", handler.Cancel).Methods("PUT")
+}
+
+// Create saves a draft, or schedules the tweet when publish_at is set
+func (handler *ScheduleHandler) Create(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.Create")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.Create : create scheduled endpoint reached")
+
+	bearerToken := strings.Split(req.Header.Get("Authorization"), "Bearer ")
+	if len(bearerToken) < 2 {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	token := authorization.GetToken(bearerToken[1])
+	if token == nil {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	claims := authorization.GetMapClaims(token.Bytes())
+
+	var request domain.ScheduledTweet
+	err := json.NewDecoder(req.Body).Decode(&request)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := handler.service.Create(ctx, claims["username"], claims["userType"], &request)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+	jsonResponse(item, writer)
+}
+
+// List serves the drafts and scheduled tweets of the user, ?status= narrows them down
+func (handler *ScheduleHandler) List(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.List")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.List : list scheduled endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	items, err := handler.service.List(ctx, username, domain.ScheduleStatus(req.URL.Query().Get("status")))
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(items, writer)
+}
+
+func (handler *ScheduleHandler) Get(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.Get")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.Get : get scheduled endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	item, err := handler.service.Get(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(item, writer)
+}
+
+func (handler *ScheduleHandler) Edit(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.Edit")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.Edit : edit scheduled endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update domain.ScheduledTweet
+	err := json.NewDecoder(req.Body).Decode(&update)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := handler.service.Edit(ctx, username, mux.Vars(req)["id"], &update)
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(item, writer)
+}
+
+func (handler *ScheduleHandler) Cancel(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.Cancel")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.Cancel : cancel scheduled endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	item, err := handler.service.Cancel(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	jsonResponse(item, writer)
+}
+
+func (handler *ScheduleHandler) Delete(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "ScheduleHandler.Delete")
+	defer span.End()
+
+	handler.logging.Infoln("scheduleHandler.Delete : delete scheduled endpoint reached")
+
+	username, ok := usernameFromToken(req)
+	if !ok {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := handler.service.Delete(ctx, username, mux.Vars(req)["id"])
+	if err != nil {
+		handler.writeError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *ScheduleHandler) writeError(writer http.ResponseWriter, err error) {
+	switch err.Error() {
+	case errors.ScheduledNotFound:
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.ScheduleConflict:
+		http.Error(writer, err.Error(), http.StatusConflict)
+	case errors.EmptyTweet, errors.InvalidPublishTime:
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+	default:
+		handler.logging.Errorf("scheduleHandler : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
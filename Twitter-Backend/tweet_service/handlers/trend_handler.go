@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"tweet_service/application"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxTrendLimit = 50
+
+type TrendHandler struct {
+	service *application.TrendService
+	tracer  trace.Tracer
+	logging *logrus.Logger
+}
+
+func NewTrendHandler(service *application.TrendService, tracer trace.Tracer, logging *logrus.Logger) *TrendHandler {
+	return &TrendHandler{
+		service: service,
+		tracer:  tracer,
+		logging: logging,
+	}
+}
+
+func (handler *TrendHandler) Init(router *mux.Router) {
+	router.HandleFunc("/trends", handler.GetTrends).Methods("GET")
+}
+
+// GetTrends serves the global trends, or the trends of a residence when ?residence= is set
+func (handler *TrendHandler) GetTrends(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TrendHandler.GetTrends")
+	defer span.End()
+
+	handler.logging.Infoln("trendHandler.GetTrends : trends endpoint reached")
+
+	limit := 0
+	if value := req.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxTrendLimit {
+			http.Error(writer, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+	}
+
+	trends, err := handler.service.GetTrends(ctx, req.URL.Query().Get("residence"), limit)
+	if err != nil {
+		handler.logging.Errorf("trendHandler.GetTrends : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(trends, writer)
+}
@@ -1,16 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 	"tweet_service/application"
 	"tweet_service/authorization"
 	"tweet_service/domain"
+	"tweet_service/errors"
 
 	"github.com/casbin/casbin"
 	"github.com/cristalhq/jwt/v4"
@@ -27,6 +29,8 @@ type TweetHandler struct {
 	logging *logrus.Logger
 }
 
+const maxUploadSize = 33 << 20
+
 var jwtKey = []byte(os.Getenv("SECRET_KEY"))
 var verifier, _ = jwt.NewVerifierHS(jwt.HS256, jwtKey)
 
@@ -49,15 +53,20 @@ func (handler *TweetHandler) Init(router *mux.Router) {
 	router.HandleFunc("/", handler.GetAll).Methods("GET")
 	router.HandleFunc("/getOneTweet/{id}", handler.GetOne).Methods("GET")
 	router.HandleFunc("/", Post(handler)).Methods("POST")
-	router.HandleFunc("/", handler.GetAll).Methods("GET")
 	router.HandleFunc("/image/{id}", handler.GetTweetImage).Methods("GET")
+	router.HandleFunc("/media/{id}", handler.GetMedia).Methods("GET")
 	router.HandleFunc("/favorite", handler.Favorite).Methods("POST")
 	router.HandleFunc("/user/{username}", handler.GetTweetsByUser).Methods("GET")
 	router.HandleFunc("/whoLiked/{id}", handler.GetLikesByTweet).Methods("GET")
+	router.HandleFunc("/whoRetweeted/{id}", handler.GetRetweetsByTweet).Methods("GET")
 	router.HandleFunc("/feed", handler.GetFeedByUser).Methods("GET")
+	router.HandleFunc("/lists/{id}/feed", handler.GetListFeed).Methods("GET")
 	router.HandleFunc("/retweet", handler.Retweet).Methods("POST")
 	router.HandleFunc("/timespent", handler.TimespentOnAd).Methods("POST")
 	router.HandleFunc("/viewCount", handler.ViewProfileFromAdd).Methods("POST")
+	router.HandleFunc("/moderation/hide/{id}", handler.Hide).Methods("PUT")
+	router.HandleFunc("/moderation/release/{id}", handler.Release).Methods("PUT")
+	router.HandleFunc("/moderation/records/{verdict}", handler.GetModerationRecords).Methods("GET")
 
 	http.Handle("/", router)
 	log.Println("Successful")
@@ -70,8 +79,14 @@ func (handler *TweetHandler) GetAll(writer http.ResponseWriter, req *http.Reques
 
 	handler.logging.Infoln("tweetHandler.GetAll : getAll endpoint reached")
 
-	tweets, err := handler.service.GetAll(ctx)
+	viewer, _ := usernameFromToken(req)
+
+	tweets, err := handler.service.GetAll(ctx, req.Header.Get("Authorization"), viewer)
 	if err != nil {
+		if err.Error() == errors.VisibilityUnknown {
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -87,14 +102,52 @@ func (handler *TweetHandler) GetOne(writer http.ResponseWriter, req *http.Reques
 	vars := mux.Vars(req)
 	tweetID := vars["id"]
 
-	tweets, err := handler.service.GetOne(ctx, tweetID)
+	//requests without a user only see tweets of public accounts and no poll results
+	viewer, _ := usernameFromToken(req)
+
+	tweets, err := handler.service.GetOne(ctx, req.Header.Get("Authorization"), viewer, tweetID)
 	if err != nil {
+		if err.Error() == errors.TweetNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err.Error() == errors.VisibilityUnknown {
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	jsonResponse(tweets, writer)
 }
 
+func (handler *TweetHandler) Hide(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.Hide")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.Hide : hide endpoint reached")
+
+	vars := mux.Vars(req)
+	tweetID, ok := vars["id"]
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := handler.service.Hide(ctx, tweetID)
+	if err != nil {
+		handler.logging.Errorf("tweetHandler.Hide : %s", err)
+		if err.Error() == errors.TweetNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
 func (handler *TweetHandler) GetTweetsByUser(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetTweetsByUser")
 	defer span.End()
@@ -109,9 +162,11 @@ func (handler *TweetHandler) GetTweetsByUser(writer http.ResponseWriter, req *ht
 		return
 	}
 
-	tweets, err := handler.service.GetTweetsByUser(ctx, username)
+	viewer, _ := usernameFromToken(req)
+
+	tweets, err := handler.service.GetTweetsByUser(ctx, req.Header.Get("Authorization"), viewer, username)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		handler.writeVisibilityError(writer, err)
 		return
 	}
 
@@ -131,14 +186,39 @@ func (handler *TweetHandler) GetLikesByTweet(writer http.ResponseWriter, req *ht
 		return
 	}
 
-	favorites, err := handler.service.GetLikesByTweet(ctx, tweetID)
+	viewer, _ := usernameFromToken(req)
+
+	favorites, err := handler.service.GetLikesByTweet(ctx, req.Header.Get("Authorization"), viewer, tweetID)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		handler.writeVisibilityError(writer, err)
 		return
 	}
 	jsonResponse(favorites, writer)
 }
 
+func (handler *TweetHandler) GetRetweetsByTweet(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetRetweetsByTweet")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.retweetsByTweet : retweetsByTweet endpoint reached")
+
+	vars := mux.Vars(req)
+	tweetID, ok := vars["id"]
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	viewer, _ := usernameFromToken(req)
+
+	retweets, err := handler.service.GetRetweetsByTweet(ctx, req.Header.Get("Authorization"), viewer, tweetID)
+	if err != nil {
+		handler.writeVisibilityError(writer, err)
+		return
+	}
+	jsonResponse(retweets, writer)
+}
+
 func (handler *TweetHandler) Favorite(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.Favorite")
 	defer span.End()
@@ -169,10 +249,18 @@ func (handler *TweetHandler) Favorite(writer http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	tweets, err := handler.service.Favorite(ctx, tweet.ID.String(), username, tweet.Advertisement)
+	tweets, err := handler.service.Favorite(ctx, bearer, tweet.ID.String(), username, tweet.Advertisement)
 
 	if err != nil {
 		log.Printf("Error in tweetHandler Favorite(): %s", err.Error())
+		if err.Error() == errors.TweetNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err.Error() == errors.VisibilityUnknown {
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		writer.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -188,9 +276,16 @@ func (handler *TweetHandler) Post(writer http.ResponseWriter, req *http.Request)
 
 	handler.logging.Infoln("tweetHandler.Post : post endpoint reached")
 
+	//the largest upload is a single video, anything bigger is rejected before it is buffered
+	req.Body = http.MaxBytesReader(writer, req.Body, maxUploadSize)
 	err := req.ParseMultipartForm(32 << 20)
 	if err != nil {
 		log.Println(err)
+		if err.Error() == "http: request body too large" {
+			http.Error(writer, errors.MediaTooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(writer, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -203,18 +298,11 @@ func (handler *TweetHandler) Post(writer http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	file, _, err := req.FormFile("image")
-
-	var imageBytes []byte
-	if err == nil {
-		bytes, err := ioutil.ReadAll(file)
-		if err != nil {
-			fmt.Fprintln(writer, "Error reading image:", err)
-			http.Error(writer, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		imageBytes = bytes
-		defer file.Close()
+	uploads, err := mediaUploads(req)
+	if err != nil {
+		handler.logging.Errorf("tweetHandler.post : %s", err)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	tweet := req.FormValue("json")
@@ -256,17 +344,74 @@ func (handler *TweetHandler) Post(writer http.ResponseWriter, req *http.Request)
 	username := claims["username"]
 
 	//dodati adve
-	ret, err := handler.service.Post(ctx, &tweetVal, username, &imageBytes)
+	ret, err := handler.service.Post(ctx, &tweetVal, username, req.Header.Get("Authorization"), uploads)
 
 	if err != nil {
 		http.Error(writer, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	//held tweets are saved but stay hidden until a moderator releases them
+	if ret.Hidden {
+		writer.WriteHeader(http.StatusAccepted)
+		jsonResponse(ret, writer)
+		return
+	}
+
 	writer.WriteHeader(http.StatusOK)
 	jsonResponse(ret, writer)
 }
 
+func (handler *TweetHandler) Release(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.Release")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.Release : release endpoint reached")
+
+	vars := mux.Vars(req)
+	tweetID, ok := vars["id"]
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := handler.service.Release(ctx, tweetID)
+	if err != nil {
+		handler.logging.Errorf("tweetHandler.Release : %s", err)
+		if err.Error() == errors.TweetNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (handler *TweetHandler) GetModerationRecords(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetModerationRecords")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.GetModerationRecords : moderation records endpoint reached")
+
+	vars := mux.Vars(req)
+	verdict := domain.ModerationVerdict(vars["verdict"])
+	if verdict != domain.VerdictHold && verdict != domain.VerdictReject {
+		http.Error(writer, "verdict must be hold or reject", http.StatusBadRequest)
+		return
+	}
+
+	records, err := handler.service.GetModerationRecords(ctx, verdict)
+	if err != nil {
+		handler.logging.Errorf("tweetHandler.GetModerationRecords : %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(records, writer)
+}
+
 func (handler *TweetHandler) GetTweetImage(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetTweetImage")
 	defer span.End()
@@ -280,15 +425,90 @@ func (handler *TweetHandler) GetTweetImage(writer http.ResponseWriter, req *http
 		return
 	}
 
-	image, err := handler.service.GetTweetImage(ctx, id)
+	viewer, _ := usernameFromToken(req)
+
+	size := domain.ImageSize(req.URL.Query().Get("size"))
+	image, err := handler.service.GetTweetImage(ctx, req.Header.Get("Authorization"), viewer, id, size)
 	if err != nil {
+		switch err.Error() {
+		case errors.InvalidImageSize:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.MediaNotFound, errors.TweetNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.VisibilityUnknown:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
 		return
 	}
 
+	writer.Header().Set("Content-Type", http.DetectContentType(*image))
 	writer.WriteHeader(http.StatusOK)
 	writer.Write(*image)
 }
 
+func (handler *TweetHandler) GetMedia(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetMedia")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.getMedia reached")
+
+	vars := mux.Vars(req)
+	viewer, _ := usernameFromToken(req)
+
+	size := domain.ImageSize(req.URL.Query().Get("size"))
+	media, data, err := handler.service.GetMedia(ctx, req.Header.Get("Authorization"), viewer, vars["id"], size)
+	if err != nil {
+		switch err.Error() {
+		case errors.InvalidImageSize:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		case errors.MediaNotFound, errors.TweetNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case errors.VisibilityUnknown:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	//media never changes once uploaded, but who may see it does, so only the viewer's browser caches it.
+	//ServeContent also answers the range requests video players send
+	writer.Header().Set("Content-Type", media.MimeType)
+	writer.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeContent(writer, req, "", time.Time{}, bytes.NewReader(data))
+}
+
+// mediaUploads collects the files sent in the media fields together with their
+// alt_text values, the legacy image field is read as one more image
+func mediaUploads(req *http.Request) ([]*domain.MediaUpload, error) {
+	files := append(req.MultipartForm.File["media"], req.MultipartForm.File["image"]...)
+	altTexts := req.MultipartForm.Value["alt_text"]
+
+	var uploads []*domain.MediaUpload
+	for i, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		upload := &domain.MediaUpload{Data: data}
+		if i < len(altTexts) {
+			upload.AltText = altTexts[i]
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, nil
+}
+
 func Post(handler *TweetHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		handler.Post(w, r)
@@ -308,10 +528,35 @@ func (handler *TweetHandler) GetFeedByUser(writer http.ResponseWriter, req *http
 
 	handler.logging.Infoln("tweetHandler.getFeedByUser reached")
 
-	feed, err := handler.service.GetFeedByUser(ctx, req.Header.Get("Authorization"))
+	bearer := req.Header.Get("Authorization")
+	bearerToken := strings.Split(bearer, "Bearer ")
+	if len(bearerToken) != 2 {
+		http.Error(writer, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := jwt.Parse([]byte(bearerToken[1]), verifier)
+	if err != nil {
+		log.Println(err)
+		http.Error(writer, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	claims := authorization.GetMapClaims(token.Bytes())
+
+	mode := domain.FeedMode(req.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = domain.FeedChronological
+	}
+	if mode != domain.FeedChronological && mode != domain.FeedRanked {
+		http.Error(writer, errors.InvalidFeedMode, http.StatusBadRequest)
+		return
+	}
+
+	feed, err := handler.service.GetFeedByUser(ctx, bearer, claims["username"], mode)
 	if err != nil {
 		log.Printf("error: %s", err.Error())
-		if err.Error() == "FollowServiceError" {
+		if err.Error() == "FollowServiceError" || err.Error() == errors.VisibilityUnknown {
 			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
 			return
 		}
@@ -323,6 +568,37 @@ func (handler *TweetHandler) GetFeedByUser(writer http.ResponseWriter, req *http
 	}
 }
 
+func (handler *TweetHandler) GetListFeed(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.GetListFeed")
+	defer span.End()
+
+	handler.logging.Infoln("tweetHandler.getListFeed reached")
+
+	bearer := req.Header.Get("Authorization")
+	viewer, ok := usernameFromToken(req)
+	if !ok {
+		http.Error(writer, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(req)
+	feed, err := handler.service.GetListFeed(ctx, bearer, viewer, vars["id"])
+	if err != nil {
+		switch err.Error() {
+		case errors.ListNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+		case "FollowServiceError", errors.VisibilityUnknown:
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			handler.logging.Errorf("tweetHandler.getListFeed : %s", err)
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonResponse(feed, writer)
+}
+
 func (handler *TweetHandler) Retweet(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "TweetHandler.Retweet")
 	defer span.End()
@@ -352,7 +628,7 @@ func (handler *TweetHandler) Retweet(writer http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	code, err := handler.service.Retweet(ctx, tweetID.ID, username)
+	code, err := handler.service.Retweet(ctx, tweetID.ID, username, bearer)
 	if err != nil {
 		http.Error(writer, err.Error(), code)
 		return
@@ -396,3 +672,19 @@ func (handler *TweetHandler) ViewProfileFromAdd(writer http.ResponseWriter, req
 
 	writer.WriteHeader(http.StatusOK)
 }
+
+// writeVisibilityError answers 404 for tweets the viewer may not see, the same as for missing
+// ones, and 403 for the profile of a private account the viewer doesn't follow
+func (handler *TweetHandler) writeVisibilityError(writer http.ResponseWriter, err error) {
+	switch err.Error() {
+	case errors.TweetNotFound:
+		http.Error(writer, err.Error(), http.StatusNotFound)
+	case errors.ProtectedAccount:
+		http.Error(writer, err.Error(), http.StatusForbidden)
+	case errors.VisibilityUnknown:
+		http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+	default:
+		handler.logging.Errorf("tweetHandler : %s", err)
+		writer.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"tweet_service/application"
+	"tweet_service/domain"
+
+	"github.com/sirupsen/logrus"
+	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TweetEventHandler feeds posted, favorited and retweeted tweets into the trend counts
+type TweetEventHandler struct {
+	trends     *application.TrendService
+	subscriber saga.Subscriber
+	tracer     trace.Tracer
+	logging    *logrus.Logger
+}
+
+func NewTweetEventHandler(trends *application.TrendService, subscriber saga.Subscriber, tracer trace.Tracer, logging *logrus.Logger) (*TweetEventHandler, error) {
+	handler := &TweetEventHandler{
+		trends:     trends,
+		subscriber: subscriber,
+		tracer:     tracer,
+		logging:    logging,
+	}
+
+	err := handler.subscriber.Subscribe(handler.handle)
+	if err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+func (handler *TweetEventHandler) handle(event *domain.TweetEvent) {
+	ctx, span := handler.tracer.Start(context.Background(), "TweetEventHandler.handle")
+	defer span.End()
+
+	err := handler.trends.HandleEvent(ctx, event)
+	if err != nil {
+		handler.logging.Errorf("TweetEventHandler.handle : %s", err)
+	}
+}
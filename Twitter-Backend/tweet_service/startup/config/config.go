@@ -14,6 +14,25 @@ type Config struct {
 	TweetCachePort             string
 	CreateReportCommandSubject string
 	CreateReportReplySubject   string
+	ModerationRulesPath        string
+	ShortLinkBaseURL           string
+	MediaStore                 string
+	MediaRoot                  string
+	MediaS3Endpoint            string
+	MediaS3Bucket              string
+	MediaS3AccessKey           string
+	MediaS3SecretKey           string
+	MediaS3UseSSL              bool
+	FollowEventsSubject        string
+	TweetEventsSubject         string
+	ImpressionEventsSubject    string
+	CelebrityFollowerThreshold string
+	FeedEngagementWeight       string
+	FeedRecencyWeight          string
+	FeedAffinityWeight         string
+	FeedRecencyHalfLife        string
+	SchedulerInterval          string
+	ScheduleClaimTimeout       string
 }
 
 func NewConfig() *Config {
@@ -29,5 +48,24 @@ func NewConfig() *Config {
 		TweetCachePort:             os.Getenv("TWEET_CACHE_PORT"),
 		CreateReportCommandSubject: os.Getenv("CREATE_REPORT_COMMAND_SUBJECT"),
 		CreateReportReplySubject:   os.Getenv("CREATE_REPORT_REPLY_SUBJECT"),
+		ModerationRulesPath:        os.Getenv("MODERATION_RULES_PATH"),
+		ShortLinkBaseURL:           os.Getenv("SHORT_LINK_BASE_URL"),
+		MediaStore:                 os.Getenv("MEDIA_STORE"),
+		MediaRoot:                  os.Getenv("MEDIA_ROOT"),
+		MediaS3Endpoint:            os.Getenv("MEDIA_S3_ENDPOINT"),
+		MediaS3Bucket:              os.Getenv("MEDIA_S3_BUCKET"),
+		MediaS3AccessKey:           os.Getenv("MEDIA_S3_ACCESS_KEY"),
+		MediaS3SecretKey:           os.Getenv("MEDIA_S3_SECRET_KEY"),
+		MediaS3UseSSL:              os.Getenv("MEDIA_S3_USE_SSL") == "true",
+		FollowEventsSubject:        os.Getenv("FOLLOW_EVENTS_SUBJECT"),
+		TweetEventsSubject:         os.Getenv("TWEET_EVENTS_SUBJECT"),
+		ImpressionEventsSubject:    os.Getenv("IMPRESSION_EVENTS_SUBJECT"),
+		CelebrityFollowerThreshold: os.Getenv("CELEBRITY_FOLLOWER_THRESHOLD"),
+		FeedEngagementWeight:       os.Getenv("FEED_ENGAGEMENT_WEIGHT"),
+		FeedRecencyWeight:          os.Getenv("FEED_RECENCY_WEIGHT"),
+		FeedAffinityWeight:         os.Getenv("FEED_AFFINITY_WEIGHT"),
+		FeedRecencyHalfLife:        os.Getenv("FEED_RECENCY_HALF_LIFE"),
+		SchedulerInterval:          os.Getenv("SCHEDULER_INTERVAL"),
+		ScheduleClaimTimeout:       os.Getenv("SCHEDULE_CLAIM_TIMEOUT"),
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/nats"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
@@ -18,6 +19,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 	"tweet_service/application"
@@ -108,24 +110,219 @@ func (server *Server) Start() {
 	tweetStore.CreateTables()
 
 	//orchestrator
-	commandPublisher := server.initPublisher(server.config.CreateReportCommandSubject)
-	replySubscriber := server.initSubscriber(server.config.CreateReportReplySubject, QueueGroup)
+	commandPublisher := server.initSagaPublisher(server.config.CreateReportCommandSubject)
+	replySubscriber := server.initSagaSubscriber(server.config.CreateReportReplySubject, QueueGroup)
 
 	createReportOrchestrator := server.initCreateEventOrchestrator(commandPublisher, replySubscriber, tracer)
 
-	tweetService := server.initTweetService(*tweetStore, tweetCache, tracer, createReportOrchestrator, Logger)
+	moderationPipeline := server.initModerationPipeline(tweetCache)
 
-	tweetHandler := server.initTweetHandler(tweetService, tracer, Logger)
+	linkService := server.initLinkService(tweetStore, tracer)
+	mediaStore, err := NewMediaStore(server.config, tracer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mediaService := server.initMediaService(tweetStore, mediaStore, tracer)
+
+	timelineService := server.initTimelineService(tweetStore, tracer)
+	followEventsSubscriber := server.initSubscriber(server.config.FollowEventsSubject, QueueGroup)
+	server.initFollowEventHandler(timelineService, followEventsSubscriber, tracer)
+
+	engagementService := server.initEngagementService(tweetStore, tracer)
+	visibilityService := server.initVisibilityService(tracer)
+	feedRanker := server.initFeedRanker(tweetStore, tweetCache, engagementService, visibilityService, tracer)
+
+	tweetEventsPublisher := server.initPublisher(server.config.TweetEventsSubject)
+	trendService := server.initTrendService(tweetCache, tweetStore, visibilityService, tweetEventsPublisher, tracer)
+	tweetEventsSubscriber := server.initSubscriber(server.config.TweetEventsSubject, QueueGroup)
+	server.initTweetEventHandler(trendService, tweetEventsSubscriber, tracer)
 
-	server.start(tweetHandler)
+	pollService := server.initPollService(tweetStore, tracer)
+
+	impressionEventsPublisher := server.initPublisher(server.config.ImpressionEventsSubject)
+	impressionService := server.initImpressionService(impressionEventsPublisher, tracer)
+	impressionCtx, stopImpressions := context.WithCancel(context.Background())
+	defer stopImpressions()
+	go impressionService.Run(impressionCtx)
+
+	tweetService := server.initTweetService(*tweetStore, tweetCache, tracer, createReportOrchestrator, moderationPipeline, linkService, mediaService, timelineService, feedRanker, trendService, pollService, engagementService, visibilityService, impressionService, Logger)
+
+	tweetHandler := server.initTweetHandler(tweetService, tracer, Logger)
+	linkHandler := server.initLinkHandler(linkService, tracer)
+	trendHandler := server.initTrendHandler(trendService, tracer)
+	bookmarkService := server.initBookmarkService(tweetStore, visibilityService, engagementService, pollService, tracer)
+	bookmarkHandler := server.initBookmarkHandler(bookmarkService, tracer)
+
+	scheduleService := server.initScheduleService(tweetStore, tweetService, tracer)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go scheduleService.Run(schedulerCtx)
+	scheduleHandler := server.initScheduleHandler(scheduleService, tracer)
+	pollHandler := server.initPollHandler(tweetService, tracer)
+
+	server.start(tweetHandler, linkHandler, trendHandler, bookmarkHandler, scheduleHandler, pollHandler)
 }
 
-func (server *Server) initTweetService(store store.TweetRepo, cache domain.TweetCache, tracer trace.Tracer, orchestrator *application2.CreateEventOrchestrator, logging *logrus.Logger) *application.TweetService {
-	service := application.NewTweetService(&store, cache, tracer, orchestrator, Logger)
+func (server *Server) initTweetService(store store.TweetRepo, cache domain.TweetCache, tracer trace.Tracer, orchestrator *application2.CreateEventOrchestrator, moderation *application.ModerationPipeline, links *application.LinkService, media *application.MediaService, timelines *application.TimelineService, ranker *application.FeedRanker, trends *application.TrendService, polls *application.PollService, engagement *application.EngagementService, visibility *application.VisibilityService, impressions *application.ImpressionService, logging *logrus.Logger) *application.TweetService {
+	service := application.NewTweetService(&store, cache, tracer, orchestrator, moderation, links, media, timelines, ranker, trends, polls, engagement, visibility, impressions, Logger)
 	Logger.Info("Started tweet service")
 	return service
 }
 
+func (server *Server) initImpressionService(publisher saga.Publisher, tracer trace.Tracer) *application.ImpressionService {
+	return application.NewImpressionService(publisher, tracer, Logger)
+}
+
+func (server *Server) initLinkService(store domain.LinkStore, tracer trace.Tracer) *application.LinkService {
+	baseURL := server.config.ShortLinkBaseURL
+	if baseURL == "" {
+		baseURL = "https://localhost:8000/api/tweets"
+	}
+	return application.NewLinkService(store, baseURL, application.NewPreviewClient(), tracer, Logger)
+}
+
+func (server *Server) initTimelineService(store *store.TweetRepo, tracer trace.Tracer) *application.TimelineService {
+	threshold, err := strconv.Atoi(server.config.CelebrityFollowerThreshold)
+	if err != nil || threshold <= 0 {
+		threshold = 10000
+	}
+	return application.NewTimelineService(store, store, threshold, tracer, Logger)
+}
+
+func (server *Server) initEngagementService(store domain.TweetStore, tracer trace.Tracer) *application.EngagementService {
+	return application.NewEngagementService(store, tracer, Logger)
+}
+
+// initFeedRanker reads the ranking weights from the config, unset or invalid values keep the defaults
+func (server *Server) initFeedRanker(store domain.TweetStore, cache domain.TweetCache, engagement *application.EngagementService, visibility *application.VisibilityService, tracer trace.Tracer) *application.FeedRanker {
+	weights := domain.RankingWeights{
+		Engagement: parseWeight(server.config.FeedEngagementWeight, 0.4),
+		Recency:    parseWeight(server.config.FeedRecencyWeight, 0.4),
+		Affinity:   parseWeight(server.config.FeedAffinityWeight, 0.2),
+		HalfLife:   6 * time.Hour,
+	}
+
+	halfLife, err := time.ParseDuration(server.config.FeedRecencyHalfLife)
+	if err == nil && halfLife > 0 {
+		weights.HalfLife = halfLife
+	}
+
+	return application.NewFeedRanker(store, cache, engagement, visibility, weights, tracer, Logger)
+}
+
+func parseWeight(value string, fallback float64) float64 {
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || weight < 0 {
+		return fallback
+	}
+	return weight
+}
+
+func (server *Server) initTrendService(cache domain.TweetCache, store domain.TweetStore, visibility *application.VisibilityService, publisher saga.Publisher, tracer trace.Tracer) *application.TrendService {
+	return application.NewTrendService(cache, store, visibility, publisher, tracer, Logger)
+}
+
+func (server *Server) initBookmarkService(store *store.TweetRepo, visibility *application.VisibilityService, engagement *application.EngagementService, polls *application.PollService, tracer trace.Tracer) *application.BookmarkService {
+	return application.NewBookmarkService(store, store, visibility, engagement, polls, tracer, Logger)
+}
+
+func (server *Server) initBookmarkHandler(service *application.BookmarkService, tracer trace.Tracer) *handlers.BookmarkHandler {
+	return handlers.NewBookmarkHandler(service, tracer, Logger)
+}
+
+func (server *Server) initScheduleService(store domain.ScheduleStore, tweets *application.TweetService, tracer trace.Tracer) *application.ScheduleService {
+	interval, err := time.ParseDuration(server.config.SchedulerInterval)
+	if err != nil || interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	claimTimeout, err := time.ParseDuration(server.config.ScheduleClaimTimeout)
+	if err != nil || claimTimeout <= 0 {
+		claimTimeout = 2 * time.Minute
+	}
+
+	return application.NewScheduleService(store, tweets, interval, claimTimeout, tracer, Logger)
+}
+
+func (server *Server) initScheduleHandler(service *application.ScheduleService, tracer trace.Tracer) *handlers.ScheduleHandler {
+	return handlers.NewScheduleHandler(service, tracer, Logger)
+}
+
+func (server *Server) initVisibilityService(tracer trace.Tracer) *application.VisibilityService {
+	return application.NewVisibilityService(tracer, Logger)
+}
+
+func (server *Server) initPollService(store domain.PollStore, tracer trace.Tracer) *application.PollService {
+	return application.NewPollService(store, tracer, Logger)
+}
+
+func (server *Server) initPollHandler(service *application.TweetService, tracer trace.Tracer) *handlers.PollHandler {
+	return handlers.NewPollHandler(service, tracer, Logger)
+}
+
+func (server *Server) initTweetEventHandler(trends *application.TrendService, subscriber saga.Subscriber, tracer trace.Tracer) {
+	_, err := handlers.NewTweetEventHandler(trends, subscriber, tracer, Logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (server *Server) initTrendHandler(service *application.TrendService, tracer trace.Tracer) *handlers.TrendHandler {
+	return handlers.NewTrendHandler(service, tracer, Logger)
+}
+
+func (server *Server) initFollowEventHandler(timelines *application.TimelineService, subscriber saga.Subscriber, tracer trace.Tracer) {
+	_, err := handlers.NewFollowEventHandler(timelines, subscriber, tracer, Logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (server *Server) initMediaService(store domain.MediaMetadataStore, blobs domain.MediaStore, tracer trace.Tracer) *application.MediaService {
+	return application.NewMediaService(store, blobs, tracer, Logger)
+}
+
+// NewMediaStore picks the media backend from the config, the filesystem is used unless s3 is asked for
+func NewMediaStore(cfg *config.Config, tracer trace.Tracer) (domain.MediaStore, error) {
+	if cfg.MediaStore == "s3" {
+		return store.NewS3MediaStore(cfg.MediaS3Endpoint, cfg.MediaS3Bucket,
+			cfg.MediaS3AccessKey, cfg.MediaS3SecretKey, cfg.MediaS3UseSSL, tracer)
+	}
+
+	root := cfg.MediaRoot
+	if root == "" {
+		root = "/app/media"
+	}
+	return store.NewFileMediaStore(root, tracer)
+}
+
+func (server *Server) initLinkHandler(service *application.LinkService, tracer trace.Tracer) *handlers.LinkHandler {
+	return handlers.NewLinkHandler(service, tracer, Logger)
+}
+
+func (server *Server) initModerationPipeline(cache domain.TweetCache) *application.ModerationPipeline {
+	path := server.config.ModerationRulesPath
+	if path == "" {
+		path = "./moderation_rules.json"
+	}
+
+	rules, err := application.LoadModerationRules(path)
+	if err != nil {
+		log.Fatalf("Failed to load moderation rules: %v", err)
+	}
+
+	wordFilter, err := application.NewWordFilter(rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return application.NewModerationPipeline(
+		wordFilter,
+		application.NewLinkFilter(rules),
+		application.NewSpamDetector(cache, rules),
+	)
+}
+
 func (server *Server) initTweetCache(client *redis.Client, tracer trace.Tracer) domain.TweetCache {
 	cache := store2.NewTweetRedisCache(client, tracer)
 	return cache
@@ -163,6 +360,28 @@ func (server *Server) initSubscriber(subject string, queueGroup string) saga.Sub
 	return subscriber
 }
 
+// initSagaPublisher publishes to a JetStream stream, saga messages are kept until the
+// services handling them are up
+func (server *Server) initSagaPublisher(subject string) saga.Publisher {
+	publisher, err := jetstream.NewJetStreamPublisher(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return publisher
+}
+
+func (server *Server) initSagaSubscriber(subject string, queueGroup string) saga.Subscriber {
+	subscriber, err := jetstream.NewJetStreamSubscriber(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject, queueGroup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return subscriber
+}
+
 func (server *Server) initCreateEventOrchestrator(publisher saga.Publisher, subscriber saga.Subscriber, tracer trace.Tracer) *application.CreateEventOrchestrator {
 	orchestrator, err := application.NewCreateEventOrchestrator(publisher, subscriber, tracer)
 	if err != nil {
@@ -171,8 +390,13 @@ func (server *Server) initCreateEventOrchestrator(publisher saga.Publisher, subs
 	return orchestrator
 }
 
-func (server *Server) start(tweetHandler *handlers.TweetHandler) {
+func (server *Server) start(tweetHandler *handlers.TweetHandler, linkHandler *handlers.LinkHandler, trendHandler *handlers.TrendHandler, bookmarkHandler *handlers.BookmarkHandler, scheduleHandler *handlers.ScheduleHandler, pollHandler *handlers.PollHandler) {
 	router := mux.NewRouter()
+	linkHandler.Init(router)
+	trendHandler.Init(router)
+	bookmarkHandler.Init(router)
+	scheduleHandler.Init(router)
+	pollHandler.Init(router)
 	tweetHandler.Init(router)
 
 	srv := &http.Server{
@@ -0,0 +1,306 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	COLLECTION_BOOKMARK            = "bookmark"
+	COLLECTION_BOOKMARK_BY_TWEET   = "bookmark_by_tweet"
+	COLLECTION_BOOKMARK_COLLECTION = "bookmark_collection"
+	COLLECTION_BOOKMARKED_BY       = "bookmarked_by"
+)
+
+func (sr *TweetRepo) createBookmarkTables() {
+	//every bookmark is in the default collection partition and in its named collection, if it has one
+	err := sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(username text, collection text, created_at bigint, tweet_id UUID,
+					PRIMARY KEY ((username, collection), created_at, tweet_id))
+					WITH CLUSTERING ORDER BY (created_at DESC, tweet_id ASC)`,
+			COLLECTION_BOOKMARK)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (username text, tweet_id UUID, collection text, created_at bigint, PRIMARY KEY ((username), tweet_id))",
+			COLLECTION_BOOKMARK_BY_TWEET)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (username text, name text, created_at bigint, PRIMARY KEY ((username), name))",
+			COLLECTION_BOOKMARK_COLLECTION)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	//only used to clean up after a deleted tweet, it is never exposed
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (tweet_id UUID, username text, PRIMARY KEY ((tweet_id), username))",
+			COLLECTION_BOOKMARKED_BY)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+}
+
+func (sr *TweetRepo) SaveBookmark(ctx context.Context, username string, bookmark *domain.Bookmark) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveBookmark")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveBookmark reached")
+
+	insert := fmt.Sprintf("INSERT INTO %s (username, collection, created_at, tweet_id) VALUES (?, ?, ?, ?)", COLLECTION_BOOKMARK)
+
+	collections := []string{domain.DefaultCollection}
+	if bookmark.Collection != domain.DefaultCollection {
+		collections = append(collections, bookmark.Collection)
+	}
+
+	for _, collection := range collections {
+		err := sr.session.Query(insert, username, collection, bookmark.CreatedAt, bookmark.TweetID).Exec()
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+	}
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (username, tweet_id, collection, created_at) VALUES (?, ?, ?, ?)", COLLECTION_BOOKMARK_BY_TWEET),
+		username, bookmark.TweetID, bookmark.Collection, bookmark.CreatedAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (tweet_id, username) VALUES (?, ?)", COLLECTION_BOOKMARKED_BY),
+		bookmark.TweetID, username).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) GetBookmark(ctx context.Context, username string, tweetID gocql.UUID) (*domain.Bookmark, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetBookmark")
+	defer span.End()
+
+	bookmark := domain.Bookmark{TweetID: tweetID}
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT collection, created_at FROM %s WHERE username = ? AND tweet_id = ?", COLLECTION_BOOKMARK_BY_TWEET),
+		username, tweetID).Scan(&bookmark.Collection, &bookmark.CreatedAt)
+	if err == gocql.ErrNotFound {
+		return nil, fmt.Errorf(errors.BookmarkNotFound)
+	}
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+
+	return &bookmark, nil
+}
+
+func (sr *TweetRepo) DeleteBookmark(ctx context.Context, username string, bookmark *domain.Bookmark) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.DeleteBookmark")
+	defer span.End()
+
+	sr.logging.Infoln("Store: deleteBookmark reached")
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE username = ? AND collection = ? AND created_at = ? AND tweet_id = ?", COLLECTION_BOOKMARK)
+
+	collections := []string{domain.DefaultCollection}
+	if bookmark.Collection != domain.DefaultCollection {
+		collections = append(collections, bookmark.Collection)
+	}
+
+	for _, collection := range collections {
+		err := sr.session.Query(deleteQuery, username, collection, bookmark.CreatedAt, bookmark.TweetID).Exec()
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+	}
+
+	err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE username = ? AND tweet_id = ?", COLLECTION_BOOKMARK_BY_TWEET),
+		username, bookmark.TweetID).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE tweet_id = ? AND username = ?", COLLECTION_BOOKMARKED_BY),
+		bookmark.TweetID, username).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetBookmarkers returns the users who bookmarked the tweet
+func (sr *TweetRepo) GetBookmarkers(ctx context.Context, tweetID gocql.UUID) ([]string, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetBookmarkers")
+	defer span.End()
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT username FROM %s WHERE tweet_id = ?", COLLECTION_BOOKMARKED_BY), tweetID).
+		Iter().Scanner()
+
+	var usernames []string
+	for scanner.Next() {
+		var username string
+		err := scanner.Scan(&username)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		usernames = append(usernames, username)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return usernames, nil
+}
+
+// GetBookmarks returns one page of a collection, newest first, and the paging state of the next page
+func (sr *TweetRepo) GetBookmarks(ctx context.Context, username string, collection string, limit int, cursor []byte) ([]*domain.Bookmark, []byte, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetBookmarks")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getBookmarks reached")
+
+	iter := sr.session.Query(
+		fmt.Sprintf("SELECT created_at, tweet_id FROM %s WHERE username = ? AND collection = ?", COLLECTION_BOOKMARK),
+		username, collection).PageSize(limit).PageState(cursor).Iter()
+	next := iter.PageState()
+
+	var bookmarks []*domain.Bookmark
+	scanner := iter.Scanner()
+	//only the requested page is read, the scanner would fetch the following pages too
+	for len(bookmarks) < limit && scanner.Next() {
+		bookmark := domain.Bookmark{Collection: collection}
+		err := scanner.Scan(&bookmark.CreatedAt, &bookmark.TweetID)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, nil, err
+		}
+		bookmarks = append(bookmarks, &bookmark)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, nil, err
+	}
+
+	return bookmarks, next, nil
+}
+
+// SaveBookmarkCollection creates the collection, false means the user already has one with that name
+func (sr *TweetRepo) SaveBookmarkCollection(ctx context.Context, username string, collection *domain.BookmarkCollection) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveBookmarkCollection")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveBookmarkCollection reached")
+
+	applied, err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (username, name, created_at) VALUES (?, ?, ?) IF NOT EXISTS", COLLECTION_BOOKMARK_COLLECTION),
+		username, collection.Name, collection.CreatedAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+func (sr *TweetRepo) GetBookmarkCollections(ctx context.Context, username string) ([]*domain.BookmarkCollection, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetBookmarkCollections")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getBookmarkCollections reached")
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT name, created_at FROM %s WHERE username = ?", COLLECTION_BOOKMARK_COLLECTION), username).
+		Iter().Scanner()
+
+	var collections []*domain.BookmarkCollection
+	for scanner.Next() {
+		var collection domain.BookmarkCollection
+		err := scanner.Scan(&collection.Name, &collection.CreatedAt)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		collections = append(collections, &collection)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return collections, nil
+}
+
+// DeleteBookmarkCollection removes the collection, its bookmarks stay in the default collection
+func (sr *TweetRepo) DeleteBookmarkCollection(ctx context.Context, username string, name string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.DeleteBookmarkCollection")
+	defer span.End()
+
+	sr.logging.Infoln("Store: deleteBookmarkCollection reached")
+
+	update := fmt.Sprintf("UPDATE %s SET collection = ? WHERE username = ? AND tweet_id = ?", COLLECTION_BOOKMARK_BY_TWEET)
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id FROM %s WHERE username = ? AND collection = ?", COLLECTION_BOOKMARK),
+		username, name).Iter().Scanner()
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		err := scanner.Scan(&tweetID)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+
+		err = sr.session.Query(update, domain.DefaultCollection, username, tweetID).Exec()
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE username = ? AND collection = ?", COLLECTION_BOOKMARK), username, name).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE username = ? AND name = ?", COLLECTION_BOOKMARK_COLLECTION), username, name).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
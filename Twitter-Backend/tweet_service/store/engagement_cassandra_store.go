@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	COLLECTION_ENGAGEMENT      = "tweet_engagement"
+	COLLECTION_ENGAGEMENT_SEED = "tweet_engagement_seed"
+)
+
+// Favorites and retweets are counted in a counter table. The counts kept in the tweet rows
+// before are copied to the seed table once, the first time the tweet is favorited or retweeted,
+// and are added to the counters when they are read
+func (sr *TweetRepo) createEngagementTables() {
+	err := sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (tweet_id UUID, favorite_count counter, retweet_count counter, PRIMARY KEY ((tweet_id)))",
+			COLLECTION_ENGAGEMENT)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (tweet_id UUID, favorite_count int, retweet_count int, PRIMARY KEY ((tweet_id)))",
+			COLLECTION_ENGAGEMENT_SEED)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("ALTER TABLE %s ADD (favorite_count int, retweet_count int)", COLLECTION_ENGAGEMENT_SEED)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", COLLECTION_ENGAGEMENT_SEED, err.Error())
+	}
+}
+
+// seedEngagement keeps the counts stored in the tweet row in the seed table. The row is written
+// with a lightweight transaction in a single statement, so the counts are kept exactly once no
+// matter how many requests race or fail, and the counters only ever hold the changes made since
+func (sr *TweetRepo) seedEngagement(ctx context.Context, tweet *domain.Tweet) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.seedEngagement")
+	defer span.End()
+
+	var seeded gocql.UUID
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id FROM %s WHERE tweet_id = ?", COLLECTION_ENGAGEMENT_SEED), tweet.ID).Scan(&seeded)
+	if err == nil {
+		return nil
+	}
+	if err != gocql.ErrNotFound {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	_, err = sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (tweet_id, favorite_count, retweet_count) VALUES (?, ?, ?) IF NOT EXISTS", COLLECTION_ENGAGEMENT_SEED),
+		tweet.ID, tweet.FavoriteCount, tweet.RetweetCount).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) incrementEngagement(ctx context.Context, tweetID gocql.UUID, favorites int, retweets int) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.incrementEngagement")
+	defer span.End()
+
+	err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET favorite_count = favorite_count + ?, retweet_count = retweet_count + ? WHERE tweet_id = ?",
+			COLLECTION_ENGAGEMENT), int64(favorites), int64(retweets), tweetID).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetEngagement returns the counts of the tweets among ids that were ever favorited or
+// retweeted, the others still have their counts in the tweet row
+func (sr *TweetRepo) GetEngagement(ctx context.Context, ids []gocql.UUID) (map[gocql.UUID]*domain.Engagement, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetEngagement")
+	defer span.End()
+
+	engagement := make(map[gocql.UUID]*domain.Engagement)
+	if len(ids) == 0 {
+		return engagement, nil
+	}
+
+	//seed rows written before the counts were kept there have none, their counters hold them
+	query := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, favorite_count, retweet_count FROM %s WHERE tweet_id IN ?", COLLECTION_ENGAGEMENT_SEED), ids)
+	err := sr.addEngagement(query, engagement)
+	if err != nil {
+		return nil, err
+	}
+
+	query = sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, favorite_count, retweet_count FROM %s WHERE tweet_id IN ?", COLLECTION_ENGAGEMENT), ids)
+	err = sr.addEngagement(query, engagement)
+	if err != nil {
+		return nil, err
+	}
+
+	return engagement, nil
+}
+
+func (sr *TweetRepo) addEngagement(query *gocql.Query, engagement map[gocql.UUID]*domain.Engagement) error {
+	query.PageSize(0)
+	scanner := query.Iter().Scanner()
+
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		var favorites, retweets int64
+		err := scanner.Scan(&tweetID, &favorites, &retweets)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+
+		counts, ok := engagement[tweetID]
+		if !ok {
+			counts = &domain.Engagement{}
+			engagement[tweetID] = counts
+		}
+		counts.FavoriteCount += int(favorites)
+		counts.RetweetCount += int(retweets)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+	return nil
+}
+
+// GetFavorited returns the tweets among ids that username favorited
+func (sr *TweetRepo) GetFavorited(ctx context.Context, ids []gocql.UUID, username string) (map[gocql.UUID]bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetFavorited")
+	defer span.End()
+
+	return sr.engagedBy(ctx, COLLECTION_FAVORITE, ids, username)
+}
+
+// GetRetweeted returns the tweets among ids that username retweeted
+func (sr *TweetRepo) GetRetweeted(ctx context.Context, ids []gocql.UUID, username string) (map[gocql.UUID]bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetRetweeted")
+	defer span.End()
+
+	return sr.engagedBy(ctx, COLLECTION_RETWEET, ids, username)
+}
+
+func (sr *TweetRepo) engagedBy(ctx context.Context, table string, ids []gocql.UUID, username string) (map[gocql.UUID]bool, error) {
+	engaged := make(map[gocql.UUID]bool)
+	if len(ids) == 0 || username == "" {
+		return engaged, nil
+	}
+
+	query := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id FROM %s WHERE tweet_id IN ? AND username = ?", table), ids, username)
+	query.PageSize(0)
+	scanner := query.Iter().Scanner()
+
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		err := scanner.Scan(&tweetID)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		engaged[tweetID] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return engaged, nil
+}
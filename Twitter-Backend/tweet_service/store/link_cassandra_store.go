@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"tweet_service/domain"
+	"tweet_service/errors"
+)
+
+const (
+	COLLECTION_SHORT_LINK        = "short_link"
+	COLLECTION_SHORT_LINK_CLICKS = "short_link_clicks"
+	TYPE_LINK_PREVIEW            = "link_preview"
+)
+
+func (sr *TweetRepo) createLinkTables() {
+	err := sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (code text, url text, tweet_id UUID, username text, created_at bigint, PRIMARY KEY ((code)))",
+			COLLECTION_SHORT_LINK)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (code text, clicks counter, PRIMARY KEY ((code)))",
+			COLLECTION_SHORT_LINK_CLICKS)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TYPE IF NOT EXISTS %s (url text, title text, description text, image text)",
+			TYPE_LINK_PREVIEW)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TYPE ERR: %s", err.Error())
+	}
+}
+
+// SaveShortLink inserts the link only if the code is still free, false means the code is taken
+func (sr *TweetRepo) SaveShortLink(ctx context.Context, link *domain.ShortLink) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveShortLink")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveShortLink reached")
+
+	insert := fmt.Sprintf("INSERT INTO %s (code, url, tweet_id, username, created_at) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS",
+		COLLECTION_SHORT_LINK)
+
+	applied, err := sr.session.Query(insert, link.Code, link.URL, link.TweetID, link.Username, link.CreatedAt).
+		MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+func (sr *TweetRepo) GetShortLink(ctx context.Context, code string) (*domain.ShortLink, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetShortLink")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getShortLink reached")
+
+	var link domain.ShortLink
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT code, url, tweet_id, username, created_at FROM %s WHERE code = ?", COLLECTION_SHORT_LINK), code).
+		Scan(&link.Code, &link.URL, &link.TweetID, &link.Username, &link.CreatedAt)
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, fmt.Errorf(errors.LinkNotFound)
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("SELECT clicks FROM %s WHERE code = ?", COLLECTION_SHORT_LINK_CLICKS), code).
+		Scan(&link.Clicks)
+	if err != nil {
+		//the counter row exists only after the first click
+		link.Clicks = 0
+	}
+
+	return &link, nil
+}
+
+func (sr *TweetRepo) CountClick(ctx context.Context, code string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.CountClick")
+	defer span.End()
+
+	err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET clicks = clicks + 1 WHERE code = ?", COLLECTION_SHORT_LINK_CLICKS), code).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) SaveLinkPreviews(ctx context.Context, tweet *domain.Tweet, previews []domain.LinkPreview) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveLinkPreviews")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveLinkPreviews reached")
+
+	err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET previews=? WHERE id=? AND created_at=?", COLLECTION),
+		previews, tweet.ID, tweet.CreatedAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET previews=? WHERE username=? AND created_at=?", COLLECTION_BY_USER),
+		previews, tweet.Username, tweet.CreatedAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/gocql/gocql"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+)
+
+const (
+	COLLECTION_MEDIA         = "media"
+	COLLECTION_MEDIA_VARIANT = "media_variant"
+	TYPE_MEDIA_INFO          = "media_info"
+)
+
+func (sr *TweetRepo) createMediaTables() {
+	err := sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(id UUID, tweet_id UUID, username text, type text, mime_type text, width int, height int,
+					duration_ms bigint, alt_text text, size bigint, created_at bigint, data blob,
+					PRIMARY KEY ((id)))`,
+			COLLECTION_MEDIA)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (media_id UUID, size text, data blob, PRIMARY KEY ((media_id), size))",
+			COLLECTION_MEDIA_VARIANT)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf(`CREATE TYPE IF NOT EXISTS %s
+					(id UUID, type text, mime_type text, width int, height int, duration_ms bigint, alt_text text, size bigint)`,
+			TYPE_MEDIA_INFO)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TYPE ERR: %s", err.Error())
+	}
+}
+
+func (sr *TweetRepo) SaveMedia(ctx context.Context, tweet *domain.Tweet, media *domain.Media, key string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveMedia")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveMedia reached")
+
+	insert := fmt.Sprintf("INSERT INTO %s "+
+		"(id, tweet_id, username, type, mime_type, width, height, duration_ms, alt_text, size, created_at, content_key) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_MEDIA)
+
+	err := sr.session.Query(insert, media.ID, tweet.ID, tweet.Username, media.Type, media.MimeType, media.Width,
+		media.Height, media.DurationMs, media.AltText, media.Size, time.Now().Unix(), key).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) GetMedia(ctx context.Context, id string) (*domain.Media, *domain.BlobRef, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetMedia")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getMedia reached")
+
+	var media domain.Media
+	var ref domain.BlobRef
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT id, tweet_id, type, mime_type, width, height, duration_ms, alt_text, size, data, content_key FROM %s WHERE id = ?",
+			COLLECTION_MEDIA), id).
+		Scan(&media.ID, &media.TweetID, &media.Type, &media.MimeType, &media.Width, &media.Height, &media.DurationMs,
+			&media.AltText, &media.Size, &ref.Data, &ref.Key)
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, nil, fmt.Errorf(errors.MediaNotFound)
+	}
+
+	return &media, &ref, nil
+}
+
+func (sr *TweetRepo) SaveMediaVariant(ctx context.Context, mediaID gocql.UUID, size domain.ImageSize, key string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveMediaVariant")
+	defer span.End()
+
+	insert := fmt.Sprintf("INSERT INTO %s (media_id, size, content_key) VALUES (?, ?, ?)", COLLECTION_MEDIA_VARIANT)
+
+	err := sr.session.Query(insert, mediaID, size, key).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetMediaVariant returns nil without an error when the image is smaller than the requested size
+func (sr *TweetRepo) GetMediaVariant(ctx context.Context, mediaID string, size domain.ImageSize) (*domain.BlobRef, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetMediaVariant")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getMediaVariant reached")
+
+	var ref domain.BlobRef
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT data, content_key FROM %s WHERE media_id = ? AND size = ?", COLLECTION_MEDIA_VARIANT), mediaID, size).
+		Scan(&ref.Data, &ref.Key)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+
+	return &ref, nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type FileMediaStore struct {
+	root   string
+	tracer trace.Tracer
+}
+
+func NewFileMediaStore(root string, tracer trace.Tracer) (domain.MediaStore, error) {
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMediaStore{
+		root:   root,
+		tracer: tracer,
+	}, nil
+}
+
+// path spreads the objects over two levels of directories named after the key prefix
+func (fs *FileMediaStore) path(key string) (string, error) {
+	if len(key) < 4 || filepath.Base(key) != key {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return filepath.Join(fs.root, key[0:2], key[2:4], key), nil
+}
+
+func (fs *FileMediaStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	ctx, span := fs.tracer.Start(ctx, "FileMediaStore.Put")
+	defer span.End()
+
+	path, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	//write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (fs *FileMediaStore) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := fs.tracer.Start(ctx, "FileMediaStore.Get")
+	defer span.End()
+
+	path, err := fs.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf(errors.MediaNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (fs *FileMediaStore) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, span := fs.tracer.Start(ctx, "FileMediaStore.Exists")
+	defer span.End()
+
+	path, err := fs.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestFileMediaStore(t *testing.T) (domain.MediaStore, string) {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "media")
+	store, err := NewFileMediaStore(root, trace.NewNoopTracerProvider().Tracer("test"))
+	if err != nil {
+		t.Fatalf("NewFileMediaStore() error = %v", err)
+	}
+	return store, root
+}
+
+func TestFileMediaStoreRoundTrip(t *testing.T) {
+	store, root := newTestFileMediaStore(t)
+	ctx := context.Background()
+	data := []byte("image bytes")
+	key := domain.ContentKey(data)
+
+	exists, err := store.Exists(ctx, key)
+	if err != nil || exists {
+		t.Fatalf("Exists() before Put = %t, %v", exists, err)
+	}
+
+	err = store.Put(ctx, key, data, "image/png")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	exists, err = store.Exists(ctx, key)
+	if err != nil || !exists {
+		t.Fatalf("Exists() after Put = %t, %v", exists, err)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get() = %q, want %q", got, data)
+	}
+
+	//objects are spread over directories named after the key prefix
+	path := filepath.Join(root, key[0:2], key[2:4], key)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("object is not stored at %s: %v", path, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the object", len(entries))
+	}
+}
+
+func TestFileMediaStorePutReplacesTheObject(t *testing.T) {
+	store, _ := newTestFileMediaStore(t)
+	ctx := context.Background()
+	key := "abcdef"
+
+	for _, data := range []string{"first version", "second"} {
+		err := store.Put(ctx, key, []byte(data), "image/png")
+		if err != nil {
+			t.Fatalf("Put(%q) error = %v", data, err)
+		}
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(got) != "second" {
+		t.Errorf("Get() = %q, want the last version", got)
+	}
+}
+
+func TestFileMediaStoreMissingObject(t *testing.T) {
+	store, _ := newTestFileMediaStore(t)
+
+	_, err := store.Get(context.Background(), "abcdef")
+	if err == nil || err.Error() != errors.MediaNotFound {
+		t.Errorf("Get() of a missing object error = %v, want %q", err, errors.MediaNotFound)
+	}
+}
+
+func TestFileMediaStoreRejectsInvalidKeys(t *testing.T) {
+	store, root := newTestFileMediaStore(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "abc", "../../etc/passwd", "ab/cd/ef", ".."} {
+		err := store.Put(ctx, key, []byte("data"), "image/png")
+		if err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		_, err = store.Get(ctx, key)
+		if err == nil {
+			t.Errorf("Get(%q) succeeded", key)
+		}
+		_, err = store.Exists(ctx, key)
+		if err == nil {
+			t.Errorf("Exists(%q) succeeded", key)
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("invalid keys left %d entries in the store", len(entries))
+	}
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// blobTable is a table that used to keep media bytes inline
+type blobTable struct {
+	name       string
+	keyColumns []string
+	dataColumn string
+}
+
+var blobTables = []blobTable{
+	{name: COLLECTION_TWEET_IMAGE, keyColumns: []string{"tweet_id"}, dataColumn: "image"},
+	{name: COLLECTION_MEDIA, keyColumns: []string{"id"}, dataColumn: "data"},
+	{name: COLLECTION_MEDIA_VARIANT, keyColumns: []string{"media_id", "size"}, dataColumn: "data"},
+}
+
+// blobRows reads the rows of a blob table and points a row at its content key
+type blobRows interface {
+	Scan(table blobTable) blobIterator
+	SetContentKey(table blobTable, key string, row map[string]interface{}) error
+}
+
+// blobIterator is satisfied by *gocql.Iter
+type blobIterator interface {
+	MapScan(row map[string]interface{}) bool
+	Close() error
+}
+
+type cassandraBlobRows struct {
+	session *gocql.Session
+}
+
+func (rows cassandraBlobRows) Scan(table blobTable) blobIterator {
+	selectQuery := fmt.Sprintf("SELECT %s, %s FROM %s",
+		strings.Join(table.keyColumns, ", "), table.dataColumn, table.name)
+
+	//small pages, every row can hold several megabytes
+	return rows.session.Query(selectQuery).PageSize(20).Iter()
+}
+
+func (rows cassandraBlobRows) SetContentKey(table blobTable, key string, row map[string]interface{}) error {
+	var conditions []string
+	args := []interface{}{key}
+	for _, column := range table.keyColumns {
+		conditions = append(conditions, column+" = ?")
+		args = append(args, row[column])
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET content_key = ?, %s = null WHERE %s",
+		table.name, table.dataColumn, strings.Join(conditions, " AND "))
+	return rows.session.Query(update, args...).Exec()
+}
+
+// MigrateMediaBlobs hands every inline blob to put and replaces it with the returned
+// content key. Rows are cleared one by one, so an interrupted run can be started again
+func (sr *TweetRepo) MigrateMediaBlobs(ctx context.Context, put func(ctx context.Context, data []byte) (string, error)) (int, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.MigrateMediaBlobs")
+	defer span.End()
+
+	return sr.migrateBlobs(ctx, cassandraBlobRows{session: sr.session}, put)
+}
+
+func (sr *TweetRepo) migrateBlobs(ctx context.Context, rows blobRows, put func(ctx context.Context, data []byte) (string, error)) (int, error) {
+	migrated := 0
+	for _, table := range blobTables {
+		iter := rows.Scan(table)
+		for {
+			row := make(map[string]interface{})
+			if !iter.MapScan(row) {
+				break
+			}
+
+			data, _ := row[table.dataColumn].([]byte)
+			if len(data) == 0 {
+				continue
+			}
+
+			key, err := put(ctx, data)
+			if err != nil {
+				iter.Close()
+				return migrated, err
+			}
+
+			err = rows.SetContentKey(table, key, row)
+			if err != nil {
+				sr.logging.Errorln(err)
+				iter.Close()
+				return migrated, err
+			}
+			migrated++
+		}
+
+		err := iter.Close()
+		if err != nil {
+			sr.logging.Errorln(err)
+			return migrated, err
+		}
+		sr.logger.Printf("media migration: %s done, %d blobs moved so far", table.name, migrated)
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// memoryBlobRows keeps the blob tables in memory, rows are matched by their key columns
+type memoryBlobRows struct {
+	tables map[string][]map[string]interface{}
+}
+
+type memoryIterator struct {
+	rows []map[string]interface{}
+}
+
+func (iter *memoryIterator) MapScan(row map[string]interface{}) bool {
+	if len(iter.rows) == 0 {
+		return false
+	}
+	for column, value := range iter.rows[0] {
+		row[column] = value
+	}
+	iter.rows = iter.rows[1:]
+	return true
+}
+
+func (iter *memoryIterator) Close() error {
+	return nil
+}
+
+func (rows *memoryBlobRows) Scan(table blobTable) blobIterator {
+	return &memoryIterator{rows: append([]map[string]interface{}(nil), rows.tables[table.name]...)}
+}
+
+func (rows *memoryBlobRows) SetContentKey(table blobTable, key string, row map[string]interface{}) error {
+	for _, stored := range rows.tables[table.name] {
+		matches := true
+		for _, column := range table.keyColumns {
+			if stored[column] != row[column] {
+				matches = false
+			}
+		}
+		if matches {
+			stored["content_key"] = key
+			stored[table.dataColumn] = nil
+			return nil
+		}
+	}
+	return fmt.Errorf("no row in %s for %v", table.name, row)
+}
+
+func newTestTweetRepo() *TweetRepo {
+	logging := logrus.New()
+	logging.SetOutput(io.Discard)
+	return &TweetRepo{
+		logger:  log.New(io.Discard, "", 0),
+		tracer:  trace.NewNoopTracerProvider().Tracer("test"),
+		logging: logging,
+	}
+}
+
+func newMemoryBlobRows() *memoryBlobRows {
+	tweetID := gocql.TimeUUID()
+	mediaID := gocql.TimeUUID()
+	return &memoryBlobRows{tables: map[string][]map[string]interface{}{
+		COLLECTION_TWEET_IMAGE: {
+			{"tweet_id": tweetID, "image": []byte("tweet image")},
+			{"tweet_id": gocql.TimeUUID(), "image": []byte(nil)},
+		},
+		COLLECTION_MEDIA: {
+			{"id": mediaID, "data": []byte("tweet image")},
+			{"id": gocql.TimeUUID(), "data": []byte("video")},
+		},
+		COLLECTION_MEDIA_VARIANT: {
+			{"media_id": mediaID, "size": "small", "data": []byte("small image")},
+			{"media_id": mediaID, "size": "medium", "data": []byte("medium image")},
+		},
+	}}
+}
+
+// countingPut stores blobs by content like MediaService.Put and counts the uploads
+type countingPut struct {
+	blobs   map[string][]byte
+	uploads int
+	failAt  int
+}
+
+func (put *countingPut) put(ctx context.Context, data []byte) (string, error) {
+	if put.failAt > 0 && put.uploads+1 == put.failAt {
+		put.failAt = 0
+		return "", fmt.Errorf("media store unavailable")
+	}
+
+	key := domain.ContentKey(data)
+	if _, ok := put.blobs[key]; !ok {
+		put.blobs[key] = append([]byte(nil), data...)
+	}
+	put.uploads++
+	return key, nil
+}
+
+func assertMigrated(t *testing.T, rows *memoryBlobRows, blobs map[string][]byte) {
+	t.Helper()
+
+	for _, table := range blobTables {
+		for _, row := range rows.tables[table.name] {
+			if data, _ := row[table.dataColumn].([]byte); len(data) > 0 {
+				t.Errorf("%s row %v still holds its blob", table.name, row)
+				continue
+			}
+			key, ok := row["content_key"].(string)
+			if !ok {
+				continue
+			}
+			if _, stored := blobs[key]; !stored {
+				t.Errorf("%s row points at %s which was never stored", table.name, key)
+			}
+		}
+	}
+}
+
+func TestMigrateMediaBlobsCanRunAgain(t *testing.T) {
+	repo := newTestTweetRepo()
+	rows := newMemoryBlobRows()
+	put := &countingPut{blobs: make(map[string][]byte)}
+
+	migrated, err := repo.migrateBlobs(context.Background(), rows, put.put)
+	if err != nil {
+		t.Fatalf("migrateBlobs() error = %v", err)
+	}
+	if migrated != 5 {
+		t.Errorf("first run migrated %d blobs, want 5", migrated)
+	}
+	//the same bytes in two tables are stored once
+	if len(put.blobs) != 4 {
+		t.Errorf("stored %d blobs, want 4", len(put.blobs))
+	}
+	assertMigrated(t, rows, put.blobs)
+
+	migrated, err = repo.migrateBlobs(context.Background(), rows, put.put)
+	if err != nil {
+		t.Fatalf("second migrateBlobs() error = %v", err)
+	}
+	if migrated != 0 {
+		t.Errorf("second run migrated %d blobs, want 0", migrated)
+	}
+	if put.uploads != 5 {
+		t.Errorf("second run uploaded again, %d uploads in total", put.uploads)
+	}
+}
+
+func TestMigrateMediaBlobsResumesAfterAFailure(t *testing.T) {
+	repo := newTestTweetRepo()
+	rows := newMemoryBlobRows()
+	put := &countingPut{blobs: make(map[string][]byte), failAt: 3}
+
+	migrated, err := repo.migrateBlobs(context.Background(), rows, put.put)
+	if err == nil {
+		t.Fatal("migrateBlobs() ignored the failed upload")
+	}
+	if migrated != 2 {
+		t.Errorf("interrupted run migrated %d blobs, want 2", migrated)
+	}
+
+	migrated, err = repo.migrateBlobs(context.Background(), rows, put.put)
+	if err != nil {
+		t.Fatalf("resumed migrateBlobs() error = %v", err)
+	}
+	if migrated != 3 {
+		t.Errorf("resumed run migrated %d blobs, want the remaining 3", migrated)
+	}
+	if put.uploads != 5 {
+		t.Errorf("uploaded %d times, want every blob once", put.uploads)
+	}
+	assertMigrated(t, rows, put.blobs)
+}
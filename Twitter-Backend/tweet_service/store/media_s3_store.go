@@ -0,0 +1,94 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// S3MediaStore works with any S3 compatible service, MinIO included
+type S3MediaStore struct {
+	client *minio.Client
+	bucket string
+	tracer trace.Tracer
+}
+
+func NewS3MediaStore(endpoint string, bucket string, accessKey string, secretKey string, useSSL bool, tracer trace.Tracer) (domain.MediaStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		err = client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3MediaStore{
+		client: client,
+		bucket: bucket,
+		tracer: tracer,
+	}, nil
+}
+
+func (s3 *S3MediaStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	ctx, span := s3.tracer.Start(ctx, "S3MediaStore.Put")
+	defer span.End()
+
+	_, err := s3.client.PutObject(ctx, s3.bucket, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s3 *S3MediaStore) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := s3.tracer.Start(ctx, "S3MediaStore.Get")
+	defer span.End()
+
+	object, err := s3.client.GetObject(ctx, s3.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf(errors.MediaNotFound)
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s3 *S3MediaStore) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, span := s3.tracer.Start(ctx, "S3MediaStore.Exists")
+	defer span.End()
+
+	_, err := s3.client.StatObject(ctx, s3.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testBucket    = "media"
+	testAccessKey = "access"
+	testSecretKey = "secret1234"
+)
+
+// fakeS3 answers the path style S3 requests S3MediaStore sends, objects are kept in memory
+type fakeS3 struct {
+	mutex       sync.Mutex
+	buckets     map[string]bool
+	objects     map[string][]byte
+	types       map[string]string
+	unsigned    int
+	denyObjects bool
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+
+	s3 := &fakeS3{
+		buckets: make(map[string]bool),
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	}
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+	return s3, server
+}
+
+func (s3 *fakeS3) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	s3.mutex.Lock()
+	defer s3.mutex.Unlock()
+
+	if !strings.Contains(req.Header.Get("Authorization"), "Credential="+testAccessKey+"/") {
+		s3.unsigned++
+		s3.error(writer, req, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	if _, ok := req.URL.Query()["location"]; ok {
+		writer.Header().Set("Content-Type", "application/xml")
+		_, _ = io.WriteString(writer, `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+		return
+	}
+
+	if key == "" {
+		switch req.Method {
+		case http.MethodHead:
+			if !s3.buckets[bucket] {
+				s3.error(writer, req, http.StatusNotFound, "NoSuchBucket")
+			}
+		case http.MethodPut:
+			s3.buckets[bucket] = true
+		default:
+			s3.error(writer, req, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		}
+		return
+	}
+
+	if s3.denyObjects {
+		s3.error(writer, req, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	if !s3.buckets[bucket] {
+		s3.error(writer, req, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	name := bucket + "/" + key
+	switch req.Method {
+	case http.MethodPut:
+		data, err := readS3Body(req)
+		if err != nil {
+			s3.error(writer, req, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s3.objects[name] = data
+		s3.types[name] = req.Header.Get("Content-Type")
+		writer.Header().Set("ETag", `"`+domain.ContentKey(data)[:32]+`"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := s3.objects[name]
+		if !ok {
+			s3.error(writer, req, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		writer.Header().Set("Content-Type", s3.types[name])
+		writer.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		writer.Header().Set("ETag", `"`+domain.ContentKey(data)[:32]+`"`)
+		writer.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if req.Method == http.MethodGet {
+			_, _ = writer.Write(data)
+		}
+	default:
+		s3.error(writer, req, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s3 *fakeS3) error(writer http.ResponseWriter, req *http.Request, status int, code string) {
+	writer.Header().Set("Content-Type", "application/xml")
+	writer.WriteHeader(status)
+	if req.Method != http.MethodHead {
+		_, _ = fmt.Fprintf(writer, `<Error><Code>%s</Code><Message>%s</Message><Resource>%s</Resource></Error>`,
+			code, code, req.URL.Path)
+	}
+}
+
+// readS3Body reads a plain body or one sent with the aws-chunked streaming signature
+func readS3Body(req *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(req.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(req.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(req.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		_, err = io.CopyN(&data, reader, size)
+		if err != nil {
+			return nil, err
+		}
+		_, err = reader.Discard(2)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newTestS3MediaStore(t *testing.T, server *httptest.Server) domain.MediaStore {
+	t.Helper()
+
+	store, err := NewS3MediaStore(strings.TrimPrefix(server.URL, "http://"), testBucket, testAccessKey, testSecretKey,
+		false, trace.NewNoopTracerProvider().Tracer("test"))
+	if err != nil {
+		t.Fatalf("NewS3MediaStore() error = %v", err)
+	}
+	return store
+}
+
+func TestS3MediaStoreCreatesTheBucket(t *testing.T) {
+	s3, server := newFakeS3(t)
+
+	newTestS3MediaStore(t, server)
+	if !s3.buckets[testBucket] {
+		t.Fatal("NewS3MediaStore() did not create the bucket")
+	}
+
+	//a second store finds the bucket instead of failing to create it again
+	newTestS3MediaStore(t, server)
+	if s3.unsigned != 0 {
+		t.Errorf("%d requests were not signed with the access key", s3.unsigned)
+	}
+}
+
+func TestS3MediaStoreRoundTrip(t *testing.T) {
+	s3, server := newFakeS3(t)
+	store := newTestS3MediaStore(t, server)
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("video bytes "), 1000)
+	key := domain.ContentKey(data)
+
+	exists, err := store.Exists(ctx, key)
+	if err != nil || exists {
+		t.Fatalf("Exists() before Put = %t, %v", exists, err)
+	}
+
+	err = store.Put(ctx, key, data, "video/mp4")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if !bytes.Equal(s3.objects[testBucket+"/"+key], data) {
+		t.Fatal("the object in the bucket differs from the bytes put")
+	}
+	if s3.types[testBucket+"/"+key] != "video/mp4" {
+		t.Errorf("object stored as %q, want video/mp4", s3.types[testBucket+"/"+key])
+	}
+
+	exists, err = store.Exists(ctx, key)
+	if err != nil || !exists {
+		t.Fatalf("Exists() after Put = %t, %v", exists, err)
+	}
+
+	got, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get() returned %d bytes, want the %d bytes put", len(got), len(data))
+	}
+}
+
+func TestS3MediaStoreMissingObject(t *testing.T) {
+	_, server := newFakeS3(t)
+	store := newTestS3MediaStore(t, server)
+
+	_, err := store.Get(context.Background(), "missing")
+	if err == nil || err.Error() != errors.MediaNotFound {
+		t.Errorf("Get() of a missing object error = %v, want %q", err, errors.MediaNotFound)
+	}
+}
+
+func TestS3MediaStoreReportsDeniedRequests(t *testing.T) {
+	s3, server := newFakeS3(t)
+	store := newTestS3MediaStore(t, server)
+	ctx := context.Background()
+	s3.denyObjects = true
+
+	if _, err := store.Exists(ctx, "key"); err == nil {
+		t.Error("Exists() hid a denied request as a missing object")
+	}
+	if _, err := store.Get(ctx, "key"); err == nil || err.Error() == errors.MediaNotFound {
+		t.Errorf("Get() error = %v, want the denied request", err)
+	}
+	if err := store.Put(ctx, "key", []byte("data"), "image/png"); err == nil {
+		t.Error("Put() succeeded although the request was denied")
+	}
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	COLLECTION_POLL       = "poll"
+	COLLECTION_POLL_VOTE  = "poll_vote"
+	COLLECTION_POLL_TALLY = "poll_tally"
+
+	//times the tally is written before a recorded vote is taken back
+	tallyAttempts = 3
+)
+
+func (sr *TweetRepo) createPollTables() {
+	err := sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (tweet_id UUID, options list<text>, closes_at bigint, PRIMARY KEY ((tweet_id)))",
+			COLLECTION_POLL)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(tweet_id UUID, username text, option int, voted_at bigint,
+					PRIMARY KEY ((tweet_id), username))`,
+			COLLECTION_POLL_VOTE)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (tweet_id UUID, option int, votes counter, PRIMARY KEY ((tweet_id), option))",
+			COLLECTION_POLL_TALLY)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+}
+
+func (sr *TweetRepo) SavePoll(ctx context.Context, tweetID gocql.UUID, poll *domain.Poll) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SavePoll")
+	defer span.End()
+
+	sr.logging.Infoln("Store: savePoll reached")
+
+	options := make([]string, len(poll.Options))
+	for i, option := range poll.Options {
+		options[i] = option.Text
+	}
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (tweet_id, options, closes_at) VALUES (?, ?, ?)", COLLECTION_POLL),
+		tweetID, options, poll.ClosesAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) GetPolls(ctx context.Context, tweetIDs []gocql.UUID) (map[gocql.UUID]*domain.Poll, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetPolls")
+	defer span.End()
+
+	polls := make(map[gocql.UUID]*domain.Poll)
+	if len(tweetIDs) == 0 {
+		return polls, nil
+	}
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, options, closes_at FROM %s WHERE tweet_id IN ?", COLLECTION_POLL), tweetIDs).
+		Iter().Scanner()
+
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		var options []string
+		var poll domain.Poll
+		err := scanner.Scan(&tweetID, &options, &poll.ClosesAt)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		for _, option := range options {
+			poll.Options = append(poll.Options, domain.PollOption{Text: option})
+		}
+		polls[tweetID] = &poll
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return polls, nil
+}
+
+// SaveVote records the vote unless the user already voted, the tally is only counted for
+// an applied vote. When the tally can't be counted the vote is taken back, so the user can
+// vote again instead of being left with a vote that is missing from the results
+func (sr *TweetRepo) SaveVote(ctx context.Context, tweetID gocql.UUID, username string, option int) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveVote")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveVote reached")
+
+	applied, err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (tweet_id, username, option, voted_at) VALUES (?, ?, ?, ?) IF NOT EXISTS", COLLECTION_POLL_VOTE),
+		tweetID, username, option, time.Now().Unix()).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+	if !applied {
+		return false, nil
+	}
+
+	for attempt := 1; attempt <= tallyAttempts; attempt++ {
+		err = sr.session.Query(
+			fmt.Sprintf("UPDATE %s SET votes = votes + 1 WHERE tweet_id = ? AND option = ?", COLLECTION_POLL_TALLY),
+			tweetID, option).Exec()
+		if err == nil {
+			return true, nil
+		}
+		sr.logging.Errorln(err)
+	}
+
+	undo := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE tweet_id = ? AND username = ? IF EXISTS", COLLECTION_POLL_VOTE),
+		tweetID, username).Exec()
+	if undo != nil {
+		sr.logging.Errorf("TweetStore.SaveVote : vote of %s on %s is not counted: %s", username, tweetID, undo)
+	}
+
+	return false, err
+}
+
+// GetVotes returns the options username voted for in the given polls
+func (sr *TweetRepo) GetVotes(ctx context.Context, tweetIDs []gocql.UUID, username string) (map[gocql.UUID]int, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetVotes")
+	defer span.End()
+
+	votes := make(map[gocql.UUID]int)
+	if len(tweetIDs) == 0 || username == "" {
+		return votes, nil
+	}
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, option FROM %s WHERE tweet_id IN ? AND username = ?", COLLECTION_POLL_VOTE),
+		tweetIDs, username).Iter().Scanner()
+
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		var option int
+		err := scanner.Scan(&tweetID, &option)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		votes[tweetID] = option
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return votes, nil
+}
+
+func (sr *TweetRepo) GetTallies(ctx context.Context, tweetIDs []gocql.UUID) (map[gocql.UUID]map[int]int, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetTallies")
+	defer span.End()
+
+	tallies := make(map[gocql.UUID]map[int]int)
+	if len(tweetIDs) == 0 {
+		return tallies, nil
+	}
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, option, votes FROM %s WHERE tweet_id IN ?", COLLECTION_POLL_TALLY), tweetIDs).
+		Iter().Scanner()
+
+	for scanner.Next() {
+		var tweetID gocql.UUID
+		var option int
+		var votes int64
+		err := scanner.Scan(&tweetID, &option, &votes)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		if tallies[tweetID] == nil {
+			tallies[tweetID] = make(map[int]int)
+		}
+		tallies[tweetID][option] = int(votes)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return tallies, nil
+}
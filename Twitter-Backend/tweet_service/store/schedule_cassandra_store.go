@@ -0,0 +1,299 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tweet_service/domain"
+	"tweet_service/errors"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	COLLECTION_SCHEDULED     = "scheduled_tweet"
+	COLLECTION_SCHEDULED_DUE = "scheduled_due"
+
+	//pending tweets are few, so the due index is a single partition read in publish_at order
+	dueShard = 0
+
+	scheduledColumns = "id, username, text, advertisement, publish_at, status, error, created_at, updated_at, user_type, claimed_by, claimed_at, " +
+		"attempts, links"
+)
+
+func (sr *TweetRepo) createScheduleTables() {
+	err := sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(username text, id UUID, text text, advertisement boolean, publish_at bigint, status text,
+					error text, created_at bigint, updated_at bigint, user_type text, claimed_by text, claimed_at bigint,
+					attempts int, links map<text, text>,
+					PRIMARY KEY ((username), id))`,
+			COLLECTION_SCHEDULED)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	//columns added after the initial schema, errors are expected when they already exist
+	err = sr.session.Query(
+		fmt.Sprintf("ALTER TABLE %s ADD (attempts int, links map<text, text>)", COLLECTION_SCHEDULED)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", COLLECTION_SCHEDULED, err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(shard int, publish_at bigint, id UUID, username text,
+					PRIMARY KEY ((shard), publish_at, id))
+					WITH CLUSTERING ORDER BY (publish_at ASC, id ASC)`,
+			COLLECTION_SCHEDULED_DUE)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+}
+
+func (sr *TweetRepo) SaveScheduled(ctx context.Context, item *domain.ScheduledTweet) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveScheduled")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveScheduled reached")
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (%s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_SCHEDULED, scheduledColumns),
+		item.ID, item.Username, item.Text, item.Advertisement, item.PublishAt, string(item.Status), item.Error,
+		item.CreatedAt, item.UpdatedAt, item.UserType, item.ClaimedBy, item.ClaimedAt, item.Attempts, item.Links).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) GetScheduled(ctx context.Context, username string, id gocql.UUID) (*domain.ScheduledTweet, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetScheduled")
+	defer span.End()
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT %s FROM %s WHERE username = ? AND id = ?", scheduledColumns, COLLECTION_SCHEDULED),
+		username, id).Iter().Scanner()
+
+	var items []*domain.ScheduledTweet
+	for scanner.Next() {
+		item, err := scanScheduled(scanner)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf(errors.ScheduledNotFound)
+	}
+	return items[0], nil
+}
+
+func (sr *TweetRepo) GetScheduledByUser(ctx context.Context, username string) ([]*domain.ScheduledTweet, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetScheduledByUser")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getScheduledByUser reached")
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT %s FROM %s WHERE username = ?", scheduledColumns, COLLECTION_SCHEDULED), username).
+		Iter().Scanner()
+
+	var items []*domain.ScheduledTweet
+	for scanner.Next() {
+		item, err := scanScheduled(scanner)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// UpdateScheduled writes the editable fields of the item if its status and publish time
+// are still the ones it was read with. The attempts and links of an earlier publish are
+// written too, an edit starts over with the ones of the item
+func (sr *TweetRepo) UpdateScheduled(ctx context.Context, item *domain.ScheduledTweet, status domain.ScheduleStatus, publishAt int64) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.UpdateScheduled")
+	defer span.End()
+
+	sr.logging.Infoln("Store: updateScheduled reached")
+
+	applied, err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET text = ?, advertisement = ?, publish_at = ?, status = ?, error = ?, updated_at = ?, "+
+			"attempts = ?, links = ? WHERE username = ? AND id = ? IF status = ? AND publish_at = ?", COLLECTION_SCHEDULED),
+		item.Text, item.Advertisement, item.PublishAt, string(item.Status), item.Error, item.UpdatedAt, item.Attempts, item.Links,
+		item.Username, item.ID, string(status), publishAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+// DeleteScheduled removes the item unless it was claimed since it was read
+func (sr *TweetRepo) DeleteScheduled(ctx context.Context, item *domain.ScheduledTweet) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.DeleteScheduled")
+	defer span.End()
+
+	sr.logging.Infoln("Store: deleteScheduled reached")
+
+	applied, err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE username = ? AND id = ? IF status = ? AND publish_at = ?", COLLECTION_SCHEDULED),
+		item.Username, item.ID, string(item.Status), item.PublishAt).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+// ClaimScheduled marks the item as being published by replica and counts the attempt. A
+// scheduled item is claimed if its publish time is unchanged, a publishing item only if its
+// old claim is still in place
+func (sr *TweetRepo) ClaimScheduled(ctx context.Context, item *domain.ScheduledTweet, replica string, now int64) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.ClaimScheduled")
+	defer span.End()
+
+	update := fmt.Sprintf("UPDATE %s SET status = ?, claimed_by = ?, claimed_at = ?, attempts = ? WHERE username = ? AND id = ? ", COLLECTION_SCHEDULED)
+
+	var query *gocql.Query
+	switch item.Status {
+	case domain.ScheduleScheduled:
+		query = sr.session.Query(update+"IF status = ? AND publish_at = ?",
+			string(domain.SchedulePublishing), replica, now, item.Attempts+1, item.Username, item.ID,
+			string(domain.ScheduleScheduled), item.PublishAt)
+	case domain.SchedulePublishing:
+		query = sr.session.Query(update+"IF status = ? AND claimed_by = ? AND claimed_at = ?",
+			string(domain.SchedulePublishing), replica, now, item.Attempts+1, item.Username, item.ID,
+			string(domain.SchedulePublishing), item.ClaimedBy, item.ClaimedAt)
+	default:
+		return false, nil
+	}
+
+	applied, err := query.MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+// CompleteScheduled records the outcome of a claim, it does nothing if the claim was taken over
+func (sr *TweetRepo) CompleteScheduled(ctx context.Context, item *domain.ScheduledTweet, replica string, status domain.ScheduleStatus, reason string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.CompleteScheduled")
+	defer span.End()
+
+	_, err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET status = ?, error = ?, updated_at = ? WHERE username = ? AND id = ? IF claimed_by = ?", COLLECTION_SCHEDULED),
+		string(status), reason, time.Now().Unix(), item.Username, item.ID, replica).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// SaveScheduledLinks keeps the short link codes of the item while replica holds the claim
+func (sr *TweetRepo) SaveScheduledLinks(ctx context.Context, item *domain.ScheduledTweet, replica string) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveScheduledLinks")
+	defer span.End()
+
+	applied, err := sr.session.Query(
+		fmt.Sprintf("UPDATE %s SET links = ? WHERE username = ? AND id = ? IF claimed_by = ?", COLLECTION_SCHEDULED),
+		item.Links, item.Username, item.ID, replica).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return applied, nil
+}
+
+func (sr *TweetRepo) AddDue(ctx context.Context, entry *domain.DueEntry) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.AddDue")
+	defer span.End()
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (shard, publish_at, id, username) VALUES (?, ?, ?, ?)", COLLECTION_SCHEDULED_DUE),
+		dueShard, entry.PublishAt, entry.ID, entry.Username).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) RemoveDue(ctx context.Context, entry *domain.DueEntry) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.RemoveDue")
+	defer span.End()
+
+	err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE shard = ? AND publish_at = ? AND id = ?", COLLECTION_SCHEDULED_DUE),
+		dueShard, entry.PublishAt, entry.ID).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDue returns the oldest entries with a publish time up to before
+func (sr *TweetRepo) GetDue(ctx context.Context, before int64, limit int) ([]*domain.DueEntry, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetDue")
+	defer span.End()
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT publish_at, id, username FROM %s WHERE shard = ? AND publish_at <= ? LIMIT ?", COLLECTION_SCHEDULED_DUE),
+		dueShard, before, limit).Iter().Scanner()
+
+	var entries []*domain.DueEntry
+	for scanner.Next() {
+		var entry domain.DueEntry
+		err := scanner.Scan(&entry.PublishAt, &entry.ID, &entry.Username)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+func scanScheduled(scanner gocql.Scanner) (*domain.ScheduledTweet, error) {
+	var item domain.ScheduledTweet
+	var status string
+	err := scanner.Scan(&item.ID, &item.Username, &item.Text, &item.Advertisement, &item.PublishAt, &status, &item.Error,
+		&item.CreatedAt, &item.UpdatedAt, &item.UserType, &item.ClaimedBy, &item.ClaimedAt, &item.Attempts, &item.Links)
+	if err != nil {
+		return nil, err
+	}
+	item.Status = domain.ScheduleStatus(status)
+	return &item, nil
+}
@@ -0,0 +1,285 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"tweet_service/domain"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	COLLECTION_TIMELINE       = "timeline"
+	COLLECTION_TIMELINE_BUILT = "timeline_built"
+	COLLECTION_CELEBRITY      = "celebrity"
+
+	//timeline entries expire after 30 days, older tweets are only reachable through the profile
+	timelineTTL = 30 * 24 * 60 * 60
+)
+
+func (sr *TweetRepo) createTimelineTables() {
+	err := sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(username text, created_at bigint, tweet_id UUID, author text,
+					PRIMARY KEY ((username), created_at, tweet_id))
+					WITH CLUSTERING ORDER BY (created_at DESC, tweet_id ASC)`,
+			COLLECTION_TIMELINE)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (username text, built_at bigint, PRIMARY KEY ((username)))",
+			COLLECTION_TIMELINE_BUILT)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	err = sr.session.Query(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (username text, followers int, PRIMARY KEY ((username)))",
+			COLLECTION_CELEBRITY)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	//rows written before the column existed read as never checked
+	err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD checked_at bigint", COLLECTION_CELEBRITY)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", COLLECTION_CELEBRITY, err.Error())
+	}
+}
+
+// AddToTimelines writes every tweet into the timeline of every user, rows are keyed by
+// the tweet creation time so writing the same tweet twice is harmless
+func (sr *TweetRepo) AddToTimelines(ctx context.Context, usernames []string, tweets []*domain.Tweet) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.AddToTimelines")
+	defer span.End()
+
+	sr.logging.Infoln("Store: addToTimelines reached")
+
+	insert := fmt.Sprintf("INSERT INTO %s (username, created_at, tweet_id, author) VALUES (?, ?, ?, ?) USING TTL %d",
+		COLLECTION_TIMELINE, timelineTTL)
+
+	for _, username := range usernames {
+		for _, tweet := range tweets {
+			err := sr.session.Query(insert, username, tweet.CreatedAt, tweet.ID, tweet.Username).Exec()
+			if err != nil {
+				sr.logging.Errorln(err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemoveFromTimeline drops the tweets of author from the timeline of username
+func (sr *TweetRepo) RemoveFromTimeline(ctx context.Context, username string, author string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.RemoveFromTimeline")
+	defer span.End()
+
+	sr.logging.Infoln("Store: removeFromTimeline reached")
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE username = ? AND created_at = ? AND tweet_id = ?", COLLECTION_TIMELINE)
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT created_at, tweet_id, author FROM %s WHERE username = ?", COLLECTION_TIMELINE), username).
+		Iter().Scanner()
+	for scanner.Next() {
+		var createdAt int64
+		var tweetID gocql.UUID
+		var entryAuthor string
+		err := scanner.Scan(&createdAt, &tweetID, &entryAuthor)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+
+		if entryAuthor != author {
+			continue
+		}
+
+		err = sr.session.Query(deleteQuery, username, createdAt, tweetID).Exec()
+		if err != nil {
+			sr.logging.Errorln(err)
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetTimeline returns the newest tweets of the timeline, hidden tweets are left out
+func (sr *TweetRepo) GetTimeline(ctx context.Context, username string, limit int) ([]*domain.Tweet, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetTimeline")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getTimeline reached")
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id FROM %s WHERE username = ? LIMIT ?", COLLECTION_TIMELINE), username, limit).
+		Iter().Scanner()
+
+	var ids []gocql.UUID
+	for scanner.Next() {
+		var id gocql.UUID
+		err := scanner.Scan(&id)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+
+	return sr.GetTweetsByIds(ctx, ids)
+}
+
+func (sr *TweetRepo) IsTimelineBuilt(ctx context.Context, username string) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.IsTimelineBuilt")
+	defer span.End()
+
+	var builtAt int64
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT built_at FROM %s WHERE username = ?", COLLECTION_TIMELINE_BUILT), username).
+		Scan(&builtAt)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (sr *TweetRepo) MarkTimelineBuilt(ctx context.Context, username string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.MarkTimelineBuilt")
+	defer span.End()
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (username, built_at) VALUES (?, ?)", COLLECTION_TIMELINE_BUILT),
+		username, time.Now().Unix()).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) IsCelebrity(ctx context.Context, username string) (bool, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.IsCelebrity")
+	defer span.End()
+
+	var followers int
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT followers FROM %s WHERE username = ?", COLLECTION_CELEBRITY), username).
+		Scan(&followers)
+	if err == gocql.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		sr.logging.Errorln(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetCelebrity returns nil if the user is not a celebrity
+func (sr *TweetRepo) GetCelebrity(ctx context.Context, username string) (*domain.Celebrity, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetCelebrity")
+	defer span.End()
+
+	celebrity := domain.Celebrity{Username: username}
+	err := sr.session.Query(
+		fmt.Sprintf("SELECT followers, checked_at FROM %s WHERE username = ?", COLLECTION_CELEBRITY), username).
+		Scan(&celebrity.Followers, &celebrity.CheckedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+
+	return &celebrity, nil
+}
+
+// SetCelebrity marks the user as a celebrity, or records that a celebrity still is one
+func (sr *TweetRepo) SetCelebrity(ctx context.Context, username string, followers int) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SetCelebrity")
+	defer span.End()
+
+	sr.logging.Infoln("Store: setCelebrity reached")
+
+	err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (username, followers, checked_at) VALUES (?, ?, ?)", COLLECTION_CELEBRITY),
+		username, followers, time.Now().Unix()).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) ClearCelebrity(ctx context.Context, username string) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.ClearCelebrity")
+	defer span.End()
+
+	sr.logging.Infoln("Store: clearCelebrity reached")
+
+	err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE username = ?", COLLECTION_CELEBRITY), username).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCelebrities returns the usernames from the list that are read at feed time
+func (sr *TweetRepo) GetCelebrities(ctx context.Context, usernames []string) ([]string, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetCelebrities")
+	defer span.End()
+
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	query := sr.session.Query(
+		fmt.Sprintf("SELECT username FROM %s WHERE username IN ?", COLLECTION_CELEBRITY), usernames)
+	query.PageSize(0)
+	scanner := query.Iter().Scanner()
+
+	var celebrities []string
+	for scanner.Next() {
+		var username string
+		err := scanner.Scan(&username)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		celebrities = append(celebrities, username)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return celebrities, nil
+}
@@ -20,8 +20,14 @@ const (
 	COLLECTION_FAVORITE    = "favorite"
 	COLLECTION_RETWEET     = "retweet"
 	COLLECTION_TWEET_IMAGE = "tweet_image"
+	COLLECTION_MODERATION  = "moderation_record"
 )
 
+// tweetColumns lists the tweet columns explicitly so that scans don't depend
+// on the column order Cassandra uses for SELECT *
+const tweetColumns = "id, created_at, advertisement, favorite_count, favorited, image, owner_username, " +
+	"retweet_count, retweeted, text, username, hidden, previews, media, retweet_of"
+
 type TweetRepo struct {
 	session *gocql.Session
 	logger  *log.Logger
@@ -106,19 +112,72 @@ func (sr *TweetRepo) CreateTables() {
 	if err != nil {
 		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
 	}
+
+	err = sr.session.Query(
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+					(verdict text, created_at bigint, tweet_id UUID, username text, text text, reason text, stage text,
+					PRIMARY KEY ((verdict), created_at, tweet_id))
+					WITH CLUSTERING ORDER BY (created_at DESC, tweet_id ASC)`,
+			COLLECTION_MODERATION)).Exec()
+	if err != nil {
+		sr.logger.Printf("CASSANDRA CREATE TABLE ERR: %s", err.Error())
+	}
+
+	sr.createLinkTables()
+	sr.createMediaTables()
+	sr.createTimelineTables()
+	sr.createBookmarkTables()
+	sr.createScheduleTables()
+	sr.createPollTables()
+	sr.createEngagementTables()
+
+	//media bytes live in the media store, the tables only keep the content key
+	for _, table := range []string{COLLECTION_TWEET_IMAGE, COLLECTION_MEDIA, COLLECTION_MEDIA_VARIANT} {
+		err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD content_key text", table)).Exec()
+		if err != nil {
+			sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", table, err.Error())
+		}
+	}
+
+	//columns added after the initial schema, errors are expected when they already exist
+	for _, table := range []string{COLLECTION, COLLECTION_BY_USER} {
+		err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD hidden boolean", table)).Exec()
+		if err != nil {
+			sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", table, err.Error())
+		}
+
+		err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD previews list<frozen<%s>>", table, TYPE_LINK_PREVIEW)).Exec()
+		if err != nil {
+			sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", table, err.Error())
+		}
+
+		err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD media list<frozen<%s>>", table, TYPE_MEDIA_INFO)).Exec()
+		if err != nil {
+			sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", table, err.Error())
+		}
+
+		err = sr.session.Query(fmt.Sprintf("ALTER TABLE %s ADD retweet_of UUID", table)).Exec()
+		if err != nil {
+			sr.logger.Printf("CASSANDRA ALTER TABLE %s: %s", table, err.Error())
+		}
+	}
+}
+
+func scanTweet(scanner gocql.Scanner, tweet *domain.Tweet) error {
+	return scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
+		&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username, &tweet.Hidden, &tweet.Previews, &tweet.Media, &tweet.RetweetOf)
 }
 
 // insert into tweet (tweet_id, created_at, favorite_count, favorited, retweet_count, retweeted, text, user_id) values
 // (60089906-68d2-11ed-9022-0242ac120002, 1641540002, 0, false, 0, false, 'cao', dae71a94-68d2-11ed-9022-0242ac120002) ;
 func (sr *TweetRepo) GetAll(ctx context.Context) ([]domain.Tweet, error) {
-	scanner := sr.session.Query(`SELECT * FROM tweet`).Iter().Scanner()
+	scanner := sr.session.Query("SELECT "+tweetColumns+" FROM tweet").Iter().Scanner()
 	sr.logging.Infoln("Store: getAll reached")
 	var tweets []domain.Tweet
 	for scanner.Next() {
 		var tweet domain.Tweet
 
-		err := scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
-			&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username)
+		err := scanTweet(scanner, &tweet)
 		if err != nil {
 			sr.logging.Errorln(err)
 			return nil, err
@@ -140,14 +199,13 @@ func (sr *TweetRepo) GetOne(ctx context.Context, tweetID string) (*domain.Tweet,
 
 	sr.logging.Infoln("Store: getOne reached")
 
-	scanner := sr.session.Query(`SELECT * FROM tweet WHERE id = ?`, tweetID).Iter().Scanner()
+	scanner := sr.session.Query("SELECT "+tweetColumns+" FROM tweet WHERE id = ?", tweetID).Iter().Scanner()
 
 	var tweets []domain.Tweet
 	for scanner.Next() {
 		var tweet domain.Tweet
 
-		err := scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
-			&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username)
+		err := scanTweet(scanner, &tweet)
 		if err != nil {
 			sr.logging.Errorln(err)
 			return nil, err
@@ -160,6 +218,10 @@ func (sr *TweetRepo) GetOne(ctx context.Context, tweetID string) (*domain.Tweet,
 		sr.logging.Errorln(err)
 		return nil, err
 	}
+
+	if len(tweets) == 0 {
+		return nil, fmt.Errorf(errors.TweetNotFound)
+	}
 	return &tweets[0], nil
 }
 
@@ -168,18 +230,21 @@ func (sr *TweetRepo) GetTweetsByUser(ctx context.Context, username string) ([]*d
 	defer span.End()
 	sr.logging.Infoln("Store: tweetsByUser reached")
 
-	scanner := sr.session.Query(`SELECT * FROM tweets_by_user WHERE username = ?`, username).Iter().Scanner()
+	scanner := sr.session.Query("SELECT "+tweetColumns+" FROM tweets_by_user WHERE username = ?", username).Iter().Scanner()
 
 	var tweets []*domain.Tweet
 	for scanner.Next() {
 		var tweet domain.Tweet
-		err := scanner.Scan(&tweet.Username, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount,
-			&tweet.Favorited, &tweet.ID, &tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text)
+		err := scanTweet(scanner, &tweet)
 		if err != nil {
 			sr.logging.Errorln(err)
 			return nil, err
 		}
 
+		if tweet.Hidden {
+			continue
+		}
+
 		tweets = append(tweets, &tweet)
 	}
 
@@ -194,7 +259,7 @@ func (sr *TweetRepo) GetPostsFeedByUser(ctx context.Context, usernames []string)
 	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetPostsFeedByUser")
 	defer span.End()
 
-	query := sr.session.Query(`SELECT * FROM tweets_by_user WHERE username IN ? ORDER BY created_at DESC`, usernames)
+	query := sr.session.Query("SELECT "+tweetColumns+" FROM tweets_by_user WHERE username IN ? ORDER BY created_at DESC", usernames)
 
 	sr.logging.Infoln("Store: getFeed reached")
 
@@ -205,14 +270,55 @@ func (sr *TweetRepo) GetPostsFeedByUser(ctx context.Context, usernames []string)
 	var tweets []*domain.Tweet
 	for scanner.Next() {
 		var tweet domain.Tweet
-		err := scanner.Scan(&tweet.Username, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount,
-			&tweet.Favorited, &tweet.ID, &tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text)
+		err := scanTweet(scanner, &tweet)
+
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
 
+		if tweet.Hidden {
+			continue
+		}
+
+		tweets = append(tweets, &tweet)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return tweets, nil
+}
+
+// GetTweetsByIds returns the visible tweets among ids, in no particular order
+func (sr *TweetRepo) GetTweetsByIds(ctx context.Context, ids []gocql.UUID) ([]*domain.Tweet, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetTweetsByIds")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getTweetsByIds reached")
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := sr.session.Query("SELECT "+tweetColumns+" FROM tweet WHERE id IN ?", ids)
+	query.PageSize(0)
+	scanner := query.Iter().Scanner()
+
+	var tweets []*domain.Tweet
+	for scanner.Next() {
+		var tweet domain.Tweet
+		err := scanTweet(scanner, &tweet)
 		if err != nil {
 			sr.logging.Errorln(err)
 			return nil, err
 		}
 
+		if tweet.Hidden {
+			continue
+		}
+
 		tweets = append(tweets, &tweet)
 	}
 
@@ -227,21 +333,24 @@ func (sr *TweetRepo) GetRecommendAdsForUser(ctx context.Context, ids []string) (
 	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetRecommendAdsForUser")
 	defer span.End()
 	log.Printf("ads: %s", ids)
-	query := sr.session.Query(`SELECT * FROM tweet WHERE id IN ? ORDER BY created_at DESC`, ids)
+	query := sr.session.Query("SELECT "+tweetColumns+" FROM tweet WHERE id IN ? ORDER BY created_at DESC", ids)
 	query.PageSize(0)
 	scanner := query.Iter().Scanner()
 
 	var ads []*domain.Tweet
 	for scanner.Next() {
 		var tweet domain.Tweet
-		err := scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
-			&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username)
+		err := scanTweet(scanner, &tweet)
 
 		if err != nil {
 			sr.logger.Println(err)
 			return nil, err
 		}
 
+		if tweet.Hidden {
+			continue
+		}
+
 		ads = append(ads, &tweet)
 	}
 
@@ -259,20 +368,20 @@ func (sr *TweetRepo) Post(ctx context.Context, tweet *domain.Tweet) (*domain.Twe
 	sr.logging.Infoln("Store: post reached")
 
 	insert := fmt.Sprintf("INSERT INTO %s "+
-		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement) "+
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION)
+		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement, hidden, previews, media) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION)
 
 	insertByUser := fmt.Sprintf("INSERT INTO %s "+
-		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement) "+
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_BY_USER)
+		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement, hidden, previews, media) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_BY_USER)
 
 	err := sr.session.Query(
 		insert, tweet.ID, tweet.CreatedAt, tweet.FavoriteCount, tweet.Favorited,
-		tweet.RetweetCount, tweet.Retweeted, tweet.Text, tweet.Username, tweet.OwnerUsername, tweet.Image, tweet.Advertisement).Exec()
+		tweet.RetweetCount, tweet.Retweeted, tweet.Text, tweet.Username, tweet.OwnerUsername, tweet.Image, tweet.Advertisement, tweet.Hidden, tweet.Previews, tweet.Media).Exec()
 
 	err = sr.session.Query(
 		insertByUser, tweet.ID, tweet.CreatedAt, tweet.FavoriteCount, tweet.Favorited,
-		tweet.RetweetCount, tweet.Retweeted, tweet.Text, tweet.Username, tweet.OwnerUsername, tweet.Image, tweet.Advertisement).Exec()
+		tweet.RetweetCount, tweet.Retweeted, tweet.Text, tweet.Username, tweet.OwnerUsername, tweet.Image, tweet.Advertisement, tweet.Hidden, tweet.Previews, tweet.Media).Exec()
 
 	if err != nil {
 		sr.logging.Errorln(err)
@@ -281,15 +390,16 @@ func (sr *TweetRepo) Post(ctx context.Context, tweet *domain.Tweet) (*domain.Twe
 	return tweet, nil
 }
 
-func (sr *TweetRepo) SaveImage(ctx context.Context, tweetID gocql.UUID, imageBytes []byte) error {
+// SaveImage points a tweet at an image in the media store, the bytes are no longer kept in the row
+func (sr *TweetRepo) SaveImage(ctx context.Context, tweetID gocql.UUID, key string) error {
 	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveImage")
 	defer span.End()
 
 	sr.logging.Infoln("Store: saveImage reached")
 
-	insert := fmt.Sprintf("INSERT INTO %s (tweet_id, image) VALUES (?, ?)", COLLECTION_TWEET_IMAGE)
+	insert := fmt.Sprintf("INSERT INTO %s (tweet_id, content_key) VALUES (?, ?)", COLLECTION_TWEET_IMAGE)
 
-	err := sr.session.Query(insert, tweetID, imageBytes).Exec()
+	err := sr.session.Query(insert, tweetID, key).Exec()
 	if err != nil {
 		sr.logging.Errorln(err)
 		return err
@@ -298,123 +408,62 @@ func (sr *TweetRepo) SaveImage(ctx context.Context, tweetID gocql.UUID, imageByt
 	return nil
 }
 
+// Favorite favorites the tweet for username, or takes the favorite back when there is one.
+// It returns 201 for a favorite and 200 for an unfavorite. The favorite row is written with a
+// lightweight transaction so only a change that was applied moves the counter
 func (sr *TweetRepo) Favorite(ctx context.Context, tweetID string, username string) (int, error) {
 	ctx, span := sr.tracer.Start(ctx, "TweetStore.Favorite")
 	defer span.End()
 
 	sr.logging.Infoln("Store: favorite reached")
 
-	id, err := gocql.ParseUUID(tweetID)
+	tweet, err := sr.GetOne(ctx, tweetID)
 	if err != nil {
-		sr.logging.Errorln(err)
-		return -1, nil
-	}
-
-	scanner := sr.session.Query(`SELECT * FROM favorite WHERE tweet_id = ? AND username = ?`, id.String(), username).Iter().Scanner()
-
-	var favorites []*domain.Favorite
-
-	for scanner.Next() {
-		var favorite domain.Favorite
-		err = scanner.Scan(&favorite.TweetID, &favorite.Username, &favorite.ID)
-		if err != nil {
-			sr.logging.Errorln(err)
-			log.Printf("Error int TweetCassandraStore, Favorite(): %s", err.Error())
-			return 502, err
+		if err.Error() == errors.TweetNotFound {
+			return 404, err
 		}
-
-		favorites = append(favorites, &favorite)
-	}
-
-	scanner = sr.session.Query(`SELECT * FROM tweet WHERE id = ?`, id.String()).Iter().Scanner()
-
-	sr.logging.Infoln("Getting all tweets in favorite")
-	var tweetUsername string
-	var tweets []*domain.Tweet
-	for scanner.Next() {
-		var tweet domain.Tweet
-		err := scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
-			&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username)
-		tweetUsername = tweet.Username
-		if err != nil {
-			sr.logging.Errorln(err)
-			log.Printf("Error int TweetCassandraStore, Favorite(): %s", err.Error())
-			return 500, err
-		}
-
-		tweets = append(tweets, &tweet)
+		return 502, err
 	}
 
-	if len(tweets) == 0 {
-		sr.logging.Errorln("no such tweet")
-		log.Printf("Error int TweetCassandraStore, Favorite(): %s", err.Error())
-		return 500, nil
+	err = sr.seedEngagement(ctx, tweet)
+	if err != nil {
+		return 502, err
 	}
 
-	favorited := false
-	create := false
-	favoriteCount := 0
-	createdAt := tweets[0].CreatedAt
-
-	if len(favorites) != 0 {
-		favoriteCount = tweets[0].FavoriteCount - 1
-		favorited = false
-		create = false
-	} else {
-		favoriteCount = tweets[0].FavoriteCount + 1
-		favorited = true
-		create = true
+	idFav, _ := gocql.RandomUUID()
+	created, err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (id, tweet_id, username) VALUES (?, ?, ?) IF NOT EXISTS", COLLECTION_FAVORITE),
+		idFav, tweet.ID, username).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		sr.logging.Errorln(err)
+		return 502, err
 	}
 
-	isDeleted := false
-
-	if create {
-		insert := fmt.Sprintf("INSERT INTO %s "+"(id, tweet_id, username) "+"VALUES (?, ?, ?)", COLLECTION_FAVORITE)
-		idFav, _ := gocql.RandomUUID()
-
-		err = sr.session.Query(
-			insert, idFav.String(), tweets[0].ID.String(), username).Exec()
+	if created {
+		err = sr.incrementEngagement(ctx, tweet.ID, 1, 0)
 		if err != nil {
-			log.Printf("Error int TweetCassandraStore, Favorite(): %s", err.Error())
-			sr.logger.Println(err)
 			return 502, err
 		}
-
-	} else {
-		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE tweet_id = ? AND username = ?", COLLECTION_FAVORITE)
-
-		err = sr.session.Query(deleteQuery, id, username).Exec()
-
-		if err != nil {
-			sr.logging.Errorf(err.Error())
-			return 502, err
-		}
-
-		isDeleted = true
-	}
-
-	err = sr.session.Query(
-		`UPDATE tweet SET favorited=?, favorite_count=? where id=? and created_at = ?`, favorited, favoriteCount, id.String(), createdAt).Exec()
-
-	if err != nil {
-		sr.logging.Errorln(err)
-		return 502, err
+		return 201, nil
 	}
 
-	err = sr.session.Query(
-		`UPDATE tweets_by_user SET favorited=?, favorite_count=? where username=? and created_at=?`,
-		favorited, favoriteCount, tweetUsername, createdAt).Exec()
-
+	deleted, err := sr.session.Query(
+		fmt.Sprintf("DELETE FROM %s WHERE tweet_id = ? AND username = ? IF EXISTS", COLLECTION_FAVORITE),
+		tweet.ID, username).MapScanCAS(map[string]interface{}{})
 	if err != nil {
 		sr.logging.Errorln(err)
 		return 502, err
 	}
 
-	if isDeleted {
-		return 200, nil
+	//a concurrent unfavorite of the same user removed the row first and already counted it
+	if deleted {
+		err = sr.incrementEngagement(ctx, tweet.ID, -1, 0)
+		if err != nil {
+			return 502, err
+		}
 	}
 
-	return 201, nil
+	return 200, nil
 }
 
 func (sr *TweetRepo) GetLikesByTweet(ctx context.Context, tweetID string) ([]*domain.Favorite, error) {
@@ -450,115 +499,102 @@ func (sr *TweetRepo) GetLikesByTweet(ctx context.Context, tweetID string) ([]*do
 	return favorites, nil
 }
 
-func (sr *TweetRepo) GetTweetImage(ctx context.Context, id string) ([]byte, error) {
-	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetTweetImage")
+func (sr *TweetRepo) GetRetweetsByTweet(ctx context.Context, tweetID string) ([]*domain.Retweet, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetRetweetsByTweet")
 	defer span.End()
 
-	sr.logging.Infoln("Store: tweetImage reached")
+	sr.logging.Infoln("Store: getRetweetsByTweet reached")
 
-	scanner := sr.session.Query(`SELECT * FROM tweet_image WHERE tweet_id = ?`, id).Iter().Scanner()
+	id, err := gocql.ParseUUID(tweetID)
+	if err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
 
-	var byteImage []byte
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, username, id FROM %s WHERE tweet_id = ?", COLLECTION_RETWEET), id).Iter().Scanner()
+
+	var retweets []*domain.Retweet
 	for scanner.Next() {
-		err := scanner.Scan(nil, &byteImage)
+		var retweet domain.Retweet
+		err := scanner.Scan(&retweet.TweetID, &retweet.Username, &retweet.ID)
 		if err != nil {
 			sr.logging.Errorln(err)
 			return nil, err
 		}
+		retweets = append(retweets, &retweet)
 	}
 
 	if err := scanner.Err(); err != nil {
 		sr.logging.Errorln(err)
 		return nil, err
 	}
-	return byteImage, nil
+
+	return retweets, nil
 }
 
-func (sr *TweetRepo) Retweet(ctx context.Context, tweetID string, username string) (*gocql.UUID, int, error) {
-	ctx, span := sr.tracer.Start(ctx, "TweetStore.Retweet")
+func (sr *TweetRepo) GetTweetImage(ctx context.Context, id string) (*domain.BlobRef, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetTweetImage")
 	defer span.End()
 
-	sr.logging.Infoln("Store: retweet reached")
-
-	id, err := gocql.ParseUUID(tweetID)
-	if err != nil {
-		return nil, 500, nil
-	}
+	sr.logging.Infoln("Store: tweetImage reached")
 
-	scanner := sr.session.Query(`SELECT * FROM retweet WHERE tweet_id = ? AND username = ?`, id.String(), username).Iter().Scanner()
-
-	var retweets []*domain.Retweet
+	scanner := sr.session.Query(`SELECT image, content_key FROM tweet_image WHERE tweet_id = ?`, id).Iter().Scanner()
 
+	var ref domain.BlobRef
 	for scanner.Next() {
-		var retweet domain.Retweet
-		err = scanner.Scan(&retweet.TweetID, &retweet.Username, &retweet.ID)
+		err := scanner.Scan(&ref.Data, &ref.Key)
 		if err != nil {
 			sr.logging.Errorln(err)
-			return nil, 502, err
+			return nil, err
 		}
-		retweets = append(retweets, &retweet)
 	}
 
-	if len(retweets) != 0 {
-		sr.logging.Errorln("no retweets")
-		return nil, 406, fmt.Errorf(errors.RetweetAlreadyExist)
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
 	}
+	return &ref, nil
+}
 
-	scanner = sr.session.Query(`SELECT * FROM tweet WHERE id = ?`, id.String()).Iter().Scanner()
+// Retweet records the retweet with a lightweight transaction and posts the copy of the tweet,
+// a second retweet of the same user is rejected
+func (sr *TweetRepo) Retweet(ctx context.Context, tweetID string, username string) (*gocql.UUID, int, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.Retweet")
+	defer span.End()
 
-	var tweetUsername string
-	var tweets []*domain.Tweet
-	for scanner.Next() {
-		var tweet domain.Tweet
-		err := scanner.Scan(&tweet.ID, &tweet.CreatedAt, &tweet.Advertisement, &tweet.FavoriteCount, &tweet.Favorited,
-			&tweet.Image, &tweet.OwnerUsername, &tweet.RetweetCount, &tweet.Retweeted, &tweet.Text, &tweet.Username)
-		tweetUsername = tweet.Username
-		if err != nil {
-			sr.logging.Errorln(err)
-			return nil, 500, err
-		}
+	sr.logging.Infoln("Store: retweet reached")
 
-		tweets = append(tweets, &tweet)
+	thisTweet, err := sr.GetOne(ctx, tweetID)
+	if err != nil {
+		if err.Error() == errors.TweetNotFound {
+			return nil, 404, err
+		}
+		return nil, 502, err
 	}
 
-	if len(tweets) == 0 {
-		sr.logger.Println("No such tweet")
-		return nil, 500, nil
+	err = sr.seedEngagement(ctx, thisTweet)
+	if err != nil {
+		return nil, 502, err
 	}
 
-	retweetCount := tweets[0].RetweetCount + 1
-	retweeted := true
-	createdAt := tweets[0].CreatedAt
-
-	insert := fmt.Sprintf("INSERT INTO %s "+"(id, tweet_id, username) "+"VALUES (?, ?, ?)", COLLECTION_RETWEET)
 	retweetID, _ := gocql.RandomUUID()
-
-	err = sr.session.Query(
-		insert, retweetID.String(), tweets[0].ID.String(), username).Exec()
+	created, err := sr.session.Query(
+		fmt.Sprintf("INSERT INTO %s (id, tweet_id, username) VALUES (?, ?, ?) IF NOT EXISTS", COLLECTION_RETWEET),
+		retweetID, thisTweet.ID, username).MapScanCAS(map[string]interface{}{})
 	if err != nil {
 		sr.logging.Errorln(err)
 		return nil, 502, err
 	}
-
-	err = sr.session.Query(
-		`UPDATE tweet SET retweeted=?, retweet_count=? WHERE id=? AND created_at=?`, retweeted, retweetCount, id.String(), createdAt).Exec()
-
-	if err != nil {
-		sr.logger.Println(err)
-		return nil, 502, err
+	if !created {
+		return nil, 406, fmt.Errorf(errors.RetweetAlreadyExist)
 	}
 
-	err = sr.session.Query(
-		`UPDATE tweets_by_user SET retweeted=?, retweet_count=? WHERE username=? AND created_at=?`,
-		retweeted, retweetCount, tweetUsername, createdAt).Exec()
-
+	err = sr.incrementEngagement(ctx, thisTweet.ID, 0, 1)
 	if err != nil {
-		sr.logging.Errorln(err)
 		return nil, 502, err
 	}
 
-	thisTweet := tweets[0]
-
 	newID, err := gocql.RandomUUID()
 	if err != nil {
 		sr.logging.Errorln(err)
@@ -567,19 +603,25 @@ func (sr *TweetRepo) Retweet(ctx context.Context, tweetID string, username strin
 
 	timeNow := time.Now().Unix()
 
+	//the copy points at the original tweet, which keeps the poll
+	original := thisTweet.ID
+	if thisTweet.RetweetOf != nil {
+		original = *thisTweet.RetweetOf
+	}
+
 	insertTweet := fmt.Sprintf("INSERT INTO %s "+
-		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement) "+
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION)
+		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement, previews, media, retweet_of) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION)
 
 	insertByUser := fmt.Sprintf("INSERT INTO %s "+
-		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement) "+
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_BY_USER)
+		"(id, created_at, favorite_count, favorited, retweet_count, retweeted, text, username, owner_username, image, advertisement, previews, media, retweet_of) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", COLLECTION_BY_USER)
 
 	err = sr.session.Query(insertTweet,
-		newID, timeNow, 0, false, 0, false, thisTweet.Text, username, thisTweet.Username, thisTweet.Image, false).Exec()
+		newID, timeNow, 0, false, 0, false, thisTweet.Text, username, thisTweet.Username, thisTweet.Image, false, thisTweet.Previews, thisTweet.Media, original).Exec()
 
 	err = sr.session.Query(insertByUser,
-		newID, timeNow, 0, false, 0, false, thisTweet.Text, username, thisTweet.Username, thisTweet.Image, false).Exec()
+		newID, timeNow, 0, false, 0, false, thisTweet.Text, username, thisTweet.Username, thisTweet.Image, false, thisTweet.Previews, thisTweet.Media, original).Exec()
 
 	if err != nil {
 		sr.logging.Errorln(err)
@@ -589,3 +631,80 @@ func (sr *TweetRepo) Retweet(ctx context.Context, tweetID string, username strin
 	return &newID, 200, nil
 
 }
+
+func (sr *TweetRepo) SetHidden(ctx context.Context, tweetID string, hidden bool) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SetHidden")
+	defer span.End()
+
+	sr.logging.Infoln("Store: setHidden reached")
+
+	tweet, err := sr.GetOne(ctx, tweetID)
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		`UPDATE tweet SET hidden=? WHERE id=? AND created_at=?`, hidden, tweet.ID, tweet.CreatedAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	err = sr.session.Query(
+		`UPDATE tweets_by_user SET hidden=? WHERE username=? AND created_at=?`, hidden, tweet.Username, tweet.CreatedAt).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) SaveModerationRecord(ctx context.Context, record *domain.ModerationRecord) error {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.SaveModerationRecord")
+	defer span.End()
+
+	sr.logging.Infoln("Store: saveModerationRecord reached")
+
+	insert := fmt.Sprintf("INSERT INTO %s (verdict, created_at, tweet_id, username, text, reason, stage) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?)", COLLECTION_MODERATION)
+
+	err := sr.session.Query(insert, record.Verdict, record.CreatedAt, record.TweetID, record.Username,
+		record.Text, record.Reason, record.Stage).Exec()
+	if err != nil {
+		sr.logging.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (sr *TweetRepo) GetModerationRecords(ctx context.Context, verdict domain.ModerationVerdict) ([]*domain.ModerationRecord, error) {
+	ctx, span := sr.tracer.Start(ctx, "TweetStore.GetModerationRecords")
+	defer span.End()
+
+	sr.logging.Infoln("Store: getModerationRecords reached")
+
+	scanner := sr.session.Query(
+		fmt.Sprintf("SELECT tweet_id, username, text, verdict, reason, stage, created_at FROM %s WHERE verdict = ?",
+			COLLECTION_MODERATION), verdict).Iter().Scanner()
+
+	var records []*domain.ModerationRecord
+	for scanner.Next() {
+		var record domain.ModerationRecord
+		err := scanner.Scan(&record.TweetID, &record.Username, &record.Text, &record.Verdict,
+			&record.Reason, &record.Stage, &record.CreatedAt)
+		if err != nil {
+			sr.logging.Errorln(err)
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sr.logging.Errorln(err)
+		return nil, err
+	}
+	return records, nil
+}
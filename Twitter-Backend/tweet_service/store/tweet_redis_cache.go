@@ -52,3 +52,119 @@ func (cache *TweetRedisCache) GetCachedValue(ctx context.Context, key string) (*
 	}
 	return nil, nil
 }
+
+// Increment bumps a counter, the expiration is set only when the counter is created
+func (cache *TweetRedisCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	ctx, span := cache.tracer.Start(ctx, "TweetRedisCache.Increment")
+	defer span.End()
+
+	count, err := cache.client.Incr(key).Result()
+	if err != nil {
+		log.Printf("redis incr error: %s", err)
+		return 0, err
+	}
+
+	if count == 1 {
+		cache.client.Expire(key, expiration)
+	}
+
+	return count, nil
+}
+
+// GetCount reads a counter made by Increment, a missing counter is 0
+func (cache *TweetRedisCache) GetCount(ctx context.Context, key string) (int64, error) {
+	ctx, span := cache.tracer.Start(ctx, "TweetRedisCache.GetCount")
+	defer span.End()
+
+	count, err := cache.client.Get(key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("redis get error: %s", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// IncrementScore adds to the score of a sorted set member, the set expires after expiration
+func (cache *TweetRedisCache) IncrementScore(ctx context.Context, key string, member string, by float64, expiration time.Duration) error {
+	ctx, span := cache.tracer.Start(ctx, "TweetRedisCache.IncrementScore")
+	defer span.End()
+
+	pipe := cache.client.TxPipeline()
+	pipe.ZIncrBy(key, by, member)
+	pipe.Expire(key, expiration)
+	_, err := pipe.Exec()
+	if err != nil {
+		log.Printf("redis zincrby error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// TopMembers sums the scores of the sorted sets and returns the highest members
+func (cache *TweetRedisCache) TopMembers(ctx context.Context, keys []string, limit int) ([]domain.ScoredMember, error) {
+	ctx, span := cache.tracer.Start(ctx, "TweetRedisCache.TopMembers")
+	defer span.End()
+
+	if len(keys) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	//the union key only exists inside the transaction
+	dest := keys[0] + ":union"
+	pipe := cache.client.TxPipeline()
+	pipe.ZUnionStore(dest, redis.ZStore{Aggregate: "SUM"}, keys...)
+	top := pipe.ZRevRangeWithScores(dest, 0, int64(limit-1))
+	pipe.Del(dest)
+	_, err := pipe.Exec()
+	if err != nil {
+		log.Printf("redis zunionstore error: %s", err)
+		return nil, err
+	}
+
+	var members []domain.ScoredMember
+	for _, entry := range top.Val() {
+		member, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+		members = append(members, domain.ScoredMember{Member: member, Score: entry.Score})
+	}
+
+	return members, nil
+}
+
+// MemberScores sums the scores of the members over the sorted sets, missing members score zero
+func (cache *TweetRedisCache) MemberScores(ctx context.Context, keys []string, members []string) (map[string]float64, error) {
+	ctx, span := cache.tracer.Start(ctx, "TweetRedisCache.MemberScores")
+	defer span.End()
+
+	scores := make(map[string]float64, len(members))
+	if len(keys) == 0 || len(members) == 0 {
+		return scores, nil
+	}
+
+	dest := keys[0] + ":union"
+	pipe := cache.client.TxPipeline()
+	pipe.ZUnionStore(dest, redis.ZStore{Aggregate: "SUM"}, keys...)
+	results := make([]*redis.FloatCmd, len(members))
+	for i, member := range members {
+		results[i] = pipe.ZScore(dest, member)
+	}
+	pipe.Del(dest)
+	_, err := pipe.Exec()
+	if err != nil && err != redis.Nil {
+		log.Printf("redis zscore error: %s", err)
+		return nil, err
+	}
+
+	for i, member := range members {
+		scores[member] = results[i].Val()
+	}
+
+	return scores, nil
+}
@@ -131,6 +131,29 @@ func (service *UserService) ChangeUserVisibility(ctx context.Context, userID str
 	return nil
 }
 
+func (service *UserService) SuspendUser(ctx context.Context, username string) error {
+	ctx, span := service.tracer.Start(ctx, "UserService.SuspendUser")
+	defer span.End()
+
+	service.logging.Infoln("UserService.SuspendUser : suspend service reached")
+
+	user, err := service.store.GetOneUser(ctx, username)
+	if err != nil {
+		service.logging.Errorln("UserService failed to get user")
+		return fmt.Errorf(errors.UserNotFound)
+	}
+
+	user.Suspended = true
+	err = service.store.UpdateUser(ctx, user)
+	if err != nil {
+		service.logging.Errorln("UserService failed to update user")
+		log.Printf("Updating user error in service: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (service *UserService) DeleteUserByID(ctx context.Context, id primitive.ObjectID) error {
 	ctx, span := service.tracer.Start(ctx, "UserService.DeleteUserByID")
 	defer span.End()
@@ -198,6 +221,8 @@ func (service *UserService) UserToDomain(userIn create_user.User) domain.User {
 	user.Username = userIn.Username
 	if userIn.UserType == "Regular" {
 		user.UserType = "Regular"
+	} else if userIn.UserType == "Moderator" {
+		user.UserType = "Moderator"
 	} else {
 		user.UserType = "Business"
 	}
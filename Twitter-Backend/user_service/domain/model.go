@@ -15,6 +15,7 @@ type User struct {
 	Username  string             `bson:"username" json:"username"`
 	UserType  UserType           `bson:"userType" json:"userType"`
 	Privacy   bool               `bson:"privacy" json:"privacy"`
+	Suspended bool               `bson:"suspended" json:"suspended"`
 
 	CompanyName string `bson:"companyName,omitempty" json:"companyName,omitempty"`
 	Website     string `bson:"website,omitempty" json:"website,omitempty"`
@@ -30,6 +31,7 @@ const (
 type UserType string
 
 const (
-	Regular  = "Regular"
-	Business = "Business"
+	Regular   = "Regular"
+	Business  = "Business"
+	Moderator = "Moderator"
 )
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	events "github.com/zjalicf/twitter-clone-common/common/saga/create_user"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"go.opentelemetry.io/otel/trace"
 	"user_service/application"
+	"user_service/errors"
 )
 
 type CreateUserCommandHandler struct {
@@ -30,7 +32,9 @@ func NewCreateUserCommandHandler(userService *application.UserService, publisher
 	return o, nil
 }
 
-func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand) {
+// handle returns a failure to have the command redelivered, the saga is only rolled back when
+// the user is invalid or the last delivery fails
+func (handler *CreateUserCommandHandler) handle(command *events.CreateUserCommand, delivery jetstream.Delivery) error {
 
 	user := handler.userService.UserToDomain(command.User)
 	reply := events.CreateUserReply{User: command.User}
@@ -41,6 +45,9 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 
 		_, err := handler.userService.Register(context.Background(), &user)
 		if err != nil {
+			if err.Error() != errors.ValidationError && !delivery.Last {
+				return err
+			}
 			reply.Type = events.UsersFailed
 		} else {
 			reply.Type = events.UsersUpdated
@@ -48,7 +55,10 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 		}
 
 	case events.RollbackUsers:
-		_ = handler.userService.DeleteUserByID(context.Background(), user.ID)
+		err := handler.userService.DeleteUserByID(context.Background(), user.ID)
+		if err != nil && !delivery.Last {
+			return err
+		}
 		reply.Type = events.UsersFailed
 		fmt.Println("Rollback users")
 
@@ -57,6 +67,7 @@ func (handler *CreateUserCommandHandler) handle(command *events.CreateUserComman
 	}
 
 	if reply.Type != events.UnknownReply {
-		_ = handler.replyPublisher.Publish(reply)
+		return handler.replyPublisher.Publish(reply)
 	}
+	return nil
 }
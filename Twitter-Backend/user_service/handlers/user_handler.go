@@ -55,6 +55,7 @@ func (handler *UserHandler) Init(router *mux.Router) {
 	router.HandleFunc("/getMe/", handler.GetMe).Methods("GET")
 	router.HandleFunc("/mailExist/{mail}", handler.MailExist).Methods("GET")
 	router.HandleFunc("/visibility", handler.ChangeVisibility).Methods("PUT")
+	router.HandleFunc("/suspend/{username}", handler.SuspendUser).Methods("PUT")
 	http.Handle("/", router)
 	log.Fatal(http.ListenAndServe(":8002", authorization.Authorizer(authEnforcer)(router)))
 }
@@ -172,6 +173,33 @@ func (handler *UserHandler) ChangeVisibility(writer http.ResponseWriter, req *ht
 	writer.WriteHeader(http.StatusOK)
 }
 
+func (handler *UserHandler) SuspendUser(writer http.ResponseWriter, req *http.Request) {
+	ctx, span := handler.tracer.Start(req.Context(), "UserHandler.SuspendUser")
+	defer span.End()
+
+	handler.logging.Infoln("UserHandler.SuspendUser : suspend endpoint reached")
+
+	vars := mux.Vars(req)
+	username, ok := vars["username"]
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := handler.service.SuspendUser(ctx, username)
+	if err != nil {
+		handler.logging.Errorln(err)
+		if err.Error() == errors.UserNotFound {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
 func (handler *UserHandler) GetOne(writer http.ResponseWriter, req *http.Request) {
 	ctx, span := handler.tracer.Start(req.Context(), "UserHandler.GetOne")
 	defer span.End()
@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	saga "github.com/zjalicf/twitter-clone-common/common/saga/messaging"
+	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/jetstream"
 	"github.com/zjalicf/twitter-clone-common/common/saga/messaging/nats"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.opentelemetry.io/otel"
@@ -91,8 +92,8 @@ func (server *Server) Start() {
 	}(mongoClient, context.Background())
 
 	//saga init
-	replyPublisher := server.initPublisher(server.config.CreateUserReplySubject)
-	commandSubscriber := server.initSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
+	replyPublisher := server.initSagaPublisher(server.config.CreateUserReplySubject)
+	commandSubscriber := server.initSagaSubscriber(server.config.CreateUserCommandSubject, QueueGroup)
 
 	cfg := config.NewConfig()
 
@@ -164,6 +165,28 @@ func (server *Server) initSubscriber(subject string, queueGroup string) saga.Sub
 	return subscriber
 }
 
+// initSagaPublisher publishes to a JetStream stream, saga messages are kept until the
+// services handling them are up
+func (server *Server) initSagaPublisher(subject string) saga.Publisher {
+	publisher, err := jetstream.NewJetStreamPublisher(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return publisher
+}
+
+func (server *Server) initSagaSubscriber(subject string, queueGroup string) saga.Subscriber {
+	subscriber, err := jetstream.NewJetStreamSubscriber(
+		server.config.NatsHost, server.config.NatsPort,
+		server.config.NatsUser, server.config.NatsPass, subject, queueGroup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return subscriber
+}
+
 func (server *Server) start(userHandler *handlers.UserHandler) {
 	router := mux.NewRouter()
 	userHandler.Init(router)
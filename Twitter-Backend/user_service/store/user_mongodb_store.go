@@ -69,6 +69,13 @@ func (store *UserMongoDBStore) Post(ctx context.Context, user *domain.User) (*do
 	store.logging.Infoln("Store: post reached")
 
 	result, err := store.users.InsertOne(context.TODO(), user)
+	if mongo.IsDuplicateKeyError(err) {
+		//a redelivered registration finds the user it saved before
+		count, countErr := store.users.CountDocuments(ctx, bson.M{"_id": user.ID})
+		if countErr == nil && count == 1 {
+			return user, nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import { AbstractControl, FormBuilder, FormControl, FormGroup, Validators } from
 import { Router } from '@angular/router';
 import { AddTweetDTO } from 'src/app/dto/addTweetDTO';
 import { AdConfig } from 'src/app/models/adConfig';
+import { Campaign } from 'src/app/models/campaign.model';
 import { Tweet } from 'src/app/models/tweet.model';
 import { User } from 'src/app/models/user.model';
 import { FollowService } from 'src/app/services/follow.service';
@@ -37,9 +38,12 @@ export class TweetAddComponent implements OnInit {
     residence: new FormControl(''),
     gender: new FormControl(''),
     age_from: new FormControl(''),
-    age_to: new FormControl('')
+    age_to: new FormControl(''),
+    campaign_id: new FormControl('')
   })
 
+  campaigns: Campaign[] = [];
+
   file!: File;
   formData = new FormData();
 
@@ -58,13 +62,25 @@ export class TweetAddComponent implements OnInit {
       residence: ['', [Validators.required, Validators.minLength(3), Validators.maxLength(35)]],
       gender: ['', [Validators.required]],
       age_from: ['', [Validators.required, Validators.min(18), Validators.max(100)]],
-      age_to: ['', [Validators.required, Validators.min(18), Validators.max(100)]]
+      age_to: ['', [Validators.required, Validators.min(18), Validators.max(100)]],
+      campaign_id: ['', [Validators.required]]
     })
 
     this.userService.GetMe()
       .subscribe({
         next: (data: User) => {
             this.user = data;
+            if (this.isBusiness()) {
+              this.followService.GetCampaigns()
+                .subscribe({
+                  next: (campaigns: Campaign[]) => {
+                    this.campaigns = campaigns;
+                  },
+                  error: (error) => {
+                    console.log(error);
+                  }
+                })
+            }
         },
         error: (error) => {
           console.log(error);
@@ -132,6 +148,7 @@ export class TweetAddComponent implements OnInit {
             adConfig.age_to = this.advertisementFormGroup.get("age_to")?.value   
             adConfig.gender = this.advertisementFormGroup.get("gender")?.value
             adConfig.residence = this.advertisementFormGroup.get("residence")?.value
+            adConfig.campaign_id = this.advertisementFormGroup.get("campaign_id")?.value
             
             this.followService.CreateAdd(adConfig).subscribe()
           }
@@ -4,6 +4,8 @@ export class AdConfig {
 	gender:    string = ""
 	age_from:   number = 0 
 	age_to:     number = 0
+	// ads are only served as part of a campaign of the advertiser
+	campaign_id: string = ""
 
     AdConfig(tweet_id: string, residence: string, gender: string, age_from: number, age_to: number) {
         this.tweet_id = tweet_id
@@ -0,0 +1,5 @@
+export class Campaign {
+    id: string = ""
+    name: string = ""
+    status: string = ""
+}
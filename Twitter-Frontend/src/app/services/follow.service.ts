@@ -3,6 +3,7 @@ import { Injectable } from '@angular/core';
 import { Observable } from 'rxjs';
 import { environment } from 'src/environments/environment';
 import { AdConfig } from '../models/adConfig';
+import { Campaign } from '../models/campaign.model';
 import { FollowRequest } from '../models/followRequest.model';
 import { User } from '../models/user.model';
 
@@ -36,6 +37,10 @@ export class FollowService {
     return this.http.post<any>(`${environment.baseApiUrl}/${this.url}/ad`, adConfig)
   }
 
+  public GetCampaigns(): Observable<Campaign[]> {
+    return this.http.get<Campaign[]>(`${environment.baseApiUrl}/${this.url}/campaigns`)
+  }
+
   public Recommendations(): Observable<string[]> {
     return this.http.get<string[]>(`${environment.baseApiUrl}/${this.url}/recommendations`)
   }